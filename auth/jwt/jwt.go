@@ -0,0 +1,261 @@
+// Package jwt implements signed JSON Web Tokens carrying the claims of user sessions,
+// which are verified without retrieving their session. Tokens are signed with HS256,
+// RS256 or EdDSA keys, where each key is named within the token by it's kid to allow
+// keys to be rotated.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// contains the algorithms of the keys tokens are signed with.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// contains the errors returned by Verify, where ErrExpired and ErrUnknownKey match
+// ErrInvalidToken with errors.Is.
+var (
+	ErrInvalidToken = errors.New("Invalid token")
+	ErrExpired      = fmt.Errorf("Token has expired: %w", ErrInvalidToken)
+	ErrUnknownKey   = fmt.Errorf("Token is signed with an unknown key: %w", ErrInvalidToken)
+)
+
+// Claims defines the claims of the session a token is issued for, where Roles are the
+// roles of the session's user when the token was issued.
+type Claims struct {
+	Subject  string   `json:"sub"`
+	Session  string   `json:"sid"`
+	Roles    []string `json:"roles,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	IssuedAt int64    `json:"iat"`
+	Expires  int64    `json:"exp"`
+}
+
+// ExpiresAt returns the time the claims expire at.
+func (c Claims) ExpiresAt() time.Time {
+	return time.Unix(c.Expires, 0).UTC()
+}
+
+// header defines the header of a token.
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// Key defines a key which signs and verifies tokens with it's Algorithm, named by it's ID.
+// Keys holding only a public key verify tokens but do not sign them.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	secret  []byte
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// HS256 returns a Key signing tokens with HMAC-SHA256 keyed by the secret.
+func HS256(id string, secret []byte) Key {
+	return Key{ID: id, Algorithm: AlgHS256, secret: secret}
+}
+
+// RS256 returns a Key signing tokens with RSASSA-PKCS1-v1_5 using SHA-256.
+func RS256(id string, private *rsa.PrivateKey) Key {
+	return Key{ID: id, Algorithm: AlgRS256, private: private, public: &private.PublicKey}
+}
+
+// RS256Public returns a Key verifying tokens signed by the RS256 key of the public key.
+func RS256Public(id string, public *rsa.PublicKey) Key {
+	return Key{ID: id, Algorithm: AlgRS256, public: public}
+}
+
+// EdDSA returns a Key signing tokens with Ed25519.
+func EdDSA(id string, private ed25519.PrivateKey) Key {
+	return Key{ID: id, Algorithm: AlgEdDSA, private: private, public: private.Public()}
+}
+
+// EdDSAPublic returns a Key verifying tokens signed by the EdDSA key of the public key.
+func EdDSAPublic(id string, public ed25519.PublicKey) Key {
+	return Key{ID: id, Algorithm: AlgEdDSA, public: public}
+}
+
+// CanSign returns true/false if the key signs tokens.
+func (k Key) CanSign() bool {
+	return k.secret != nil || k.private != nil
+}
+
+// sign returns the signature of the data.
+func (k Key) sign(data []byte) ([]byte, error) {
+	switch k.Algorithm {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		return k.private.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case AlgEdDSA:
+		return k.private.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+
+	return nil, fmt.Errorf("Unknown algorithm %q", k.Algorithm)
+}
+
+// verify returns true/false if the signature is the signature of the data.
+func (k Key) verify(data []byte, signature []byte) bool {
+	switch k.Algorithm {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case AlgRS256:
+		public, ok := k.public.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature) == nil
+	case AlgEdDSA:
+		public, ok := k.public.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+
+		return ed25519.Verify(public, data, signature)
+	}
+
+	return false
+}
+
+// Keys defines the set of keys tokens are verified with, where new tokens are signed
+// with the key named by Signing. Keys are rotated by signing with a new key, while
+// keeping the previous keys until the tokens signed with them have expired.
+type Keys struct {
+	Signing string
+
+	keys map[string]Key
+}
+
+// NewKeys returns a new Keys signing with the key of the signing id.
+func NewKeys(signing string, keys ...Key) (*Keys, error) {
+	set := Keys{Signing: signing, keys: make(map[string]Key, len(keys))}
+
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("Key has no id")
+		}
+
+		if _, ok := set.keys[key.ID]; ok {
+			return nil, fmt.Errorf("Duplicate key %q", key.ID)
+		}
+
+		set.keys[key.ID] = key
+	}
+
+	if key, ok := set.keys[signing]; !ok || !key.CanSign() {
+		return nil, fmt.Errorf("Signing key %q is not a signing key of the set", signing)
+	}
+
+	return &set, nil
+}
+
+// Sign returns a new token of the claims, signed with the signing key.
+func (k *Keys) Sign(claims Claims) (string, error) {
+	key := k.keys[k.Signing]
+
+	head, err := json.Marshal(header{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	data := encode(head) + "." + encode(body)
+
+	signature, err := key.sign([]byte(data))
+	if err != nil {
+		return "", err
+	}
+
+	return data + "." + encode(signature), nil
+}
+
+// Verify returns the claims of the token if it is signed by the key of it's kid with
+// the algorithm of the key, and has not expired.
+func (k *Keys) Verify(token string) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, fmt.Errorf("Token must be Header.Claims.Signature format: %w", ErrInvalidToken)
+	}
+
+	var head header
+
+	if err := decode(parts[0], &head); err != nil {
+		return claims, err
+	}
+
+	key, ok := k.keys[head.KeyID]
+	if !ok {
+		return claims, ErrUnknownKey
+	}
+
+	// The algorithm is that of the key, as tokens could otherwise choose to be verified
+	// with a weaker algorithm.
+	if head.Algorithm != key.Algorithm {
+		return claims, fmt.Errorf("Token algorithm %q does not match it's key: %w", head.Algorithm, ErrInvalidToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !key.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return claims, fmt.Errorf("Token signature does not match: %w", ErrInvalidToken)
+	}
+
+	if err := decode(parts[1], &claims); err != nil {
+		return claims, err
+	}
+
+	if !time.Now().Before(claims.ExpiresAt()) {
+		return claims, ErrExpired
+	}
+
+	return claims, nil
+}
+
+// encode returns the data as unpadded url safe base64.
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decode decodes the unpadded url safe base64 json of a token part into the target.
+func decode(part string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%s: %w", err, ErrInvalidToken)
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("%s: %w", err, ErrInvalidToken)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/backoffice/auth/jwt"
+	"github.com/influx6/faux/tests"
+)
+
+// TestKeys validates the signing and verification of tokens with each algorithm.
+func TestKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		tests.Failed("Should have successfully generated rsa key: %+q.", err)
+	}
+	tests.Passed("Should have successfully generated rsa key.")
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tests.Failed("Should have successfully generated ed25519 key: %+q.", err)
+	}
+	tests.Passed("Should have successfully generated ed25519 key.")
+
+	claims := jwt.Claims{
+		Subject:  "bob",
+		Session:  "laptop",
+		Scopes:   []string{"users:read"},
+		IssuedAt: time.Now().Unix(),
+		Expires:  time.Now().Add(time.Hour).Unix(),
+	}
+
+	t.Logf("Given the need to sign tokens with each algorithm")
+	{
+		for _, key := range []jwt.Key{
+			jwt.HS256("hs", []byte("secret")),
+			jwt.RS256("rs", rsaKey),
+			jwt.EdDSA("ed", edKey),
+		} {
+			t.Logf("\tWhen signing with a %s key", key.Algorithm)
+			{
+				keys, err := jwt.NewKeys(key.ID, key)
+				if err != nil {
+					tests.Failed("Should have successfully created keys: %+q.", err)
+				}
+				tests.Passed("Should have successfully created keys.")
+
+				token, err := keys.Sign(claims)
+				if err != nil {
+					tests.Failed("Should have successfully signed token: %+q.", err)
+				}
+				tests.Passed("Should have successfully signed token.")
+
+				verified, err := keys.Verify(token)
+				if err != nil {
+					tests.Failed("Should have successfully verified token: %+q.", err)
+				}
+				tests.Passed("Should have successfully verified token.")
+
+				if verified.Subject != "bob" || verified.Session != "laptop" || len(verified.Scopes) != 1 {
+					tests.Info("Claims: %+v", verified)
+					tests.Failed("Should have matched claims of token.")
+				}
+				tests.Passed("Should have matched claims of token.")
+
+				parts := strings.Split(token, ".")
+				forged := parts[0] + "." + parts[1] + "x." + parts[2]
+
+				if _, err := keys.Verify(forged); !errors.Is(err, jwt.ErrInvalidToken) {
+					tests.Failed("Should have rejected token with altered claims: %+q.", err)
+				}
+				tests.Passed("Should have rejected token with altered claims.")
+			}
+		}
+	}
+
+	t.Logf("Given the need to verify tokens with public keys")
+	{
+		signing, err := jwt.NewKeys("rs", jwt.RS256("rs", rsaKey))
+		if err != nil {
+			tests.Failed("Should have successfully created signing keys: %+q.", err)
+		}
+		tests.Passed("Should have successfully created signing keys.")
+
+		token, err := signing.Sign(claims)
+		if err != nil {
+			tests.Failed("Should have successfully signed token: %+q.", err)
+		}
+		tests.Passed("Should have successfully signed token.")
+
+		if _, err := jwt.NewKeys("rs", jwt.RS256Public("rs", &rsaKey.PublicKey)); err == nil {
+			tests.Failed("Should have refused signing with a public key.")
+		}
+		tests.Passed("Should have refused signing with a public key.")
+
+		verifying, err := jwt.NewKeys("hs", jwt.HS256("hs", []byte("secret")), jwt.RS256Public("rs", &rsaKey.PublicKey))
+		if err != nil {
+			tests.Failed("Should have successfully created verifying keys: %+q.", err)
+		}
+		tests.Passed("Should have successfully created verifying keys.")
+
+		if _, err := verifying.Verify(token); err != nil {
+			tests.Failed("Should have successfully verified token with public key: %+q.", err)
+		}
+		tests.Passed("Should have successfully verified token with public key.")
+	}
+
+	t.Logf("Given the need to rotate keys")
+	{
+		old, err := jwt.NewKeys("2020", jwt.HS256("2020", []byte("old-secret")))
+		if err != nil {
+			tests.Failed("Should have successfully created old keys: %+q.", err)
+		}
+		tests.Passed("Should have successfully created old keys.")
+
+		token, err := old.Sign(claims)
+		if err != nil {
+			tests.Failed("Should have successfully signed token: %+q.", err)
+		}
+		tests.Passed("Should have successfully signed token.")
+
+		rotated, err := jwt.NewKeys("2021", jwt.HS256("2021", []byte("new-secret")), jwt.HS256("2020", []byte("old-secret")))
+		if err != nil {
+			tests.Failed("Should have successfully created rotated keys: %+q.", err)
+		}
+		tests.Passed("Should have successfully created rotated keys.")
+
+		if _, err := rotated.Verify(token); err != nil {
+			tests.Failed("Should have successfully verified token of previous key: %+q.", err)
+		}
+		tests.Passed("Should have successfully verified token of previous key.")
+
+		retired, err := jwt.NewKeys("2021", jwt.HS256("2021", []byte("new-secret")))
+		if err != nil {
+			tests.Failed("Should have successfully created retired keys: %+q.", err)
+		}
+		tests.Passed("Should have successfully created retired keys.")
+
+		if _, err := retired.Verify(token); !errors.Is(err, jwt.ErrUnknownKey) {
+			tests.Failed("Should have rejected token of retired key: %+q.", err)
+		}
+		tests.Passed("Should have rejected token of retired key.")
+	}
+
+	t.Logf("Given the need to reject invalid tokens")
+	{
+		rsaKeys, err := jwt.NewKeys("rs", jwt.RS256("rs", rsaKey))
+		if err != nil {
+			tests.Failed("Should have successfully created keys: %+q.", err)
+		}
+		tests.Passed("Should have successfully created keys.")
+
+		// A token claiming HS256 with the rsa key's id must not be verified as HMAC.
+		confused, err := jwt.NewKeys("rs", jwt.HS256("rs", []byte("public")))
+		if err != nil {
+			tests.Failed("Should have successfully created confused keys: %+q.", err)
+		}
+		tests.Passed("Should have successfully created confused keys.")
+
+		token, err := confused.Sign(claims)
+		if err != nil {
+			tests.Failed("Should have successfully signed token: %+q.", err)
+		}
+		tests.Passed("Should have successfully signed token.")
+
+		if _, err := rsaKeys.Verify(token); !errors.Is(err, jwt.ErrInvalidToken) {
+			tests.Failed("Should have rejected token with algorithm of another key: %+q.", err)
+		}
+		tests.Passed("Should have rejected token with algorithm of another key.")
+
+		expired := claims
+		expired.Expires = time.Now().Add(-time.Minute).Unix()
+
+		token, err = rsaKeys.Sign(expired)
+		if err != nil {
+			tests.Failed("Should have successfully signed token: %+q.", err)
+		}
+		tests.Passed("Should have successfully signed token.")
+
+		if _, err := rsaKeys.Verify(token); !errors.Is(err, jwt.ErrExpired) {
+			tests.Failed("Should have rejected expired token: %+q.", err)
+		}
+		tests.Passed("Should have rejected expired token.")
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/influx6/backoffice/models/user"
+)
+
+// migrate applies, reverts or lists the backoffice migrations.
+func migrate(e *env, args []string) error {
+	fs := e.Flags()
+	steps := fs.Int("steps", 1, "number of migrations to revert with down")
+
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("missing up, down or status")
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	migrator := e.Migrator()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Apply()
+		for _, migration := range applied {
+			fmt.Printf("applied %d %s\n", migration.Version, migration.Name)
+		}
+
+		return err
+	case "down":
+		reverted, err := migrator.Rollback(*steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %d %s\n", migration.Version, migration.Name)
+		}
+
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+
+		for _, status := range statuses {
+			fmt.Fprintf(w, "%d\t%s\t%t\n", status.Version, status.Name, status.Applied)
+		}
+
+		return w.Flush()
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate action %q", args[0])
+	}
+}
+
+// createUser creates a new user with a profile.
+func createUser(e *env, args []string) error {
+	fs := e.Flags()
+	email := fs.String("email", "", "email of the user")
+	password := fs.String("password", "", "password of the user, read from stdin if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *email == "" {
+		fs.Usage()
+		return errors.New("missing -email")
+	}
+
+	pass, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	nu, err := e.Users().Create(user.NewUser{Email: *email, Password: pass})
+	if err != nil {
+		return err
+	}
+
+	return printJSON(os.Stdout, nu.SafeFields())
+}
+
+// resetPassword sets a new password for an existing user.
+func resetPassword(e *env, args []string) error {
+	fs := e.Flags()
+	id := fs.String("id", "", "public_id of the user")
+	email := fs.String("email", "", "email of the user, used if -id is empty")
+	password := fs.String("password", "", "new password of the user, read from stdin if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	users := e.Users()
+
+	userID := *id
+	if userID == "" {
+		if *email == "" {
+			fs.Usage()
+			return errors.New("missing -id or -email")
+		}
+
+		nu, err := users.GetByEmail(*email)
+		if err != nil {
+			return err
+		}
+
+		userID = nu.PublicID
+	}
+
+	pass, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	if err := users.UpdatePassword(user.UpdateUserPassword{PublicID: userID, Password: pass}); err != nil {
+		return err
+	}
+
+	fmt.Printf("password updated for %s\n", userID)
+
+	return nil
+}
+
+// setRoles replaces the roles of a user, such as granting the admin role.
+func setRoles(e *env, args []string) error {
+	fs := e.Flags()
+	id := fs.String("id", "", "public_id of the user")
+	email := fs.String("email", "", "email of the user, used if -id is empty")
+	roles := fs.String("roles", "", "comma separated roles of the user, none if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	users := e.Users()
+
+	userID := *id
+	if userID == "" {
+		if *email == "" {
+			fs.Usage()
+			return errors.New("missing -id or -email")
+		}
+
+		nu, err := users.GetByEmail(*email)
+		if err != nil {
+			return err
+		}
+
+		userID = nu.PublicID
+	}
+
+	var names []string
+	for _, role := range strings.Split(*roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			names = append(names, role)
+		}
+	}
+
+	if err := users.SetRoles(user.UpdateUserRoles{PublicID: userID, Roles: names}); err != nil {
+		return err
+	}
+
+	fmt.Printf("roles of %s set to %q\n", userID, strings.Join(names, ","))
+
+	return nil
+}
+
+// verifyUser marks the email of a user as verified, such as for users created before
+// emails were verified.
+func verifyUser(e *env, args []string) error {
+	fs := e.Flags()
+	id := fs.String("id", "", "public_id of the user")
+	email := fs.String("email", "", "email of the user, used if -id is empty")
+	unset := fs.Bool("unset", false, "mark the email as unverified instead")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	users := e.Users()
+
+	userID := *id
+	if userID == "" {
+		if *email == "" {
+			fs.Usage()
+			return errors.New("missing -id or -email")
+		}
+
+		nu, err := users.GetByEmail(*email)
+		if err != nil {
+			return err
+		}
+
+		userID = nu.PublicID
+	}
+
+	if err := users.SetVerified(user.UpdateUserVerified{PublicID: userID, Verified: !*unset}); err != nil {
+		return err
+	}
+
+	fmt.Printf("verified of %s set to %t\n", userID, !*unset)
+
+	return nil
+}
+
+// listSessions prints all user sessions.
+func listSessions(e *env, args []string) error {
+	fs := e.Flags()
+	page := fs.Int("page", 0, "page of sessions to list, all if 0")
+	perPage := fs.Int("per-page", 0, "number of sessions per page")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	records, err := e.Sessions().GetAll(*page, *perPage)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PUBLIC_ID\tUSER_ID\tEXPIRES\tLAST_SEEN\tIP\tLABEL")
+
+	for _, record := range records.Records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", record.PublicID, record.UserID, record.Expires, record.LastSeen, record.IP, record.Label)
+	}
+
+	return w.Flush()
+}
+
+// revokeSession removes a single session of a user, or all of it's sessions if none is given.
+func revokeSession(e *env, args []string) error {
+	fs := e.Flags()
+	userID := fs.String("user", "", "public_id of the user")
+	sessionID := fs.String("session", "", "public_id of the session, all sessions of the user if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *userID == "" {
+		fs.Usage()
+		return errors.New("missing -user")
+	}
+
+	if *sessionID != "" {
+		if err := e.Sessions().Revoke(*userID, *sessionID); err != nil {
+			return err
+		}
+
+		fmt.Printf("session %s revoked for %s\n", *sessionID, *userID)
+
+		return nil
+	}
+
+	if err := e.Sessions().DeleteByUser(*userID); err != nil {
+		return err
+	}
+
+	fmt.Printf("sessions revoked for %s\n", *userID)
+
+	return nil
+}
+
+// hashTokens replaces the tokens of legacy sessions and refresh tokens with their hash,
+// keyed by the -session-secret the services use.
+func hashTokens(e *env, args []string) error {
+	fs := e.Flags()
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if len(e.Secret) == 0 {
+		return errors.New("missing -session-secret")
+	}
+
+	upgraded, err := e.Sessions().HashLegacyTokens()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d tokens hashed\n", upgraded)
+
+	return nil
+}
+
+// showProfile prints the profile of a user.
+func showProfile(e *env, args []string) error {
+	fs := e.Flags()
+	userID := fs.String("user", "", "public_id of the user")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *userID == "" {
+		fs.Usage()
+		return errors.New("missing -user")
+	}
+
+	nu, err := e.Profiles().GetByUser(*userID)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(os.Stdout, nu)
+}
+
+// exportUsers writes all users as JSON or CSV.
+func exportUsers(e *env, args []string) error {
+	fs := e.Flags()
+	format := fs.String("format", "json", "export format: json or csv")
+	out := fs.String("out", "", "file to write to, stdout if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format != "json" && *format != "csv" {
+		fs.Usage()
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	records, err := e.Users().GetAll(0, 0)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		w = file
+	}
+
+	if *format == "json" {
+		var users []map[string]interface{}
+
+		for _, record := range records.Records {
+			users = append(users, record.SafeFields())
+		}
+
+		return printJSON(w, users)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"public_id", "email"})
+
+	for _, record := range records.Records {
+		cw.Write([]string{record.PublicID, record.Email})
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// readPassword returns the giving password or reads one from the first line of stdin
+// if it is empty.
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("missing password")
+	}
+
+	return password, nil
+}
+
+// printJSON writes the giving value as indented JSON.
+func printJSON(w io.Writer, value interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
@@ -0,0 +1,238 @@
+// Package main implements the backoffice command, which provides operators with
+// subcommands to migrate the backoffice tables and manage users, sessions and
+// profiles stored within them.
+//
+// Usage:
+//
+//	backoffice [flags] <command> [command flags]
+//
+// All connection flags default to the BACKOFFICE_* environment variables listed
+// in the output of `backoffice -h`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/sql"
+	"github.com/influx6/backoffice/db/sql/dialects"
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/migrations/sqltables"
+	"github.com/influx6/faux/naming"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+)
+
+// command defines a subcommand of the backoffice command.
+type command struct {
+	Usage string
+	Run   func(env *env, args []string) error
+}
+
+// commands contains all subcommands keyed by their name.
+var commands = map[string]command{
+	"migrate":        {Usage: "migrate up|down|status [-steps n]: applies, reverts or lists migrations", Run: migrate},
+	"create-user":    {Usage: "create-user -email e [-password p]: creates a new user with a profile", Run: createUser},
+	"reset-password": {Usage: "reset-password -id id|-email e [-password p]: sets a new password for a user", Run: resetPassword},
+	"set-roles":      {Usage: "set-roles -id id|-email e -roles r1,r2: replaces the roles of a user", Run: setRoles},
+	"verify-user":    {Usage: "verify-user -id id|-email e [-unset]: marks the email of a user as verified, or unverified", Run: verifyUser},
+	"list-sessions":  {Usage: "list-sessions [-page n -per-page n]: lists all user sessions", Run: listSessions},
+	"revoke-session": {Usage: "revoke-session -user id [-session id]: removes a session, or all sessions, of a user", Run: revokeSession},
+	"hash-tokens":    {Usage: "hash-tokens: replaces the tokens of sessions created before tokens were hashed with their hash", Run: hashTokens},
+	"show-profile":   {Usage: "show-profile -user id: prints the profile of a user", Run: showProfile},
+	"export-users":   {Usage: "export-users [-format json|csv] [-out file]: exports all users", Run: exportUsers},
+}
+
+func main() {
+	var conn sql.Conn
+	var dialect, prefix, expiry, refreshExpiry, secret string
+	var verbose bool
+
+	flag.StringVar(&dialect, "dialect", envOr("BACKOFFICE_DIALECT", "mysql"), "sql dialect: mysql, postgres or sqlite (BACKOFFICE_DIALECT)")
+	flag.StringVar(&conn.Addr, "addr", envOr("BACKOFFICE_DB_ADDR", "localhost"), "db server address (BACKOFFICE_DB_ADDR)")
+	flag.IntVar(&conn.Port, "port", envInt("BACKOFFICE_DB_PORT", 3306), "db server port (BACKOFFICE_DB_PORT)")
+	flag.StringVar(&conn.User, "user", envOr("BACKOFFICE_DB_USER", ""), "db user (BACKOFFICE_DB_USER)")
+	flag.StringVar(&conn.Password, "password", envOr("BACKOFFICE_DB_PASSWORD", ""), "db password (BACKOFFICE_DB_PASSWORD)")
+	flag.StringVar(&conn.Database, "database", envOr("BACKOFFICE_DB_NAME", ""), "db name or sqlite file (BACKOFFICE_DB_NAME)")
+	flag.StringVar(&prefix, "prefix", envOr("BACKOFFICE_TABLE_PREFIX", ""), "prefix of the table names (BACKOFFICE_TABLE_PREFIX)")
+	flag.StringVar(&expiry, "session-expiry", envOr("BACKOFFICE_SESSION_EXPIRY", "24h"), "expiry of user sessions (BACKOFFICE_SESSION_EXPIRY)")
+	flag.StringVar(&refreshExpiry, "refresh-expiry", envOr("BACKOFFICE_REFRESH_EXPIRY", "720h"), "expiry of session refresh tokens (BACKOFFICE_REFRESH_EXPIRY)")
+	flag.StringVar(&secret, "session-secret", envOr("BACKOFFICE_SESSION_SECRET", ""), "secret keying the hashes of session tokens (BACKOFFICE_SESSION_SECRET)")
+	flag.BoolVar(&verbose, "v", false, "print db logs")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "backoffice: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	conn.Dialect = dialects.ForDriver(dialect)
+	if conn.Dialect == nil {
+		fmt.Fprintf(os.Stderr, "backoffice: unknown dialect %q\n", dialect)
+		os.Exit(2)
+	}
+
+	sessionExpiry, err := time.ParseDuration(expiry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backoffice: invalid session expiry: %s\n", err)
+		os.Exit(2)
+	}
+
+	refreshTokenExpiry, err := time.ParseDuration(refreshExpiry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backoffice: invalid refresh expiry: %s\n", err)
+		os.Exit(2)
+	}
+
+	var log sink.Sink = silent{}
+	if verbose {
+		log = sink.New(sinks.Stdout{})
+	}
+
+	conn.Log = log
+
+	var names db.Namer = plainNamer{}
+	if prefix != "" {
+		names = naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: prefix})
+	}
+
+	store := sql.New(log, conn)
+	defer store.Close()
+
+	e := &env{
+		Log:     log,
+		Store:   store,
+		Names:   names,
+		Expiry:  sessionExpiry,
+		Refresh: refreshTokenExpiry,
+		Secret:  []byte(secret),
+		Command: flag.Arg(0),
+		Usage:   cmd.Usage,
+	}
+
+	if err := cmd.Run(e, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "backoffice: %s: %s\n", flag.Arg(0), err)
+		store.Close()
+		os.Exit(1)
+	}
+}
+
+// env defines the shared state provided to all subcommands.
+type env struct {
+	Log     sink.Sink
+	Store   *sql.SQL
+	Names   db.Namer
+	Expiry  time.Duration
+	Refresh time.Duration
+	Secret  []byte
+	Command string
+	Usage   string
+}
+
+// Migrator returns a Migrator for the backoffice migrations.
+func (e *env) Migrator() *sql.Migrator {
+	return sql.NewMigrator(e.Log, e.Store, sqltables.Migrations(e.Names)...)
+}
+
+// Users returns the Users handler for the backoffice tables.
+func (e *env) Users() handlers.Users {
+	return handlers.UsersFactory(e.Log, e.Store, e.table("users"), e.table("profiles"))
+}
+
+// Profiles returns the Profiles handler for the backoffice tables.
+func (e *env) Profiles() handlers.Profiles {
+	return handlers.ProfilesFactory(e.Log, e.Store, e.table("profiles"))
+}
+
+// Sessions returns the Sessions handler for the backoffice tables.
+func (e *env) Sessions() handlers.Sessions {
+	return handlers.RefreshSessionsFactory(e.Log, e.Store, e.Secret, e.Expiry, e.table("sessions"), e.table("refresh_tokens"), e.Refresh)
+}
+
+// Flags returns a new FlagSet for the current subcommand.
+func (e *env) Flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(e.Command, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: backoffice %s\n", e.Usage)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// table returns the TableName of the giving backoffice table.
+func (e *env) table(name string) db.TableName {
+	return db.TableName{Name: e.Names.New(name)}
+}
+
+// usage prints the usage of the backoffice command.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: backoffice [flags] <command> [command flags]\n\nCommands:\n")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].Usage)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// envOr returns the value of the environment variable or def if it is not set.
+func envOr(key string, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return def
+}
+
+// envInt returns the integer value of the environment variable or def if it is
+// not set or invalid.
+func envInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+
+	return value
+}
+
+// plainNamer implements db.Namer by returning table names as is.
+type plainNamer struct{}
+
+// New returns the giving name.
+func (plainNamer) New(name string) string {
+	return name
+}
+
+// silent implements sink.Sink by discarding all entries.
+type silent struct{}
+
+// Emit discards the giving entry.
+func (silent) Emit(sink.Entry) error {
+	return nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cursor defines the position of a record within the records of a table ordered by
+// their created_at and public_id fields, which remains stable as records are added.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	PublicID  string    `json:"p"`
+
+	// Before is true if the cursor retrieves the records before the position rather
+	// than after it.
+	Before bool `json:"b,omitempty"`
+}
+
+// Encode returns the cursor as an opaque url safe string.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns the Cursor of a string returned by Cursor.Encode.
+func DecodeCursor(value string) (Cursor, error) {
+	var c Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, fmt.Errorf("Invalid cursor: %s", err)
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("Invalid cursor: %s", err)
+	}
+
+	if c.PublicID == "" {
+		return c, fmt.Errorf("Invalid cursor: missing public_id")
+	}
+
+	return c, nil
+}
+
+// CursorOf returns the Cursor at the position of the giving record.
+func CursorOf(record map[string]interface{}, before bool) (Cursor, error) {
+	publicID, ok := record["public_id"].(string)
+	if !ok {
+		return Cursor{}, fmt.Errorf("Record has no public_id for cursor")
+	}
+
+	createdAt, err := TimeOf(record["created_at"])
+	if err != nil {
+		return Cursor{}, fmt.Errorf("Record created_at %s", err)
+	}
+
+	if createdAt.IsZero() {
+		return Cursor{}, fmt.Errorf("Record has no created_at for cursor")
+	}
+
+	return Cursor{CreatedAt: createdAt, PublicID: publicID, Before: before}, nil
+}
+
+// timeLayouts contains the layouts of the timestamps returned as strings by drivers.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999"}
+
+// TimeOf returns the time, in UTC, of a timestamp field of a record, which drivers return
+// either as a time.Time or as a string. The zero time is returned if the field is missing
+// or empty, where callers requiring the field must check for it.
+func TimeOf(value interface{}) (time.Time, error) {
+	switch co := value.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return co.UTC(), nil
+	case []byte:
+		return TimeOf(string(co))
+	case string:
+		if co == "" {
+			return time.Time{}, nil
+		}
+
+		for _, layout := range timeLayouts {
+			if parsed, err := time.Parse(layout, co); err == nil {
+				return parsed.UTC(), nil
+			}
+		}
+
+		return time.Time{}, fmt.Errorf("%q is not a time", co)
+	default:
+		return time.Time{}, fmt.Errorf("%T is not a time", co)
+	}
+}
+
+// Page defines a page of records retrieved with FindPage, where Next and Prev are the
+// encoded cursors of the following and preceding pages, being empty if there is none.
+type Page struct {
+	Records []map[string]interface{}
+	Next    string
+	Prev    string
+}
+
+// FindPage retrieves up to limit records of the table matching the predicates, ordered
+// by their created_at and public_id fields. Records are retrieved after, or before,
+// the position of the encoded cursor, starting from the first record if it is empty.
+func FindPage(ctx context.Context, d DB, t TableIdentity, where []Predicate, cursor string, limit int) (Page, error) {
+	var page Page
+
+	if limit <= 0 {
+		return page, fmt.Errorf("Page limit must be positive: %d", limit)
+	}
+
+	query := Query{
+		Where: append([]Predicate(nil), where...),
+		Order: []Order{{Field: "created_at"}, {Field: "public_id"}},
+		Limit: limit + 1,
+	}
+
+	var current Cursor
+
+	if cursor != "" {
+		var err error
+		if current, err = DecodeCursor(cursor); err != nil {
+			return page, err
+		}
+
+		op := Gt
+		if current.Before {
+			op = Lt
+			query.Order = []Order{{Field: "created_at", Desc: true}, {Field: "public_id", Desc: true}}
+		}
+
+		query.Where = append(query.Where, Or(
+			Where("created_at", op, current.CreatedAt),
+			And(Where("created_at", Eq, current.CreatedAt), Where("public_id", op, current.PublicID)),
+		))
+	}
+
+	records, err := WithContext(d).FindCtx(ctx, t, query)
+	if err != nil {
+		return page, err
+	}
+
+	// The extra record retrieved reports if there are more records in the direction
+	// of the cursor.
+	more := len(records) > limit
+	if more {
+		records = records[:limit]
+	}
+
+	if current.Before {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+
+	page.Records = records
+
+	if len(records) == 0 {
+		return page, nil
+	}
+
+	hasNext, hasPrev := more, cursor != ""
+	if current.Before {
+		hasNext, hasPrev = true, more
+	}
+
+	if hasNext {
+		next, err := CursorOf(records[len(records)-1], false)
+		if err != nil {
+			return page, err
+		}
+
+		page.Next = next.Encode()
+	}
+
+	if hasPrev {
+		prev, err := CursorOf(records[0], true)
+		if err != nil {
+			return page, err
+		}
+
+		page.Prev = prev.Encode()
+	}
+
+	return page, nil
+}
@@ -1,5 +1,7 @@
 package db
 
+import "context"
+
 // contains templates of sql statement for use in operations.
 const (
 	countTemplate         = "SELECT %s FROM %s"
@@ -36,7 +38,8 @@ type Migration interface {
 }
 
 // DB defines a type which allows CRUD operations provided by a underline
-// db structure.
+// db structure. Get, Update and Delete return an error matching ErrNotFound when no
+// record matches the giving index and value.
 type DB interface {
 	Save(t TableIdentity, f TableFields) error
 	Count(t TableIdentity) (int, error)
@@ -45,6 +48,154 @@ type DB interface {
 	Get(t TableIdentity, c TableConsumer, index string, value interface{}) error
 	GetAll(t TableIdentity, order string, orderBy string) ([]map[string]interface{}, error)
 	GetAllPerPage(t TableIdentity, order string, orderBy string, page int, responsePage int) ([]map[string]interface{}, int, error)
+
+	// Find retrieves the records of the table matching the giving Query.
+	Find(t TableIdentity, q Query) ([]map[string]interface{}, error)
+
+	// CountWhere returns the number of records of the table matching all the giving
+	// predicates, as Find would retrieve for a Query of them.
+	CountWhere(t TableIdentity, where []Predicate) (int, error)
+
+	// UpdateWhere updates the records of the table matching all the giving predicates
+	// with the fields, returning the number of records updated. It allows a record to
+	// be updated only if it is still in an expected state, as a compare-and-swap.
+	UpdateWhere(t TableIdentity, f TableFields, where []Predicate) (int, error)
+
+	// WithTx runs the giving function within a transaction, where all operations on the
+	// DB provided to it are committed only if it returns nil, else they are rolled back.
+	WithTx(fn func(tx DB) error) error
+}
+
+// ContextDB defines a DB which also exposes context aware variants of all it's operations,
+// allowing a caller to cancel or apply deadlines to the underline work.
+type ContextDB interface {
+	DB
+	SaveCtx(ctx context.Context, t TableIdentity, f TableFields) error
+	CountCtx(ctx context.Context, t TableIdentity) (int, error)
+	UpdateCtx(ctx context.Context, t TableIdentity, f TableFields, index string) error
+	DeleteCtx(ctx context.Context, t TableIdentity, index string, value interface{}) error
+	GetCtx(ctx context.Context, t TableIdentity, c TableConsumer, index string, value interface{}) error
+	GetAllCtx(ctx context.Context, t TableIdentity, order string, orderBy string) ([]map[string]interface{}, error)
+	GetAllPerPageCtx(ctx context.Context, t TableIdentity, order string, orderBy string, page int, responsePage int) ([]map[string]interface{}, int, error)
+	FindCtx(ctx context.Context, t TableIdentity, q Query) ([]map[string]interface{}, error)
+	CountWhereCtx(ctx context.Context, t TableIdentity, where []Predicate) (int, error)
+	UpdateWhereCtx(ctx context.Context, t TableIdentity, f TableFields, where []Predicate) (int, error)
+	WithTxCtx(ctx context.Context, fn func(tx DB) error) error
+}
+
+// WithContext returns the giving DB as a ContextDB. If the DB does not implement
+// ContextDB, then it is wrapped so the context is checked before every operation.
+func WithContext(d DB) ContextDB {
+	if cd, ok := d.(ContextDB); ok {
+		return cd
+	}
+
+	return contextDB{DB: d}
+}
+
+// contextDB wraps a DB which is not context aware, failing operations whoes context
+// is already done.
+type contextDB struct {
+	DB
+}
+
+// SaveCtx calls DB.Save if the context is not done.
+func (c contextDB) SaveCtx(ctx context.Context, t TableIdentity, f TableFields) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.DB.Save(t, f)
+}
+
+// CountCtx calls DB.Count if the context is not done.
+func (c contextDB) CountCtx(ctx context.Context, t TableIdentity) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.DB.Count(t)
+}
+
+// UpdateCtx calls DB.Update if the context is not done.
+func (c contextDB) UpdateCtx(ctx context.Context, t TableIdentity, f TableFields, index string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.DB.Update(t, f, index)
+}
+
+// DeleteCtx calls DB.Delete if the context is not done.
+func (c contextDB) DeleteCtx(ctx context.Context, t TableIdentity, index string, value interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.DB.Delete(t, index, value)
+}
+
+// GetCtx calls DB.Get if the context is not done.
+func (c contextDB) GetCtx(ctx context.Context, t TableIdentity, consumer TableConsumer, index string, value interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.DB.Get(t, consumer, index, value)
+}
+
+// FindCtx calls DB.Find if the context is not done.
+func (c contextDB) FindCtx(ctx context.Context, t TableIdentity, q Query) ([]map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.DB.Find(t, q)
+}
+
+// CountWhereCtx calls DB.CountWhere if the context is not done.
+func (c contextDB) CountWhereCtx(ctx context.Context, t TableIdentity, where []Predicate) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.DB.CountWhere(t, where)
+}
+
+// UpdateWhereCtx calls DB.UpdateWhere if the context is not done.
+func (c contextDB) UpdateWhereCtx(ctx context.Context, t TableIdentity, f TableFields, where []Predicate) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.DB.UpdateWhere(t, f, where)
+}
+
+// GetAllCtx calls DB.GetAll if the context is not done.
+func (c contextDB) GetAllCtx(ctx context.Context, t TableIdentity, order string, orderBy string) ([]map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.DB.GetAll(t, order, orderBy)
+}
+
+// GetAllPerPageCtx calls DB.GetAllPerPage if the context is not done.
+func (c contextDB) GetAllPerPageCtx(ctx context.Context, t TableIdentity, order string, orderBy string, page int, responsePage int) ([]map[string]interface{}, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, -1, err
+	}
+
+	return c.DB.GetAllPerPage(t, order, orderBy, page, responsePage)
+}
+
+// WithTxCtx calls DB.WithTx if the context is not done.
+func (c contextDB) WithTxCtx(ctx context.Context, fn func(DB) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.DB.WithTx(fn)
 }
 
 //=============================================================================================================================================
@@ -0,0 +1,18 @@
+package db
+
+import "errors"
+
+// contains the errors returned by all DB implementations, which callers can check
+// for with errors.Is.
+var (
+	// ErrNotFound is returned when no record matches the giving index and value.
+	ErrNotFound = errors.New("Record not found")
+
+	// ErrConflict is returned when a record conflicts with an existing record, such
+	// as on a duplicate unique field.
+	ErrConflict = errors.New("Record conflicts with an existing record")
+
+	// ErrInvalidQuery is returned when a query holds an invalid field name, operator or
+	// value, such as an unknown order field.
+	ErrInvalidQuery = errors.New("Invalid query")
+)
@@ -0,0 +1,631 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+)
+
+// Memory defines a struct which implements the db.DB interface using in-process maps,
+// where each table is keyed by it's TableIdentity.Table() name. It is safe for concurrent use.
+type Memory struct {
+	ml     sync.RWMutex
+	tables map[string][]map[string]interface{}
+}
+
+// New returns a new instance of Memory.
+func New() *Memory {
+	return &Memory{
+		tables: make(map[string][]map[string]interface{}),
+	}
+}
+
+// Save takes the giving table name with the giving fields and attempts to save this giving
+// data appropriately into the giving table.
+func (m *Memory) Save(identity db.TableIdentity, table db.TableFields) error {
+	record := copyRecord(table.Fields())
+
+	now := time.Now().UTC()
+	record["created_at"] = now
+	record["updated_at"] = now
+
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	m.tables[identity.Table()] = append(m.tables[identity.Table()], record)
+
+	return nil
+}
+
+// Update takes the giving table name with the giving fields and attempts to update this giving
+// data appropriately into the giving table.
+// index - defines the string which should identify the key to be retrieved from the fields to target the
+// data to be updated in the table.
+func (m *Memory) Update(identity db.TableIdentity, table db.TableFields, index string) error {
+	fields := table.Fields()
+
+	// Given index was not found, return error.
+	indexValue, ok := fields[index]
+	if !ok {
+		return fmt.Errorf("Index key %q not found in fields", index)
+	}
+
+	delete(fields, index)
+
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	now := time.Now().UTC()
+	found := false
+
+	for _, record := range m.tables[identity.Table()] {
+		if compare(record[index], indexValue) != 0 {
+			continue
+		}
+
+		for key, value := range fields {
+			record[key] = value
+		}
+
+		record["updated_at"] = now
+		found = true
+	}
+
+	if !found {
+		return notFound(identity, index, indexValue)
+	}
+
+	return nil
+}
+
+// Delete removes the giving data from the specific table with the specific index and value.
+func (m *Memory) Delete(identity db.TableIdentity, index string, indexValue interface{}) error {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	records := m.tables[identity.Table()]
+	kept := records[:0]
+
+	for _, record := range records {
+		if compare(record[index], indexValue) == 0 {
+			continue
+		}
+
+		kept = append(kept, record)
+	}
+
+	if len(kept) == len(records) {
+		return notFound(identity, index, indexValue)
+	}
+
+	// Clear out the trailing references left behind by the filtering.
+	for i := len(kept); i < len(records); i++ {
+		records[i] = nil
+	}
+
+	m.tables[identity.Table()] = kept
+
+	return nil
+}
+
+// Get retrieves the giving data from the specific table with the specific index and value.
+func (m *Memory) Get(identity db.TableIdentity, consumer db.TableConsumer, index string, indexValue interface{}) error {
+	m.ml.RLock()
+
+	var found map[string]interface{}
+
+	for _, record := range m.tables[identity.Table()] {
+		if compare(record[index], indexValue) == 0 {
+			found = copyRecord(record)
+			break
+		}
+	}
+
+	m.ml.RUnlock()
+
+	if found == nil {
+		return notFound(identity, index, indexValue)
+	}
+
+	return consumer.WithFields(found)
+}
+
+// Count retrieves the total number of records from the specific table.
+func (m *Memory) Count(identity db.TableIdentity) (int, error) {
+	m.ml.RLock()
+	defer m.ml.RUnlock()
+
+	return len(m.tables[identity.Table()]), nil
+}
+
+// CountWhere returns the number of records of the specific table matching the giving predicates.
+func (m *Memory) CountWhere(identity db.TableIdentity, where []db.Predicate) (int, error) {
+	if err := (db.Query{Where: where}).Validate(); err != nil {
+		return 0, err
+	}
+
+	m.ml.RLock()
+	defer m.ml.RUnlock()
+
+	var total int
+	for _, record := range m.tables[identity.Table()] {
+		if matchesAll(record, where, false) {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+// UpdateWhere updates the records of the specific table matching the giving predicates with
+// the fields, returning the number of records updated.
+func (m *Memory) UpdateWhere(identity db.TableIdentity, table db.TableFields, where []db.Predicate) (int, error) {
+	if err := (db.Query{Where: where}).Validate(); err != nil {
+		return 0, err
+	}
+
+	fields := table.Fields()
+
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	now := time.Now().UTC()
+
+	var total int
+	for _, record := range m.tables[identity.Table()] {
+		if !matchesAll(record, where, false) {
+			continue
+		}
+
+		for key, value := range fields {
+			record[key] = value
+		}
+
+		record["updated_at"] = now
+		total++
+	}
+
+	return total, nil
+}
+
+// Find retrieves the records of the specific table matching the giving query.
+func (m *Memory) Find(identity db.TableIdentity, query db.Query) ([]map[string]interface{}, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.ml.RLock()
+
+	var records []map[string]interface{}
+	for _, record := range m.tables[identity.Table()] {
+		if matchesAll(record, query.Where, false) {
+			records = append(records, copyRecord(record))
+		}
+	}
+
+	m.ml.RUnlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, order := range query.Order {
+			diff := compare(records[i][order.Field], records[j][order.Field])
+			if diff == 0 {
+				continue
+			}
+
+			if order.Desc {
+				return diff > 0
+			}
+
+			return diff < 0
+		}
+
+		return false
+	})
+
+	if query.Offset >= len(records) {
+		return nil, nil
+	}
+
+	records = records[query.Offset:]
+
+	if query.Limit > 0 && query.Limit < len(records) {
+		records = records[:query.Limit]
+	}
+
+	if len(query.Fields) == 0 {
+		return records, nil
+	}
+
+	for index, record := range records {
+		projected := make(map[string]interface{}, len(query.Fields))
+
+		for _, field := range query.Fields {
+			if value, ok := record[field]; ok {
+				projected[field] = value
+			}
+		}
+
+		records[index] = projected
+	}
+
+	return records, nil
+}
+
+// GetAll retrieves all records from the specific table ordered by the giving field.
+func (m *Memory) GetAll(identity db.TableIdentity, order string, orderBy string) ([]map[string]interface{}, error) {
+	return m.sorted(identity, order, orderBy), nil
+}
+
+// GetAllPerPage retrieves all records from the specific table ordered by the giving field, limited
+// to the provided page and responsePerPage.
+func (m *Memory) GetAllPerPage(identity db.TableIdentity, order string, orderBy string, page int, responsePerPage int) ([]map[string]interface{}, int, error) {
+	records := m.sorted(identity, order, orderBy)
+	totalRecords := len(records)
+
+	if responsePerPage <= 0 {
+		return records, totalRecords, nil
+	}
+
+	indexToStart := db.PageOffset(page, responsePerPage)
+
+	// If we are passed the total, just return nil records and total without error.
+	if indexToStart >= totalRecords {
+		return nil, totalRecords, nil
+	}
+
+	end := indexToStart + responsePerPage
+	if end > totalRecords {
+		end = totalRecords
+	}
+
+	return records[indexToStart:end], totalRecords, nil
+}
+
+// SaveCtx is the same as Save but fails if the provided context is done.
+func (m *Memory) SaveCtx(ctx context.Context, identity db.TableIdentity, table db.TableFields) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.Save(identity, table)
+}
+
+// UpdateCtx is the same as Update but fails if the provided context is done.
+func (m *Memory) UpdateCtx(ctx context.Context, identity db.TableIdentity, table db.TableFields, index string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.Update(identity, table, index)
+}
+
+// DeleteCtx is the same as Delete but fails if the provided context is done.
+func (m *Memory) DeleteCtx(ctx context.Context, identity db.TableIdentity, index string, indexValue interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.Delete(identity, index, indexValue)
+}
+
+// GetCtx is the same as Get but fails if the provided context is done.
+func (m *Memory) GetCtx(ctx context.Context, identity db.TableIdentity, consumer db.TableConsumer, index string, indexValue interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.Get(identity, consumer, index, indexValue)
+}
+
+// CountCtx is the same as Count but fails if the provided context is done.
+func (m *Memory) CountCtx(ctx context.Context, identity db.TableIdentity) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return m.Count(identity)
+}
+
+// GetAllCtx is the same as GetAll but fails if the provided context is done.
+func (m *Memory) GetAllCtx(ctx context.Context, identity db.TableIdentity, order string, orderBy string) ([]map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return m.GetAll(identity, order, orderBy)
+}
+
+// GetAllPerPageCtx is the same as GetAllPerPage but fails if the provided context is done.
+func (m *Memory) GetAllPerPageCtx(ctx context.Context, identity db.TableIdentity, order string, orderBy string, page int, responsePerPage int) ([]map[string]interface{}, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, -1, err
+	}
+
+	return m.GetAllPerPage(identity, order, orderBy, page, responsePerPage)
+}
+
+// CountWhereCtx is the same as CountWhere but fails if the provided context is done.
+func (m *Memory) CountWhereCtx(ctx context.Context, identity db.TableIdentity, where []db.Predicate) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return m.CountWhere(identity, where)
+}
+
+// UpdateWhereCtx is the same as UpdateWhere but fails if the provided context is done.
+func (m *Memory) UpdateWhereCtx(ctx context.Context, identity db.TableIdentity, table db.TableFields, where []db.Predicate) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return m.UpdateWhere(identity, table, where)
+}
+
+// FindCtx is the same as Find but fails if the provided context is done.
+func (m *Memory) FindCtx(ctx context.Context, identity db.TableIdentity, query db.Query) ([]map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return m.Find(identity, query)
+}
+
+// WithTx runs the provided function against a copy of the store, which replaces the
+// store's records only if the function returns nil. The store is locked for the
+// duration of the function, hence it must only use the db.DB provided to it.
+func (m *Memory) WithTx(fn func(db.DB) error) error {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	tx := New()
+
+	for table, records := range m.tables {
+		copied := make([]map[string]interface{}, 0, len(records))
+
+		for _, record := range records {
+			copied = append(copied, copyRecord(record))
+		}
+
+		tx.tables[table] = copied
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	m.tables = tx.tables
+
+	return nil
+}
+
+// WithTxCtx is the same as WithTx but fails if the provided context is done.
+func (m *Memory) WithTxCtx(ctx context.Context, fn func(db.DB) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.WithTx(fn)
+}
+
+// sorted returns a copy of all records within the giving table, ordered by the
+// provided field in the provided order.
+func (m *Memory) sorted(identity db.TableIdentity, order string, orderBy string) []map[string]interface{} {
+	m.ml.RLock()
+
+	records := make([]map[string]interface{}, 0, len(m.tables[identity.Table()]))
+	for _, record := range m.tables[identity.Table()] {
+		records = append(records, copyRecord(record))
+	}
+
+	m.ml.RUnlock()
+
+	desc := false
+
+	switch strings.ToLower(order) {
+	case "dsc", "desc":
+		desc = true
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if desc {
+			return compare(records[i][orderBy], records[j][orderBy]) > 0
+		}
+
+		return compare(records[i][orderBy], records[j][orderBy]) < 0
+	})
+
+	return records
+}
+
+// matchesAll reports if the record matches all of the predicates, or any of them if
+// any is true. An empty list of predicates matches all records.
+func matchesAll(record map[string]interface{}, predicates []db.Predicate, any bool) bool {
+	if len(predicates) == 0 {
+		return true
+	}
+
+	for _, predicate := range predicates {
+		if matches(record, predicate) == any {
+			return any
+		}
+	}
+
+	return !any
+}
+
+// matches reports if the record matches the predicate. As with the sql backends, a
+// missing value only matches an IsNull predicate.
+func matches(record map[string]interface{}, predicate db.Predicate) bool {
+	if predicate.IsGroup() {
+		return matchesAll(record, predicate.Group, predicate.Any)
+	}
+
+	value := record[predicate.Field]
+
+	if predicate.Op == db.IsNull {
+		return (value == nil) == predicate.Null()
+	}
+
+	if value == nil {
+		return false
+	}
+
+	switch predicate.Op {
+	case db.Eq:
+		return compare(value, predicate.Value) == 0
+	case db.Ne:
+		return compare(value, predicate.Value) != 0
+	case db.Lt:
+		return compare(value, predicate.Value) < 0
+	case db.Lte:
+		return compare(value, predicate.Value) <= 0
+	case db.Gt:
+		return compare(value, predicate.Value) > 0
+	case db.Gte:
+		return compare(value, predicate.Value) >= 0
+	case db.Like:
+		return like(toString(value), predicate.Value.(string))
+	case db.In:
+		for _, item := range predicate.Values() {
+			if compare(value, item) == 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// like reports if the value matches the sql LIKE pattern, ignoring case.
+func like(value string, pattern string) bool {
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+
+	for _, char := range pattern {
+		switch char {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+
+	expr.WriteString("$")
+
+	matched, _ := regexp.MatchString(expr.String(), value)
+	return matched
+}
+
+// notFound returns a db.ErrNotFound error for the giving table and index.
+func notFound(identity db.TableIdentity, index string, indexValue interface{}) error {
+	return fmt.Errorf("Record with %s=%v not found in %q: %w", index, indexValue, identity.Table(), db.ErrNotFound)
+}
+
+// copyRecord returns a shallow copy of the giving record.
+func copyRecord(record map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(record))
+
+	for key, value := range record {
+		cp[key] = value
+	}
+
+	return cp
+}
+
+// compare returns -1, 0 or 1 if a is less than, equal or greater than b. Nil values
+// are always ordered first, as is done by the sql backends.
+func compare(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1
+			case af > bf:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			switch {
+			case at.Before(bt):
+				return -1
+			case at.After(bt):
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	return strings.Compare(toString(a), toString(b))
+}
+
+// toFloat attempts to convert the giving numeric value into a float64.
+func toFloat(item interface{}) (float64, bool) {
+	switch rl := item.(type) {
+	case int:
+		return float64(rl), true
+	case int8:
+		return float64(rl), true
+	case int16:
+		return float64(rl), true
+	case int32:
+		return float64(rl), true
+	case int64:
+		return float64(rl), true
+	case uint:
+		return float64(rl), true
+	case uint8:
+		return float64(rl), true
+	case uint16:
+		return float64(rl), true
+	case uint32:
+		return float64(rl), true
+	case uint64:
+		return float64(rl), true
+	case float32:
+		return float64(rl), true
+	case float64:
+		return rl, true
+	default:
+		return 0, false
+	}
+}
+
+// toString returns the string representation of the giving value.
+func toString(item interface{}) string {
+	switch rl := item.(type) {
+	case string:
+		return rl
+	case []byte:
+		return string(rl)
+	case time.Time:
+		return rl.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(rl)
+	}
+}
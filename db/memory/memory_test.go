@@ -0,0 +1,446 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/memory"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/naming"
+	"github.com/influx6/faux/tests"
+)
+
+func TestMemoryAPI(t *testing.T) {
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "test"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	nw, err := user.New(user.NewUser{
+		Email:    "bob@guma.com",
+		Password: "Glow-Worm-Lantern-42",
+	})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	db := memory.New()
+
+	t.Logf("Given the need to validate memory api operations")
+	{
+
+		t.Log("\tWhen saving user record")
+		{
+			if err := db.Save(userTable, nw); err != nil {
+				tests.Failed("Should have successfully saved record to db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully saved record to db table %q.", userTable.Table())
+		}
+
+		t.Log("\tWhen counting user records")
+		{
+			total, err := db.Count(userTable)
+			if err != nil {
+				tests.Failed("Should have successfully counted records in db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully counted records in db table %q.", userTable.Table())
+
+			if total != 1 {
+				tests.Failed("Should have successfully recieved a count of 1.")
+			}
+			tests.Passed("Should have successfully recieved a count of 1.")
+		}
+
+		t.Log("\tWhen retrieving all user record")
+		{
+			records, err := db.GetAll(userTable, "asc", "public_id")
+			if err != nil {
+				tests.Failed("Should have successfully retrieved all records from db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully retrieved all records from db table %q.", userTable.Table())
+
+			if len(records) != 1 {
+				tests.Failed("Should have successfully retrieved one record from db table %q.", userTable.Table())
+			}
+			tests.Passed("Should have successfully retrieved one record from db table %q.", userTable.Table())
+
+			if _, ok := records[0]["created_at"]; !ok {
+				tests.Failed("Should have stamped record with a 'created_at' field.")
+			}
+			tests.Passed("Should have stamped record with a 'created_at' field.")
+		}
+
+		t.Log("\tWhen retrieving user record")
+		{
+			var nu user.User
+			if err := db.Get(userTable, &nu, "public_id", nw.PublicID); err != nil {
+				tests.Failed("Should have successfully retrieved record from db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully retrieved record from db table %q.", userTable.Table())
+
+			if nu.PublicID != nw.PublicID {
+				tests.Info("Expected: %+q", nw.Fields())
+				tests.Info("Recieved: %+q", nu.Fields())
+				tests.Failed("Should have successfully matched original user with user retrieved from db.")
+			}
+			tests.Passed("Should have successfully matched original user with user retrieved from db.")
+		}
+
+		t.Log("\tWhen updating user record")
+		{
+			if err := db.Update(userTable, user.UpdateUser{PublicID: nw.PublicID, Email: "bob@gum.com"}, "public_id"); err != nil {
+				tests.Failed("Should have successfully updated record to db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully updated record to db table %q.", userTable.Table())
+
+			var nu user.User
+			if err := db.Get(userTable, &nu, "public_id", nw.PublicID); err != nil {
+				tests.Failed("Should have successfully retrieved record from db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully retrieved record from db table %q.", userTable.Table())
+
+			if nu.Email != "bob@gum.com" {
+				tests.Failed("Should have successfully updated the email of the user record.")
+			}
+			tests.Passed("Should have successfully updated the email of the user record.")
+		}
+
+		t.Logf("\tWhen deleting user record")
+		{
+			if err := db.Delete(userTable, "public_id", nw.PublicID); err != nil {
+				tests.Failed("Should have successfully deleted record to db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully deleted record to db table %q.", userTable.Table())
+
+			var nu user.User
+			if err := db.Get(userTable, &nu, "public_id", nw.PublicID); err == nil {
+				tests.Failed("Should have failed to retrieve deleted record from db table %q.", userTable.Table())
+			}
+			tests.Passed("Should have failed to retrieve deleted record from db table %q.", userTable.Table())
+		}
+	}
+}
+
+func TestMemoryTx(t *testing.T) {
+	userTable := db.TableName{Name: "users"}
+
+	nw, err := user.New(user.NewUser{
+		Email:    "bob@guma.com",
+		Password: "Glow-Worm-Lantern-42",
+	})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	store := memory.New()
+
+	t.Logf("Given the need to validate memory transactions")
+	{
+		t.Log("\tWhen a transaction fails")
+		{
+			failure := errors.New("failed")
+
+			err := store.WithTx(func(tx db.DB) error {
+				if err := tx.Save(userTable, nw); err != nil {
+					return err
+				}
+
+				return failure
+			})
+			if err != failure {
+				tests.Failed("Should have received transaction failure: %+q.", err)
+			}
+			tests.Passed("Should have received transaction failure.")
+
+			if total, _ := store.Count(userTable); total != 0 {
+				tests.Failed("Should have rolled back saved record.")
+			}
+			tests.Passed("Should have rolled back saved record.")
+		}
+
+		t.Log("\tWhen a transaction succeeds")
+		{
+			err := store.WithTx(func(tx db.DB) error {
+				return tx.Save(userTable, nw)
+			})
+			if err != nil {
+				tests.Failed("Should have successfully committed transaction: %+q.", err)
+			}
+			tests.Passed("Should have successfully committed transaction.")
+
+			if total, _ := store.Count(userTable); total != 1 {
+				tests.Failed("Should have committed saved record.")
+			}
+			tests.Passed("Should have committed saved record.")
+		}
+	}
+}
+
+func TestMemoryNotFound(t *testing.T) {
+	userTable := db.TableName{Name: "users"}
+
+	nw, err := user.New(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	store := memory.New()
+
+	t.Logf("Given the need to detect missing memory records")
+	{
+		t.Log("\tWhen retrieving a missing record")
+		{
+			var nu user.User
+			if err := store.Get(userTable, &nu, "public_id", nw.PublicID); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have failed with db.ErrNotFound: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrNotFound.")
+		}
+
+		t.Log("\tWhen updating a missing record")
+		{
+			if err := store.Update(userTable, nw, "public_id"); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have failed with db.ErrNotFound: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrNotFound.")
+		}
+
+		t.Log("\tWhen deleting a missing record")
+		{
+			if err := store.Delete(userTable, "public_id", nw.PublicID); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have failed with db.ErrNotFound: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrNotFound.")
+		}
+	}
+}
+
+func TestMemoryFind(t *testing.T) {
+	userTable := db.TableName{Name: "users"}
+	store := memory.New()
+
+	var bob *user.User
+
+	for _, email := range []string{"bob@guma.com", "alice@guma.com", "carl@other.com"} {
+		nw, err := user.New(user.NewUser{Email: email, Password: "Glow-Worm-Lantern-42"})
+		if err != nil {
+			tests.Failed("Should have successfully created new user: %+q.", err)
+		}
+
+		if err := store.Save(userTable, nw); err != nil {
+			tests.Failed("Should have successfully saved user: %+q.", err)
+		}
+
+		if bob == nil {
+			bob = nw
+		}
+	}
+	tests.Passed("Should have successfully saved users.")
+
+	t.Logf("Given the need to find memory records with a query")
+	{
+		t.Log("\tWhen filtering with like and ordering by email")
+		{
+			records, err := store.Find(userTable, db.Query{
+				Where: []db.Predicate{db.Where("email", db.Like, "%GUMA%")},
+				Order: []db.Order{{Field: "email"}},
+			})
+			if err != nil {
+				tests.Failed("Should have successfully found records: %+q.", err)
+			}
+			tests.Passed("Should have successfully found records.")
+
+			if len(records) != 2 || records[0]["email"] != "alice@guma.com" || records[1]["email"] != "bob@guma.com" {
+				tests.Info("Records: %#v", records)
+				tests.Failed("Should have found ordered guma users.")
+			}
+			tests.Passed("Should have found ordered guma users.")
+		}
+
+		t.Log("\tWhen filtering with an or group, limit and projection")
+		{
+			records, err := store.Find(userTable, db.Query{
+				Where:  []db.Predicate{db.Or(db.Where("email", db.Eq, "carl@other.com"), db.Where("email", db.In, []string{"bob@guma.com"}))},
+				Order:  []db.Order{{Field: "email", Desc: true}},
+				Limit:  1,
+				Fields: []string{"email"},
+			})
+			if err != nil {
+				tests.Failed("Should have successfully found records: %+q.", err)
+			}
+			tests.Passed("Should have successfully found records.")
+
+			if len(records) != 1 || len(records[0]) != 1 || records[0]["email"] != "carl@other.com" {
+				tests.Info("Records: %#v", records)
+				tests.Failed("Should have found only the projected carl record.")
+			}
+			tests.Passed("Should have found only the projected carl record.")
+		}
+
+		t.Log("\tWhen filtering on null and offset")
+		{
+			records, err := store.Find(userTable, db.Query{
+				Where:  []db.Predicate{db.Where("email", db.IsNull, false), db.Where("email", db.Ne, "carl@other.com")},
+				Order:  []db.Order{{Field: "email"}},
+				Offset: 1,
+			})
+			if err != nil {
+				tests.Failed("Should have successfully found records: %+q.", err)
+			}
+			tests.Passed("Should have successfully found records.")
+
+			if len(records) != 1 || records[0]["email"] != "bob@guma.com" {
+				tests.Info("Records: %#v", records)
+				tests.Failed("Should have found bob after offset.")
+			}
+			tests.Passed("Should have found bob after offset.")
+		}
+
+		t.Log("\tWhen filtering with an invalid field")
+		{
+			if _, err := store.Find(userTable, db.Query{Where: []db.Predicate{db.Where("email; DROP", db.Eq, "")}}); err == nil {
+				tests.Failed("Should have failed to find records with invalid field.")
+			}
+			tests.Passed("Should have failed to find records with invalid field.")
+		}
+
+		t.Log("\tWhen counting records matching predicates")
+		{
+			total, err := store.CountWhere(userTable, []db.Predicate{db.Or(db.Where("email", db.Like, "%GUMA%"), db.Where("email", db.In, []string{"carl@other.com"})), db.Where("email", db.Ne, "bob@guma.com")})
+			if err != nil {
+				tests.Failed("Should have successfully counted records: %+q.", err)
+			}
+			tests.Passed("Should have successfully counted records.")
+
+			if total != 2 {
+				tests.Failed("Should have counted 2 records, got %d.", total)
+			}
+			tests.Passed("Should have counted 2 records.")
+
+			if _, err := store.CountWhere(userTable, []db.Predicate{db.Where("email; DROP", db.Eq, "")}); err == nil {
+				tests.Failed("Should have failed to count records with invalid field.")
+			}
+			tests.Passed("Should have failed to count records with invalid field.")
+		}
+
+		t.Log("\tWhen updating records still matching predicates")
+		{
+			swap := []db.Predicate{db.Where("public_id", db.Eq, bob.PublicID), db.Where("hash", db.Eq, bob.Hash)}
+
+			updated, err := store.UpdateWhere(userTable, user.UpdateUserHash{PublicID: bob.PublicID, Hash: "swapped"}, swap)
+			if err != nil || updated != 1 {
+				tests.Failed("Should have updated the matching record, got %d: %+q.", updated, err)
+			}
+			tests.Passed("Should have updated the matching record.")
+
+			updated, err = store.UpdateWhere(userTable, user.UpdateUserHash{PublicID: bob.PublicID, Hash: "lost"}, swap)
+			if err != nil || updated != 0 {
+				tests.Failed("Should have updated no record once it changed, got %d: %+q.", updated, err)
+			}
+			tests.Passed("Should have updated no record once it changed.")
+
+			total, err := store.CountWhere(userTable, []db.Predicate{db.Where("hash", db.Eq, "swapped")})
+			if err != nil || total != 1 {
+				tests.Failed("Should have kept the first update, got %d: %+q.", total, err)
+			}
+			tests.Passed("Should have kept the first update.")
+		}
+	}
+}
+
+func TestMemoryPages(t *testing.T) {
+	userTable := db.TableName{Name: "users"}
+	store := memory.New()
+
+	save := func(email string) {
+		nw, err := user.New(user.NewUser{Email: email, Password: "Glow-Worm-Lantern-42"})
+		if err != nil {
+			tests.Failed("Should have successfully created new user: %+q.", err)
+		}
+
+		if err := store.Save(userTable, nw); err != nil {
+			tests.Failed("Should have successfully saved user: %+q.", err)
+		}
+	}
+
+	for _, email := range []string{"u0@guma.com", "u1@guma.com", "u2@guma.com", "u3@guma.com", "u4@guma.com"} {
+		save(email)
+	}
+	tests.Passed("Should have successfully saved users.")
+
+	emails := func(records []map[string]interface{}) string {
+		var list []string
+		for _, record := range records {
+			list = append(list, record["email"].(string))
+		}
+		return strings.Join(list, ",")
+	}
+
+	t.Logf("Given the need to retrieve pages of memory records")
+	{
+		t.Log("\tWhen retrieving pages by offset")
+		{
+			records, total, err := store.GetAllPerPage(userTable, "asc", "email", 2, 2)
+			if err != nil {
+				tests.Failed("Should have successfully retrieved page: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved page.")
+
+			if total != 5 || emails(records) != "u2@guma.com,u3@guma.com" {
+				tests.Info("Total: %d, Records: %s", total, emails(records))
+				tests.Failed("Should have retrieved the second page of 2 records.")
+			}
+			tests.Passed("Should have retrieved the second page of 2 records.")
+
+			records, _, err = store.GetAllPerPage(userTable, "asc", "email", 3, 2)
+			if err != nil || emails(records) != "u4@guma.com" {
+				tests.Info("Records: %s", emails(records))
+				tests.Failed("Should have retrieved the last record on the third page: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the last record on the third page.")
+
+			records, _, err = store.GetAllPerPage(userTable, "asc", "email", 4, 2)
+			if err != nil || len(records) != 0 {
+				tests.Failed("Should have retrieved no records past the last page: %+q.", err)
+			}
+			tests.Passed("Should have retrieved no records past the last page.")
+		}
+
+		t.Log("\tWhen retrieving pages by cursor with concurrent inserts")
+		{
+			first, err := db.FindPage(context.Background(), store, userTable, nil, "", 2)
+			if err != nil || emails(first.Records) != "u0@guma.com,u1@guma.com" || first.Next == "" || first.Prev != "" {
+				tests.Info("Page: %s %+v", emails(first.Records), first)
+				tests.Failed("Should have retrieved the first page with only a next cursor: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the first page with only a next cursor.")
+
+			second, err := db.FindPage(context.Background(), store, userTable, nil, first.Next, 2)
+			if err != nil || emails(second.Records) != "u2@guma.com,u3@guma.com" || second.Next == "" || second.Prev == "" {
+				tests.Info("Page: %s %+v", emails(second.Records), second)
+				tests.Failed("Should have retrieved the second page with both cursors: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the second page with both cursors.")
+
+			save("u5@guma.com")
+
+			third, err := db.FindPage(context.Background(), store, userTable, nil, second.Next, 2)
+			if err != nil || emails(third.Records) != "u4@guma.com,u5@guma.com" || third.Next != "" {
+				tests.Info("Page: %s %+v", emails(third.Records), third)
+				tests.Failed("Should have retrieved the last page including the new record: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the last page including the new record.")
+
+			previous, err := db.FindPage(context.Background(), store, userTable, nil, third.Prev, 2)
+			if err != nil || emails(previous.Records) != "u2@guma.com,u3@guma.com" || previous.Prev == "" || previous.Next == "" {
+				tests.Info("Page: %s %+v", emails(previous.Records), previous)
+				tests.Failed("Should have retrieved the second page again by prev cursor: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the second page again by prev cursor.")
+		}
+	}
+}
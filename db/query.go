@@ -0,0 +1,172 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// Operator defines the comparison applied by a Predicate.
+type Operator string
+
+// contains the operators supported by all Find implementations.
+const (
+	Eq     Operator = "eq"
+	Ne     Operator = "ne"
+	Lt     Operator = "lt"
+	Lte    Operator = "lte"
+	Gt     Operator = "gt"
+	Gte    Operator = "gte"
+	In     Operator = "in"
+	Like   Operator = "like"
+	IsNull Operator = "null"
+)
+
+// Predicate defines a condition of a Query. It either compares a Field with Op and
+// Value, or joins the predicates of Group with AND, or with OR if Any is true.
+//
+// Like matches Value as a case insensitive pattern, where "%" matches any run of
+// characters and "_" matches a single character. In expects a slice as Value. IsNull
+// matches missing values, or present values if Value is false.
+type Predicate struct {
+	Field string
+	Op    Operator
+	Value interface{}
+
+	Any   bool
+	Group []Predicate
+}
+
+// Where returns a Predicate comparing the field against the value with the operator.
+func Where(field string, op Operator, value interface{}) Predicate {
+	return Predicate{Field: field, Op: op, Value: value}
+}
+
+// And returns a Predicate matching records matched by all of the predicates.
+func And(predicates ...Predicate) Predicate {
+	return Predicate{Group: predicates}
+}
+
+// Or returns a Predicate matching records matched by any of the predicates.
+func Or(predicates ...Predicate) Predicate {
+	return Predicate{Any: true, Group: predicates}
+}
+
+// IsGroup reports if the Predicate joins other predicates rather than comparing a field.
+func (p Predicate) IsGroup() bool {
+	return p.Field == "" && p.Op == ""
+}
+
+// Order defines a field by which records returned by a Query are sorted.
+type Order struct {
+	Field string
+	Desc  bool
+}
+
+// Query defines the records retrieved by Find. All predicates of Where must match a
+// record, which are sorted by each Order in turn. Fields lists the fields returned for
+// each record, returning all fields if empty. A Limit of zero returns all records.
+type Query struct {
+	Where  []Predicate
+	Order  []Order
+	Limit  int
+	Offset int
+	Fields []string
+}
+
+// fieldName matches the field names accepted within a Query.
+var fieldName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate returns an error matching ErrInvalidQuery if the Query contains an invalid
+// field name, operator or value. Find implementations call it before running the Query, as field names are
+// written into sql statements as is.
+func (q Query) Validate() error {
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("Query limit and offset must not be negative: %d, %d: %w", q.Limit, q.Offset, ErrInvalidQuery)
+	}
+
+	for _, predicate := range q.Where {
+		if err := predicate.validate(); err != nil {
+			return fmt.Errorf("%s: %w", err, ErrInvalidQuery)
+		}
+	}
+
+	for _, order := range q.Order {
+		if !fieldName.MatchString(order.Field) {
+			return fmt.Errorf("Invalid order field %q: %w", order.Field, ErrInvalidQuery)
+		}
+	}
+
+	for _, field := range q.Fields {
+		if !fieldName.MatchString(field) {
+			return fmt.Errorf("Invalid field %q: %w", field, ErrInvalidQuery)
+		}
+	}
+
+	return nil
+}
+
+// validate returns an error if the Predicate or any within it's Group is invalid.
+func (p Predicate) validate() error {
+	if p.IsGroup() {
+		for _, predicate := range p.Group {
+			if err := predicate.validate(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if !fieldName.MatchString(p.Field) {
+		return fmt.Errorf("Invalid predicate field %q", p.Field)
+	}
+
+	switch p.Op {
+	case Eq, Ne, Lt, Lte, Gt, Gte:
+	case In:
+		if kind := reflect.ValueOf(p.Value).Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return fmt.Errorf("Predicate %q on %q expects a slice, got %T", p.Op, p.Field, p.Value)
+		}
+	case Like:
+		if _, ok := p.Value.(string); !ok {
+			return fmt.Errorf("Predicate %q on %q expects a string, got %T", p.Op, p.Field, p.Value)
+		}
+	case IsNull:
+		if _, ok := p.Value.(bool); p.Value != nil && !ok {
+			return fmt.Errorf("Predicate %q on %q expects a bool, got %T", p.Op, p.Field, p.Value)
+		}
+	default:
+		return fmt.Errorf("Unknown predicate operator %q", p.Op)
+	}
+
+	return nil
+}
+
+// Values returns the elements of the slice Value of an In predicate.
+func (p Predicate) Values() []interface{} {
+	rv := reflect.ValueOf(p.Value)
+
+	values := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		values = append(values, rv.Index(i).Interface())
+	}
+
+	return values
+}
+
+// Null reports if an IsNull predicate matches missing values, rather than present ones.
+func (p Predicate) Null() bool {
+	present, ok := p.Value.(bool)
+	return !ok || present
+}
+
+// PageOffset returns the number of records skipped to reach the giving page, where
+// pages start at 1 and any lower page is treated as the first.
+func PageOffset(page int, responsePerPage int) int {
+	if page < 1 {
+		page = 1
+	}
+
+	return (page - 1) * responsePerPage
+}
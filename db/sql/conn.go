@@ -1,15 +1,15 @@
 package sql
 
 import (
-	"fmt"
-
+	"github.com/influx6/backoffice/db/sql/dialects"
 	"github.com/influx6/faux/sink"
 	"github.com/influx6/faux/sink/sinks"
 	"github.com/jmoiron/sqlx"
 )
 
 // Conn defines a struct which will generate a new sqlx connection for making
-// sql queries.
+// sql queries. The Dialect is used to build the connection DSN and defaults to
+// MySQL when not set.
 type Conn struct {
 	Port     int
 	Addr     string
@@ -17,6 +17,7 @@ type Conn struct {
 	Driver   string
 	Password string
 	Database string
+	Dialect  dialects.Dialect
 	Log      sink.Sink
 }
 
@@ -31,9 +32,25 @@ func (s Conn) New() (*sqlx.DB, error) {
 		"driver": s.Driver,
 	}).Trace("sql.Conn.New").End())
 
-	addr := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", s.User, s.Password, s.Addr, s.Port, s.Database)
+	dialect := s.Dialect
+	if dialect == nil {
+		dialect = dialects.MySQL{}
+	}
+
+	driver := s.Driver
+	if driver == "" {
+		driver = dialect.Driver()
+	}
+
+	addr := dialect.DSN(dialects.Credentials{
+		Port:     s.Port,
+		Addr:     s.Addr,
+		User:     s.User,
+		Password: s.Password,
+		Database: s.Database,
+	})
 
-	db, err := sqlx.Connect(s.Driver, addr)
+	db, err := sqlx.Connect(driver, addr)
 	if err != nil {
 		s.Log.Emit(sinks.Error("Failed to connect to sql server: %+q", err).WithFields(sink.Fields{
 			"ip":     s.Addr,
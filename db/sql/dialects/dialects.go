@@ -0,0 +1,95 @@
+package dialects
+
+import "strings"
+
+// Credentials defines the set of connection details which a Dialect uses to
+// build the DSN for it's underline driver.
+type Credentials struct {
+	Port     int
+	Addr     string
+	User     string
+	Password string
+	Database string
+}
+
+// Dialect defines an interface which exposes the sql variations which differ between
+// database servers, allowing queries and migrations to be generated for each.
+type Dialect interface {
+	// Name returns the name of the dialect.
+	Name() string
+
+	// Driver returns the default database/sql driver name for the dialect.
+	Driver() string
+
+	// DSN returns the data source name for connecting with the provided credentials.
+	DSN(Credentials) string
+
+	// Placeholder returns the bind marker for the argument at the giving position,
+	// which starts from 1.
+	Placeholder(position int) string
+
+	// AutoIncrement returns the column type and the trailing clause used to declare an
+	// auto incrementing column for the giving field type.
+	AutoIncrement(fieldType string) (string, string)
+
+	// Index returns the clause for creating an index named name for the field on the
+	// giving table, and reports if the clause is to be inlined within the CREATE TABLE
+	// statement or executed as a statement of it's own.
+	Index(table string, name string, field string) (string, bool)
+
+	// CreateIndex returns the statement for creating an index named name for the field on
+	// an existing table, which is unique if requested.
+	CreateIndex(table string, name string, field string, unique bool) string
+
+	// DropIndex returns the statement for dropping the index named name from the table.
+	DropIndex(table string, name string) string
+
+	// Timestamp returns the column type used for timestamp fields.
+	Timestamp() string
+
+	// Conflict reports if the giving error returned by the driver is a violation of
+	// a unique or primary key constraint.
+	Conflict(err error) bool
+}
+
+// ForDriver returns the Dialect associated with the giving database/sql driver name.
+// It returns nil if no dialect is known for the driver.
+func ForDriver(driver string) Dialect {
+	switch strings.ToLower(driver) {
+	case "mysql":
+		return MySQL{}
+	case "sqlite", "sqlite3":
+		return SQLite{}
+	case "postgres", "postgresql", "pgx":
+		return PostgreSQL{}
+	default:
+		return nil
+	}
+}
+
+// indexKind returns the kind of index to be created by a CREATE statement.
+func indexKind(unique bool) string {
+	if unique {
+		return "UNIQUE INDEX"
+	}
+
+	return "INDEX"
+}
+
+// containsAny reports if the message of the giving error contains any of the provided
+// values. It allows dialects to classify errors without importing their drivers.
+func containsAny(err error, values ...string) bool {
+	if err == nil {
+		return false
+	}
+
+	message := err.Error()
+
+	for _, value := range values {
+		if strings.Contains(message, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package dialects
+
+import "fmt"
+
+// MySQL defines the Dialect for the MySQL database server.
+type MySQL struct{}
+
+// Name returns the name of the dialect.
+func (MySQL) Name() string {
+	return "mysql"
+}
+
+// Driver returns the default database/sql driver name for the dialect.
+func (MySQL) Driver() string {
+	return "mysql"
+}
+
+// DSN returns the user:pass@tcp(addr:port)/db data source name for the credentials.
+// clientFoundRows is enabled so updates report matched rather than changed rows, as
+// with the other dialects.
+func (MySQL) DSN(c Credentials) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?clientFoundRows=true", c.User, c.Password, c.Addr, c.Port, c.Database)
+}
+
+// Placeholder returns the ? bind marker used by MySQL.
+func (MySQL) Placeholder(position int) string {
+	return "?"
+}
+
+// AutoIncrement returns the field type with the AUTO_INCREMENT clause.
+func (MySQL) AutoIncrement(fieldType string) (string, string) {
+	return fieldType, "AUTO_INCREMENT"
+}
+
+// Index returns the inline INDEX clause for the giving field.
+func (MySQL) Index(table string, name string, field string) (string, bool) {
+	return fmt.Sprintf("INDEX %s (%s)", name, field), true
+}
+
+// CreateIndex returns a CREATE INDEX statement for the giving field.
+func (MySQL) CreateIndex(table string, name string, field string, unique bool) string {
+	return fmt.Sprintf("CREATE %s %s ON %s (%s)", indexKind(unique), name, table, field)
+}
+
+// DropIndex returns a DROP INDEX statement for the index on the giving table.
+func (MySQL) DropIndex(table string, name string) string {
+	return fmt.Sprintf("DROP INDEX %s ON %s", name, table)
+}
+
+// Timestamp returns the column type used for timestamp fields.
+func (MySQL) Timestamp() string {
+	return "timestamp"
+}
+
+// Conflict reports if the error is a MySQL duplicate entry error (1062).
+func (MySQL) Conflict(err error) bool {
+	return containsAny(err, "Error 1062", "Duplicate entry")
+}
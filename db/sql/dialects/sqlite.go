@@ -0,0 +1,65 @@
+package dialects
+
+import "fmt"
+
+// SQLite defines the Dialect for SQLite file and in-memory databases.
+type SQLite struct{}
+
+// Name returns the name of the dialect.
+func (SQLite) Name() string {
+	return "sqlite3"
+}
+
+// Driver returns the default database/sql driver name for the dialect.
+func (SQLite) Driver() string {
+	return "sqlite3"
+}
+
+// DSN returns the path to the database file in Credentials.Database. An empty
+// database or ":memory:" returns a shared cache in-memory database, which lives for
+// as long as a connection to it stays open.
+func (SQLite) DSN(c Credentials) string {
+	if c.Database == "" || c.Database == ":memory:" {
+		return "file::memory:?cache=shared"
+	}
+
+	return c.Database
+}
+
+// Placeholder returns the ? bind marker used by SQLite.
+func (SQLite) Placeholder(position int) string {
+	return "?"
+}
+
+// AutoIncrement returns the INTEGER type with the AUTOINCREMENT clause, as SQLite only
+// allows auto incrementing INTEGER PRIMARY KEY fields.
+func (SQLite) AutoIncrement(fieldType string) (string, string) {
+	return "INTEGER", "AUTOINCREMENT"
+}
+
+// Index returns a CREATE INDEX statement for the giving field. Index names in SQLite
+// are unique across the database, hence the table name is used as a prefix.
+func (d SQLite) Index(table string, name string, field string) (string, bool) {
+	return d.CreateIndex(table, name, field, false), false
+}
+
+// CreateIndex returns a CREATE INDEX statement for the giving field, using the table name
+// as a prefix to the index name.
+func (SQLite) CreateIndex(table string, name string, field string, unique bool) string {
+	return fmt.Sprintf("CREATE %s IF NOT EXISTS %s_%s ON %s (%s)", indexKind(unique), table, name, table, field)
+}
+
+// DropIndex returns a DROP INDEX statement for the index on the giving table.
+func (SQLite) DropIndex(table string, name string) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s_%s", table, name)
+}
+
+// Timestamp returns the column type used for timestamp fields.
+func (SQLite) Timestamp() string {
+	return "DATETIME"
+}
+
+// Conflict reports if the error is a SQLite unique or primary key constraint error.
+func (SQLite) Conflict(err error) bool {
+	return containsAny(err, "UNIQUE constraint failed", "PRIMARY KEY constraint failed")
+}
@@ -0,0 +1,279 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influx6/backoffice/db/sql/tables"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+	"github.com/jmoiron/sqlx"
+)
+
+// LedgerTable defines the default name of the table which records the versions of all
+// applied migrations.
+const LedgerTable = "schema_migrations"
+
+// MigrationStatus defines the state of a giving migration within the db.
+type MigrationStatus struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// Migrator defines a struct which applies and reverts versioned migrations against
+// the db of a SQL instance, recording the applied versions in a ledger table.
+// Each migration is executed within a transaction, though note that some servers
+// like MySQL implicitly commit schema changes.
+type Migrator struct {
+	sq         *SQL
+	l          sink.Sink
+	ledger     string
+	migrations []tables.Migration
+}
+
+// NewMigrator returns a new instance of Migrator for the giving migrations, which
+// are ordered by their version.
+func NewMigrator(s sink.Sink, sq *SQL, ms ...tables.Migration) *Migrator {
+	migrations := append([]tables.Migration(nil), ms...)
+
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{
+		sq:         sq,
+		l:          s,
+		ledger:     LedgerTable,
+		migrations: migrations,
+	}
+}
+
+// Apply executes all migrations which have not being applied in order of their version,
+// returning the migrations applied.
+func (m *Migrator) Apply() ([]tables.Migration, error) {
+	return m.ApplyCtx(context.Background())
+}
+
+// ApplyCtx is the same as Apply but executes the queries within the provided context.
+func (m *Migrator) ApplyCtx(ctx context.Context) ([]tables.Migration, error) {
+	defer m.l.Emit(sinks.Info("Apply Migrations").With("ledger", m.ledger).Trace("Migrator.Apply").End())
+
+	dbi, applied, err := m.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dialect := dialectOf(dbi)
+	insert := fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)", m.ledger, dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3))
+
+	var done []tables.Migration
+
+	for _, migration := range m.migrations {
+		if applied[migration.Version] {
+			continue
+		}
+
+		if err := m.run(ctx, dbi, migration, migration.Up, insert, int64(migration.Version), migration.Name, time.Now().UTC()); err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Rollback reverts the last applied steps number of migrations in reverse order of their
+// version, returning the migrations reverted. A steps below 1 reverts the last migration.
+func (m *Migrator) Rollback(steps int) ([]tables.Migration, error) {
+	return m.RollbackCtx(context.Background(), steps)
+}
+
+// RollbackCtx is the same as Rollback but executes the queries within the provided context.
+func (m *Migrator) RollbackCtx(ctx context.Context, steps int) ([]tables.Migration, error) {
+	defer m.l.Emit(sinks.Info("Rollback Migrations").With("ledger", m.ledger).With("steps", steps).Trace("Migrator.Rollback").End())
+
+	if steps < 1 {
+		steps = 1
+	}
+
+	dbi, applied, err := m.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+
+	for version := range applied {
+		versions = append(versions, version)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	if len(versions) > steps {
+		versions = versions[:steps]
+	}
+
+	dialect := dialectOf(dbi)
+	remove := fmt.Sprintf("DELETE FROM %s WHERE version=%s", m.ledger, dialect.Placeholder(1))
+
+	var done []tables.Migration
+
+	for _, version := range versions {
+		migration, ok := m.find(version)
+		if !ok {
+			err := fmt.Errorf("Applied migration version %d is unknown", version)
+			m.l.Emit(sinks.Error(err).With("version", version))
+			return done, err
+		}
+
+		if err := m.run(ctx, dbi, migration, migration.Down, remove, int64(version)); err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status returns the state of all migrations in order of their version.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	return m.StatusCtx(context.Background())
+}
+
+// StatusCtx is the same as Status but executes the queries within the provided context.
+func (m *Migrator) StatusCtx(ctx context.Context) ([]MigrationStatus, error) {
+	defer m.l.Emit(sinks.Info("Migrations Status").With("ledger", m.ledger).Trace("Migrator.Status").End())
+
+	_, applied, err := m.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+
+	for _, migration := range m.migrations {
+		statuses = append(statuses, MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: applied[migration.Version],
+		})
+	}
+
+	return statuses, nil
+}
+
+// prepare validates the migrations, creates the ledger table if missing and returns the
+// set of applied versions.
+func (m *Migrator) prepare(ctx context.Context) (*sqlx.DB, map[int]bool, error) {
+	seen := make(map[int]bool)
+
+	for _, migration := range m.migrations {
+		if seen[migration.Version] {
+			err := fmt.Errorf("Migration version %d is declared more than once", migration.Version)
+			m.l.Emit(sinks.Error(err).With("version", migration.Version))
+			return nil, nil, err
+		}
+
+		seen[migration.Version] = true
+	}
+
+	dbi, err := m.sq.conn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledger := tables.TableMigration{
+		TableName: m.ledger,
+		Fields: []tables.FieldMigration{
+			{FieldName: "version", FieldType: "BIGINT", PrimaryKey: true, NotNull: true},
+			{FieldName: "name", FieldType: "VARCHAR(255)", NotNull: true},
+			{FieldName: "applied_at", FieldType: "timestamp", NotNull: true},
+		},
+	}
+
+	for _, query := range ledger.Statements(dialectOf(dbi)) {
+		if _, err := dbi.ExecContext(ctx, query); err != nil {
+			m.l.Emit(sinks.Error(err).WithFields(sink.Fields{"query": query, "table": m.ledger}))
+			return nil, nil, err
+		}
+	}
+
+	var versions []int
+
+	query := fmt.Sprintf("SELECT version FROM %s", m.ledger)
+	if err := dbi.SelectContext(ctx, &versions, query); err != nil {
+		m.l.Emit(sinks.Error(err).WithFields(sink.Fields{"query": query, "table": m.ledger}))
+		return nil, nil, err
+	}
+
+	applied := make(map[int]bool, len(versions))
+
+	for _, version := range versions {
+		applied[version] = true
+	}
+
+	return dbi, applied, nil
+}
+
+// run executes the giving steps of a migration and the ledger query with it's args
+// within a single transaction, where steps which are a tables.Func are run in place of
+// their statements.
+func (m *Migrator) run(ctx context.Context, dbi *sqlx.DB, migration tables.Migration, steps []tables.Step, ledgerQuery string, ledgerArgs ...interface{}) error {
+	tx, err := dbi.BeginTxx(ctx, nil)
+	if err != nil {
+		m.l.Emit(sinks.Error(err).With("version", migration.Version))
+		return err
+	}
+
+	dialect := dialectOf(dbi)
+
+	for _, step := range steps {
+		if fn, ok := step.(tables.Func); ok {
+			if err := fn(ctx, tx, dialect); err != nil {
+				tx.Rollback()
+				m.l.Emit(sinks.Error(err).WithFields(sink.Fields{"version": migration.Version, "name": migration.Name}))
+				return err
+			}
+
+			continue
+		}
+
+		for _, query := range step.Statements(dialect) {
+			m.l.Emit(sinks.Info("Executing Migration").WithFields(sink.Fields{
+				"query":   query,
+				"version": migration.Version,
+				"name":    migration.Name,
+			}))
+
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				tx.Rollback()
+				m.l.Emit(sinks.Error(err).WithFields(sink.Fields{"query": query, "version": migration.Version}))
+				return err
+			}
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, ledgerQuery, ledgerArgs...); err != nil {
+		tx.Rollback()
+		m.l.Emit(sinks.Error(err).WithFields(sink.Fields{"query": ledgerQuery, "version": migration.Version}))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// find returns the migration with the giving version.
+func (m *Migrator) find(version int) (tables.Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return tables.Migration{}, false
+}
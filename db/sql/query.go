@@ -0,0 +1,222 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/sql/dialects"
+)
+
+// operators maps the comparison operators of a db.Query to their sql equivalent.
+var operators = map[db.Operator]string{
+	db.Eq:  "=",
+	db.Ne:  "<>",
+	db.Lt:  "<",
+	db.Lte: "<=",
+	db.Gt:  ">",
+	db.Gte: ">=",
+}
+
+// queryBuilder generates the sql statement and arguments of a db.Query, numbering
+// the bind markers of the dialect as arguments are added.
+type queryBuilder struct {
+	dialect dialects.Dialect
+	args    []interface{}
+}
+
+// selectQuery returns the SELECT statement and arguments of the query against the table.
+// The query must have been validated, as field names are written as is.
+func selectQuery(dialect dialects.Dialect, table string, query db.Query) (string, []interface{}, error) {
+	qb := queryBuilder{dialect: dialect}
+
+	fields := "*"
+	if len(query.Fields) != 0 {
+		fields = strings.Join(query.Fields, ", ")
+	}
+
+	var statement strings.Builder
+	fmt.Fprintf(&statement, "SELECT %s FROM %s", fields, table)
+
+	where, err := qb.join(query.Where, " AND ")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if where != "" {
+		fmt.Fprintf(&statement, " WHERE %s", where)
+	}
+
+	if len(query.Order) != 0 {
+		var orders []string
+
+		for _, order := range query.Order {
+			if order.Desc {
+				orders = append(orders, order.Field+" DESC")
+				continue
+			}
+
+			orders = append(orders, order.Field+" ASC")
+		}
+
+		fmt.Fprintf(&statement, " ORDER BY %s", strings.Join(orders, ", "))
+	}
+
+	// All dialects require a LIMIT before an OFFSET, so the largest is used when only
+	// an offset is provided.
+	switch {
+	case query.Limit > 0:
+		fmt.Fprintf(&statement, " LIMIT %d OFFSET %d", query.Limit, query.Offset)
+	case query.Offset > 0:
+		fmt.Fprintf(&statement, " LIMIT %d OFFSET %d", int64(math.MaxInt64), query.Offset)
+	}
+
+	return statement.String(), qb.args, nil
+}
+
+// countQuery returns the SELECT count(*) statement and arguments counting the records of
+// the table matching the predicates, which must have been validated.
+func countQuery(dialect dialects.Dialect, table string, where []db.Predicate) (string, []interface{}, error) {
+	qb := queryBuilder{dialect: dialect}
+
+	var statement strings.Builder
+	fmt.Fprintf(&statement, countTemplate, table)
+
+	condition, err := qb.join(where, " AND ")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if condition != "" {
+		fmt.Fprintf(&statement, " WHERE %s", condition)
+	}
+
+	return statement.String(), qb.args, nil
+}
+
+// updateQuery returns the UPDATE statement and arguments setting the fields of the records
+// of the table matching the predicates, which must have been validated.
+func updateQuery(dialect dialects.Dialect, table string, fields map[string]interface{}, where []db.Predicate) (string, []interface{}, error) {
+	names := fieldNames(fields)
+
+	values, err := fieldValues(names, fields)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The set values are bound first, so the predicates are numbered after them.
+	qb := queryBuilder{dialect: dialect, args: values}
+
+	var statement strings.Builder
+	fmt.Fprintf(&statement, "UPDATE %s SET %s", table, setMarkers(dialect, names))
+
+	condition, err := qb.join(where, " AND ")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if condition != "" {
+		fmt.Fprintf(&statement, " WHERE %s", condition)
+	}
+
+	return statement.String(), qb.args, nil
+}
+
+// join returns the conditions of the predicates joined by the separator, skipping
+// empty groups.
+func (qb *queryBuilder) join(predicates []db.Predicate, separator string) (string, error) {
+	var conditions []string
+
+	for _, predicate := range predicates {
+		condition, err := qb.condition(predicate)
+		if err != nil {
+			return "", err
+		}
+
+		if condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	return strings.Join(conditions, separator), nil
+}
+
+// condition returns the sql condition of the predicate.
+func (qb *queryBuilder) condition(predicate db.Predicate) (string, error) {
+	if predicate.IsGroup() {
+		separator := " AND "
+		if predicate.Any {
+			separator = " OR "
+		}
+
+		condition, err := qb.join(predicate.Group, separator)
+		if err != nil || condition == "" {
+			return condition, err
+		}
+
+		return "(" + condition + ")", nil
+	}
+
+	switch predicate.Op {
+	case db.IsNull:
+		if predicate.Null() {
+			return predicate.Field + " IS NULL", nil
+		}
+
+		return predicate.Field + " IS NOT NULL", nil
+
+	case db.Like:
+		marker, err := qb.bind(predicate.Value)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("LOWER(%s) LIKE LOWER(%s)", predicate.Field, marker), nil
+
+	case db.In:
+		values := predicate.Values()
+
+		// An empty list matches no records, which is not valid sql.
+		if len(values) == 0 {
+			return "1=0", nil
+		}
+
+		markers := make([]string, 0, len(values))
+
+		for _, value := range values {
+			marker, err := qb.bind(value)
+			if err != nil {
+				return "", err
+			}
+
+			markers = append(markers, marker)
+		}
+
+		return fmt.Sprintf("%s IN (%s)", predicate.Field, strings.Join(markers, ", ")), nil
+	}
+
+	operator, ok := operators[predicate.Op]
+	if !ok {
+		return "", fmt.Errorf("Unknown predicate operator %q", predicate.Op)
+	}
+
+	marker, err := qb.bind(predicate.Value)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s %s %s", predicate.Field, operator, marker), nil
+}
+
+// bind adds the value as an argument, returning it's bind marker.
+func (qb *queryBuilder) bind(value interface{}) (string, error) {
+	arg, err := bindValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	qb.args = append(qb.args, arg)
+
+	return qb.dialect.Placeholder(len(qb.args)), nil
+}
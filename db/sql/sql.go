@@ -1,13 +1,17 @@
 package sql
 
 import (
+	"context"
+	dsql "database/sql"
 	"errors"
 	"fmt"
-	"strconv"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/sql/dialects"
 	"github.com/influx6/backoffice/db/sql/tables"
 	"github.com/influx6/faux/sink"
 	"github.com/influx6/faux/sink/sinks"
@@ -31,16 +35,40 @@ type DB interface {
 	New() (*sqlx.DB, error)
 }
 
+// executor defines the set of query methods shared by a connection pool and a
+// transaction, allowing SQL to run it's operations against either.
+type executor interface {
+	DriverName() string
+	ExecContext(ctx context.Context, query string, args ...interface{}) (dsql.Result, error)
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+	QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// Pool defines the configuration for the pool of connections held by a SQL instance.
+// Zero values leave the defaults of database/sql in place.
+type Pool struct {
+	MaxOpen     int
+	MaxIdle     int
+	MaxLifetime time.Duration
+}
+
 // SQL defines an struct which implements the db.Provider which allows us
-// execute CRUD ops.
+// execute CRUD ops. It holds a long-lived pool of connections which is opened
+// on first use or with a call to SQL.Open.
 type SQL struct {
 	d      DB
 	l      sink.Sink
+	pool   Pool
+	ml     sync.Mutex
+	dbi    *sqlx.DB
+	tx     *sqlx.Tx
 	inited bool
 	tables []tables.TableMigration
 }
 
-// New returns a new instance of SQL.
+// New returns a new instance of SQL. The provided table migrations are executed
+// when first connecting to the db; use a Migrator for versioned migrations.
 func New(s sink.Sink, d DB, ts ...tables.TableMigration) *SQL {
 	return &SQL{
 		d:      d,
@@ -49,33 +77,199 @@ func New(s sink.Sink, d DB, ts ...tables.TableMigration) *SQL {
 	}
 }
 
-// migrate takes the individual query supplied and attempts to
-// execute them returning any error found.
-func (sq *SQL) migrate() error {
-	if sq.d == nil {
-		return nil
+// NewWithPool returns a new instance of SQL, whoes connection pool is configured
+// with the provided Pool.
+func NewWithPool(s sink.Sink, d DB, p Pool, ts ...tables.TableMigration) *SQL {
+	return &SQL{
+		d:      d,
+		l:      s,
+		pool:   p,
+		tables: ts,
 	}
+}
 
-	if sq.inited {
+// Open connects the underline connection pool and runs all migrations if not
+// already done.
+func (sq *SQL) Open() error {
+	_, err := sq.conn()
+	return err
+}
+
+// Close closes the underline connection pool. The SQL instance will reconnect on
+// next use.
+func (sq *SQL) Close() error {
+	sq.ml.Lock()
+	defer sq.ml.Unlock()
+
+	if sq.dbi == nil {
 		return nil
 	}
 
+	err := sq.dbi.Close()
+	sq.dbi = nil
+
+	return err
+}
+
+// Ping verifies that the connection to the underline db is still alive,
+// connecting if needed.
+func (sq *SQL) Ping() error {
+	return sq.PingCtx(context.Background())
+}
+
+// PingCtx is the same as Ping but executes within the provided context.
+func (sq *SQL) PingCtx(ctx context.Context) error {
+	defer sq.l.Emit(sinks.Info("Ping DB").Trace("db.Ping").End())
+
+	db, err := sq.conn()
+	if err != nil {
+		return err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		sq.l.Emit(sinks.Error(err).With("err", err))
+		return err
+	}
+
+	return nil
+}
+
+// conn returns the underline connection pool, connecting, configuring and
+// migrating the db on first call.
+func (sq *SQL) conn() (*sqlx.DB, error) {
+	sq.ml.Lock()
+	defer sq.ml.Unlock()
+
+	if sq.dbi != nil {
+		return sq.dbi, nil
+	}
+
+	if sq.d == nil {
+		return nil, errors.New("No DB provided for connection")
+	}
+
 	dbi, err := sq.d.New()
 	if err != nil {
+		return nil, err
+	}
+
+	if sq.pool.MaxOpen > 0 {
+		dbi.SetMaxOpenConns(sq.pool.MaxOpen)
+	}
+
+	if sq.pool.MaxIdle > 0 {
+		dbi.SetMaxIdleConns(sq.pool.MaxIdle)
+	}
+
+	if sq.pool.MaxLifetime > 0 {
+		dbi.SetConnMaxLifetime(sq.pool.MaxLifetime)
+	}
+
+	if err := sq.migrate(dbi); err != nil {
+		dbi.Close()
+		return nil, err
+	}
+
+	sq.dbi = dbi
+
+	return dbi, nil
+}
+
+// exec returns the executor which operations are run against, which is the
+// transaction if SQL is bound to one, else the connection pool.
+func (sq *SQL) exec() (executor, error) {
+	if sq.tx != nil {
+		return sq.tx, nil
+	}
+
+	return sq.conn()
+}
+
+// orderOf returns the column and direction by which GetAll and GetAllPerPage sort the
+// records of the table. As both are written into the statement as is, the column must be
+// a valid field name, else an error matching db.ErrInvalidQuery is returned, and
+// directions other than desc or dsc give ASC.
+func (sq *SQL) orderOf(table db.TableIdentity, orderBy string, order string) (string, string, error) {
+	if err := (db.Query{Order: []db.Order{{Field: orderBy}}}).Validate(); err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{"table": table.Table()}))
+		return "", "", err
+	}
+
+	direction := "ASC"
+	switch strings.ToLower(order) {
+	case "dsc", "desc":
+		direction = "DESC"
+	}
+
+	return orderBy, direction, nil
+}
+
+// WithTx runs the provided function within a transaction, where the db.DB passed
+// to it executes all operations within that transaction. The transaction is
+// committed if the function returns nil, else it is rolled back. Calling WithTx
+// on the db.DB provided to the function runs within the same transaction.
+func (sq *SQL) WithTx(fn func(db.DB) error) error {
+	return sq.WithTxCtx(context.Background(), fn)
+}
+
+// WithTxCtx is the same as WithTx but begins the transaction within the provided context.
+func (sq *SQL) WithTxCtx(ctx context.Context, fn func(db.DB) error) (err error) {
+	defer sq.l.Emit(sinks.Info("Run DB transaction").Trace("db.WithTx").End())
+
+	if sq.tx != nil {
+		return fn(sq)
+	}
+
+	dbi, err := sq.conn()
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbi.BeginTxx(ctx, nil)
+	if err != nil {
+		sq.l.Emit(sinks.Error(err).With("err", err))
+		return err
+	}
+
+	defer func() {
+		if rerr := recover(); rerr != nil {
+			tx.Rollback()
+			panic(rerr)
+		}
+	}()
+
+	if err := fn(&SQL{l: sq.l, tx: tx}); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			sq.l.Emit(sinks.Error(rerr).With("err", rerr))
+		}
+
 		return err
 	}
 
-	defer dbi.Close()
+	return tx.Commit()
+}
+
+// migrate takes the individual query supplied and attempts to
+// execute them returning any error found.
+func (sq *SQL) migrate(dbi *sqlx.DB) error {
+	if sq.inited {
+		return nil
+	}
+
+	dialect := dialectOf(dbi)
 
 	for _, table := range sq.tables {
-		sq.l.Emit(sinks.Info("Executing Migration").WithFields(sink.Fields{
-			"query": table.String(),
-			"table": table.TableName,
-		}))
+		for _, query := range table.Statements(dialect) {
+			sq.l.Emit(sinks.Info("Executing Migration").WithFields(sink.Fields{
+				"query":   query,
+				"table":   table.TableName,
+				"dialect": dialect.Name(),
+			}))
 
-		if _, err := dbi.Exec(table.String()); err != nil {
-			sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{"query": table.String(), "table": table.TableName}))
-			return err
+			if _, err := dbi.Exec(query); err != nil {
+				sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{"query": query, "table": table.TableName}))
+				return err
+			}
 		}
 	}
 
@@ -87,47 +281,46 @@ func (sq *SQL) migrate() error {
 // Save takes the giving table name with the giving fields and attempts to save this giving
 // data appropriately into the giving db.
 func (sq *SQL) Save(identity db.TableIdentity, table db.TableFields) error {
-	defer sq.l.Emit(sinks.Info("Save to DB").With("table", identity.Table()).Trace("db.Save").End())
+	return sq.SaveCtx(context.Background(), identity, table)
+}
 
-	if err := sq.migrate(); err != nil {
-		return err
-	}
+// SaveCtx is the same as Save but executes the queries within the provided context.
+func (sq *SQL) SaveCtx(ctx context.Context, identity db.TableIdentity, table db.TableFields) error {
+	defer sq.l.Emit(sinks.Info("Save to DB").With("table", identity.Table()).Trace("db.Save").End())
 
-	db, err := sq.d.New()
+	exec, err := sq.exec()
 	if err != nil {
 		return err
 	}
 
-	defer db.Close()
+	fields := table.Fields()
+	fields["created_at"] = time.Now().UTC()
+	fields["updated_at"] = time.Now().UTC()
+
+	fieldNames := fieldNames(fields)
 
-	tx, err := db.Begin()
+	values, err := fieldValues(fieldNames, fields)
 	if err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"table": identity.Table(),
+		}))
 		return err
 	}
 
-	fields := table.Fields()
-	fieldNames := fieldNames(fields)
-	values := fieldValues(fieldNames, fields)
-
-	fieldNames = append(fieldNames, "created_at")
-	fieldNames = append(fieldNames, "updated_at")
-
-	values = append(values, time.Now().UTC())
-	values = append(values, time.Now().UTC())
-
-	query := fmt.Sprintf(insertTemplate, identity.Table(), fieldNameMarkers(fieldNames), fieldMarkers(len(fieldNames)))
+	query := fmt.Sprintf(insertTemplate, identity.Table(), fieldNameMarkers(fieldNames), fieldMarkers(dialectOf(exec), len(fieldNames)))
 	sq.l.Emit(sinks.Info("DB:Query").With("query", query))
 
-	if _, err := db.Exec(query, values...); err != nil {
+	if _, err := exec.ExecContext(ctx, query, values...); err != nil {
 		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"err":   err,
 			"query": query,
 			"table": identity.Table(),
 		}))
-		return err
+		return conflictErr(dialectOf(exec), err)
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 // Update takes the giving table name with the giving fields and attempts to update this giving
@@ -135,20 +328,14 @@ func (sq *SQL) Save(identity db.TableIdentity, table db.TableFields) error {
 // index - defines the string which should identify the key to be retrieved from the fields to target the
 // data to be updated in the db.
 func (sq *SQL) Update(identity db.TableIdentity, table db.TableFields, index string) error {
-	defer sq.l.Emit(sinks.Info("Update to DB").With("table", identity.Table()).Trace("db.Update").End())
-
-	if err := sq.migrate(); err != nil {
-		return err
-	}
-
-	db, err := sq.d.New()
-	if err != nil {
-		return err
-	}
+	return sq.UpdateCtx(context.Background(), identity, table, index)
+}
 
-	defer db.Close()
+// UpdateCtx is the same as Update but executes the queries within the provided context.
+func (sq *SQL) UpdateCtx(ctx context.Context, identity db.TableIdentity, table db.TableFields, index string) error {
+	defer sq.l.Emit(sinks.Info("Update to DB").With("table", identity.Table()).Trace("db.Update").End())
 
-	tx, err := db.Begin()
+	exec, err := sq.exec()
 	if err != nil {
 		return err
 	}
@@ -167,7 +354,13 @@ func (sq *SQL) Update(identity db.TableIdentity, table db.TableFields, index str
 		return err
 	}
 
-	indexValueString, err := printLiteral(indexValue)
+	// Delete given index from fieldNames
+	delete(tableFields, index)
+
+	dialect := dialectOf(exec)
+	names := fieldNames(tableFields)
+
+	values, err := fieldValues(names, tableFields)
 	if err != nil {
 		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"err":   err,
@@ -176,10 +369,7 @@ func (sq *SQL) Update(identity db.TableIdentity, table db.TableFields, index str
 		return err
 	}
 
-	// Delete given index from fieldNames
-	delete(tableFields, index)
-
-	sets, err := setValues(tableFields)
+	indexArg, err := bindValue(indexValue)
 	if err != nil {
 		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"err":   err,
@@ -188,91 +378,76 @@ func (sq *SQL) Update(identity db.TableIdentity, table db.TableFields, index str
 		return err
 	}
 
-	query := fmt.Sprintf(updateTemplate, identity.Table(), sets, index, indexValueString)
+	values = append(values, indexArg)
+
+	query := fmt.Sprintf(updateTemplate, identity.Table(), setMarkers(dialect, names), index, dialect.Placeholder(len(values)))
 	sq.l.Emit(sinks.Info("DB:Query").With("query", query))
 
-	if _, err := db.Exec(query); err != nil {
+	result, err := exec.ExecContext(ctx, query, values...)
+	if err != nil {
 		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"err":   err,
 			"query": query,
 			"table": identity.Table(),
 		}))
-		return err
+		return conflictErr(dialectOf(exec), err)
 	}
 
-	return tx.Commit()
+	return affected(result, identity, index, indexValue)
 }
 
 // GetAllPerPage retrieves the giving data from the specific db with the specific index and value.
 func (sq *SQL) GetAllPerPage(table db.TableIdentity, order string, orderBy string, page int, responsePerPage int) ([]map[string]interface{}, int, error) {
+	return sq.GetAllPerPageCtx(context.Background(), table, order, orderBy, page, responsePerPage)
+}
+
+// GetAllPerPageCtx is the same as GetAllPerPage but executes the queries within the provided context.
+func (sq *SQL) GetAllPerPageCtx(ctx context.Context, table db.TableIdentity, order string, orderBy string, page int, responsePerPage int) ([]map[string]interface{}, int, error) {
 	defer sq.l.Emit(sinks.Info("Retrieve all records from DB").With("table", table.Table()).WithFields(sink.Fields{
 		"order":           order,
 		"page":            page,
 		"responsePerPage": responsePerPage,
 	}).Trace("db.GetAll").End())
 
-	if err := sq.migrate(); err != nil {
-		return nil, -1, err
-	}
+	indexToStart := db.PageOffset(page, responsePerPage)
 
-	db, err := sq.d.New()
+	exec, err := sq.exec()
 	if err != nil {
 		return nil, -1, err
 	}
 
-	defer db.Close()
-
-	if page <= 0 && responsePerPage <= 0 {
-		records, err := sq.GetAll(table, order, orderBy)
+	if responsePerPage <= 0 {
+		records, err := sq.GetAllCtx(ctx, table, order, orderBy)
 		return records, len(records), err
 	}
 
 	// Get total number of records.
-	totalRecords, err := sq.Count(table)
+	totalRecords, err := sq.CountCtx(ctx, table)
 	if err != nil {
 		return nil, -1, err
 	}
 
-	switch strings.ToLower(order) {
-	case "asc":
-		order = "ASC"
-	case "dsc", "desc":
-		order = "DESC"
-	default:
-		order = "ASC"
-	}
-
-	var totalWanted, indexToStart int
-
-	if page <= 1 && responsePerPage > 0 {
-		totalWanted = responsePerPage
-		indexToStart = 0
-	} else {
-		totalWanted = responsePerPage * page
-		indexToStart = totalWanted / 2
-
-		if page > 1 {
-			indexToStart++
-		}
+	orderBy, order, err = sq.orderOf(table, orderBy, order)
+	if err != nil {
+		return nil, -1, err
 	}
 
 	sq.l.Emit(sinks.Info("DB:Query:GetAllPerPage").WithFields(sink.Fields{
-		"starting_index":       indexToStart,
-		"total_records_wanted": totalWanted,
-		"order":                order,
-		"page":                 page,
-		"responsePerPage":      responsePerPage,
+		"starting_index":  indexToStart,
+		"order":           order,
+		"page":            page,
+		"responsePerPage": responsePerPage,
 	}))
 
 	// If we are passed the total, just return nil records and total without error.
-	if indexToStart > totalRecords {
+	if indexToStart >= totalRecords {
 		return nil, totalRecords, nil
 	}
 
-	query := fmt.Sprintf(selectLimitedTemplate, table.Table(), orderBy, order, totalWanted, indexToStart)
+	query := fmt.Sprintf(selectLimitedTemplate, table.Table(), orderBy, order, responsePerPage, indexToStart)
 	sq.l.Emit(sinks.Info("DB:Query:GetAllPerPage").With("query", query))
 
-	rows, err := db.Queryx(query)
+	rows, err := exec.QueryxContext(ctx, query)
 	if err != nil {
 		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"err":   err,
@@ -282,6 +457,8 @@ func (sq *SQL) GetAllPerPage(table db.TableIdentity, order string, orderBy strin
 		return nil, -1, err
 	}
 
+	defer rows.Close()
+
 	var fields []map[string]interface{}
 
 	for rows.Next() {
@@ -305,34 +482,29 @@ func (sq *SQL) GetAllPerPage(table db.TableIdentity, order string, orderBy strin
 
 // GetAll retrieves the giving data from the specific db with the specific index and value.
 func (sq *SQL) GetAll(table db.TableIdentity, order string, orderBy string) ([]map[string]interface{}, error) {
+	return sq.GetAllCtx(context.Background(), table, order, orderBy)
+}
+
+// GetAllCtx is the same as GetAll but executes the queries within the provided context.
+func (sq *SQL) GetAllCtx(ctx context.Context, table db.TableIdentity, order string, orderBy string) ([]map[string]interface{}, error) {
 	defer sq.l.Emit(sinks.Info("Retrieve all records from DB").With("table", table.Table()).Trace("db.GetAll").End())
 
-	if err := sq.migrate(); err != nil {
+	exec, err := sq.exec()
+	if err != nil {
 		return nil, err
 	}
 
-	db, err := sq.d.New()
+	orderBy, order, err = sq.orderOf(table, orderBy, order)
 	if err != nil {
 		return nil, err
 	}
 
-	defer db.Close()
-
-	switch strings.ToLower(order) {
-	case "asc":
-		order = "ASC"
-	case "dsc", "desc":
-		order = "DESC"
-	default:
-		order = "ASC"
-	}
-
 	var fields []map[string]interface{}
 
 	query := fmt.Sprintf(selectAllTemplate, table.Table(), orderBy, order)
 	sq.l.Emit(sinks.Info("DB:Query:GetAll").With("query", query))
 
-	rows, err := db.Queryx(query)
+	rows, err := exec.QueryxContext(ctx, query)
 	if err != nil {
 		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"err":   err,
@@ -342,6 +514,8 @@ func (sq *SQL) GetAll(table db.TableIdentity, order string, orderBy string) ([]m
 		return nil, err
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
 		mo := make(map[string]interface{})
 		if err := rows.MapScan(mo); err != nil {
@@ -359,26 +533,191 @@ func (sq *SQL) GetAll(table db.TableIdentity, order string, orderBy string) ([]m
 	return fields, nil
 }
 
+// Find retrieves the records of the specific table matching the giving query.
+func (sq *SQL) Find(table db.TableIdentity, query db.Query) ([]map[string]interface{}, error) {
+	return sq.FindCtx(context.Background(), table, query)
+}
+
+// FindCtx is the same as Find but executes the queries within the provided context.
+func (sq *SQL) FindCtx(ctx context.Context, table db.TableIdentity, query db.Query) ([]map[string]interface{}, error) {
+	defer sq.l.Emit(sinks.Info("Find records from DB").With("table", table.Table()).Trace("db.Find").End())
+
+	if err := query.Validate(); err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"table": table.Table(),
+		}))
+		return nil, err
+	}
+
+	exec, err := sq.exec()
+	if err != nil {
+		return nil, err
+	}
+
+	statement, args, err := selectQuery(dialectOf(exec), table.Table(), query)
+	if err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"table": table.Table(),
+		}))
+		return nil, err
+	}
+
+	sq.l.Emit(sinks.Info("DB:Query:Find").With("query", statement))
+
+	rows, err := exec.QueryxContext(ctx, statement, args...)
+	if err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"query": statement,
+			"table": table.Table(),
+		}))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var fields []map[string]interface{}
+
+	for rows.Next() {
+		mo := make(map[string]interface{})
+		if err := rows.MapScan(mo); err != nil {
+			sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+				"err":   err,
+				"query": statement,
+				"table": table.Table(),
+			}))
+			return nil, err
+		}
+
+		fields = append(fields, naturalizeMap(mo))
+	}
+
+	return fields, rows.Err()
+}
+
+// CountWhere returns the number of records of the specific table matching the giving predicates.
+func (sq *SQL) CountWhere(table db.TableIdentity, where []db.Predicate) (int, error) {
+	return sq.CountWhereCtx(context.Background(), table, where)
+}
+
+// CountWhereCtx is the same as CountWhere but executes the queries within the provided context.
+func (sq *SQL) CountWhereCtx(ctx context.Context, table db.TableIdentity, where []db.Predicate) (int, error) {
+	defer sq.l.Emit(sinks.Info("Count matching records from DB").With("table", table.Table()).Trace("db.CountWhere").End())
+
+	if err := (db.Query{Where: where}).Validate(); err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"table": table.Table(),
+		}))
+		return 0, err
+	}
+
+	exec, err := sq.exec()
+	if err != nil {
+		return 0, err
+	}
+
+	statement, args, err := countQuery(dialectOf(exec), table.Table(), where)
+	if err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"table": table.Table(),
+		}))
+		return 0, err
+	}
+
+	sq.l.Emit(sinks.Info("DB:Query:CountWhere").With("query", statement))
+
+	var records int
+
+	if err := exec.GetContext(ctx, &records, statement, args...); err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"query": statement,
+			"table": table.Table(),
+		}))
+		return 0, err
+	}
+
+	return records, nil
+}
+
+// UpdateWhere updates the records of the specific table matching the giving predicates with
+// the fields, returning the number of records updated.
+func (sq *SQL) UpdateWhere(table db.TableIdentity, fields db.TableFields, where []db.Predicate) (int, error) {
+	return sq.UpdateWhereCtx(context.Background(), table, fields, where)
+}
+
+// UpdateWhereCtx is the same as UpdateWhere but executes the queries within the provided context.
+func (sq *SQL) UpdateWhereCtx(ctx context.Context, table db.TableIdentity, fields db.TableFields, where []db.Predicate) (int, error) {
+	defer sq.l.Emit(sinks.Info("Update matching records to DB").With("table", table.Table()).Trace("db.UpdateWhere").End())
+
+	if err := (db.Query{Where: where}).Validate(); err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"table": table.Table(),
+		}))
+		return 0, err
+	}
+
+	exec, err := sq.exec()
+	if err != nil {
+		return 0, err
+	}
+
+	tableFields := fields.Fields()
+	tableFields["updated_at"] = time.Now().UTC()
+
+	statement, args, err := updateQuery(dialectOf(exec), table.Table(), tableFields, where)
+	if err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"table": table.Table(),
+		}))
+		return 0, err
+	}
+
+	sq.l.Emit(sinks.Info("DB:Query:UpdateWhere").With("query", statement))
+
+	result, err := exec.ExecContext(ctx, statement, args...)
+	if err != nil {
+		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"err":   err,
+			"query": statement,
+			"table": table.Table(),
+		}))
+		return 0, conflictErr(dialectOf(exec), err)
+	}
+
+	total, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(total), nil
+}
+
 // Get retrieves the giving data from the specific db with the specific index and value.
 func (sq *SQL) Get(table db.TableIdentity, consumer db.TableConsumer, index string, indexValue interface{}) error {
+	return sq.GetCtx(context.Background(), table, consumer, index, indexValue)
+}
+
+// GetCtx is the same as Get but executes the queries within the provided context.
+func (sq *SQL) GetCtx(ctx context.Context, table db.TableIdentity, consumer db.TableConsumer, index string, indexValue interface{}) error {
 	defer sq.l.Emit(sinks.Info("Get record from DB").WithFields(sink.Fields{
 		"table":      table.Table(),
 		"index":      index,
 		"indexValue": indexValue,
 	}).Trace("db.Get").End())
 
-	if err := sq.migrate(); err != nil {
-		return err
-	}
-
-	db, err := sq.d.New()
+	exec, err := sq.exec()
 	if err != nil {
 		return err
 	}
 
-	defer db.Close()
-
-	indexValueString, err := printLiteral(indexValue)
+	indexArg, err := bindValue(indexValue)
 	if err != nil {
 		sq.l.Emit(sinks.Error("DB:Query: %+q", err).WithFields(sink.Fields{
 			"err":   err,
@@ -387,10 +726,10 @@ func (sq *SQL) Get(table db.TableIdentity, consumer db.TableConsumer, index stri
 		return err
 	}
 
-	query := fmt.Sprintf(selectItemTemplate, table.Table(), index, indexValueString)
+	query := fmt.Sprintf(selectItemTemplate, table.Table(), index, dialectOf(exec).Placeholder(1))
 	sq.l.Emit(sinks.Info("DB:Query").With("query", query))
 
-	row := db.QueryRowx(query)
+	row := exec.QueryRowxContext(ctx, query, indexArg)
 	if err := row.Err(); err != nil {
 		sq.l.Emit(sinks.Error("DB:Query: %+q", err).WithFields(sink.Fields{
 			"err":   err,
@@ -409,6 +748,10 @@ func (sq *SQL) Get(table db.TableIdentity, consumer db.TableConsumer, index stri
 			"table": table.Table(),
 		}))
 
+		if err == dsql.ErrNoRows {
+			return notFoundErr(table, index, indexValue)
+		}
+
 		return err
 	}
 
@@ -432,27 +775,26 @@ func (sq *SQL) Get(table db.TableIdentity, consumer db.TableConsumer, index stri
 
 // Count retrieves the total number of records from the specific table from the db.
 func (sq *SQL) Count(table db.TableIdentity) (int, error) {
+	return sq.CountCtx(context.Background(), table)
+}
+
+// CountCtx is the same as Count but executes the queries within the provided context.
+func (sq *SQL) CountCtx(ctx context.Context, table db.TableIdentity) (int, error) {
 	defer sq.l.Emit(sinks.Info("Count record from DB").WithFields(sink.Fields{
 		"table": table.Table(),
 	}).Trace("db.Get").End())
 
-	if err := sq.migrate(); err != nil {
-		return 0, err
-	}
-
-	db, err := sq.d.New()
+	exec, err := sq.exec()
 	if err != nil {
 		return 0, err
 	}
 
-	defer db.Close()
-
 	var records int
 
 	query := fmt.Sprintf(countTemplate, table.Table())
 	sq.l.Emit(sinks.Info("DB:Query").With("query", query))
 
-	if err := db.Get(&records, query); err != nil {
+	if err := exec.GetContext(ctx, &records, query); err != nil {
 		sq.l.Emit(sinks.Error("DB:Query").WithFields(sink.Fields{
 			"err":   err,
 			"query": query,
@@ -466,29 +808,23 @@ func (sq *SQL) Count(table db.TableIdentity) (int, error) {
 
 // Delete removes the giving data from the specific db with the specific index and value.
 func (sq *SQL) Delete(table db.TableIdentity, index string, indexValue interface{}) error {
+	return sq.DeleteCtx(context.Background(), table, index, indexValue)
+}
+
+// DeleteCtx is the same as Delete but executes the queries within the provided context.
+func (sq *SQL) DeleteCtx(ctx context.Context, table db.TableIdentity, index string, indexValue interface{}) error {
 	defer sq.l.Emit(sinks.Info("Delete record from DB").WithFields(sink.Fields{
 		"table":      table.Table(),
 		"index":      index,
 		"indexValue": indexValue,
 	}).Trace("db.GetAll").End())
 
-	if err := sq.migrate(); err != nil {
-		return err
-	}
-
-	db, err := sq.d.New()
-	if err != nil {
-		return err
-	}
-
-	defer db.Close()
-
-	tx, err := db.Begin()
+	exec, err := sq.exec()
 	if err != nil {
 		return err
 	}
 
-	indexValueString, err := printLiteral(indexValue)
+	indexArg, err := bindValue(indexValue)
 	if err != nil {
 		sq.l.Emit(sinks.Error("DB:Query: %+q", err).WithFields(sink.Fields{
 			"err":   err,
@@ -497,10 +833,11 @@ func (sq *SQL) Delete(table db.TableIdentity, index string, indexValue interface
 		return err
 	}
 
-	query := fmt.Sprintf(deleteTemplate, table.Table(), index, indexValueString)
+	query := fmt.Sprintf(deleteTemplate, table.Table(), index, dialectOf(exec).Placeholder(1))
 	sq.l.Emit(sinks.Info("DB:Query").With("query", query))
 
-	if _, err := db.Exec(query); err != nil {
+	result, err := exec.ExecContext(ctx, query, indexArg)
+	if err != nil {
 		sq.l.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"err":   err,
 			"query": query,
@@ -509,16 +846,59 @@ func (sq *SQL) Delete(table db.TableIdentity, index string, indexValue interface
 		return err
 	}
 
-	return tx.Commit()
+	return affected(result, table, index, indexValue)
+}
+
+// conflictErr wraps the giving error with db.ErrConflict if the dialect reports it as
+// a violation of a unique constraint. The driver's message is kept for logs, where the
+// resources deliver only a fixed detail for db.ErrConflict to clients.
+func conflictErr(dialect dialects.Dialect, err error) error {
+	if dialect.Conflict(err) {
+		return fmt.Errorf("%s: %w", err, db.ErrConflict)
+	}
+
+	return err
+}
+
+// notFoundErr returns a db.ErrNotFound error for the giving table and index. The table
+// and index are kept for logs, where the resources deliver only a fixed detail for
+// db.ErrNotFound to clients.
+func notFoundErr(table db.TableIdentity, index string, indexValue interface{}) error {
+	return fmt.Errorf("Record with %s=%v not found in %q: %w", index, indexValue, table.Table(), db.ErrNotFound)
+}
+
+// affected returns a db.ErrNotFound error if the giving result affected no rows.
+func affected(result dsql.Result, table db.TableIdentity, index string, indexValue interface{}) error {
+	total, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if total == 0 {
+		return notFoundErr(table, index, indexValue)
+	}
+
+	return nil
+}
+
+// dialectOf returns the Dialect associated with the driver of the giving executor,
+// defaulting to MySQL if none is known.
+func dialectOf(dbi executor) dialects.Dialect {
+	if dialect := dialects.ForDriver(dbi.DriverName()); dialect != nil {
+		return dialect
+	}
+
+	return dialects.MySQL{}
 }
 
 // FieldMarkers returns a (?,...,>) string which represents
-// all filedNames extrated from the provided TableField.
-func fieldMarkers(total int) string {
+// all filedNames extrated from the provided TableField, using the bind
+// markers of the provided dialect.
+func fieldMarkers(d dialects.Dialect, total int) string {
 	var markers []string
 
 	for i := 0; i < total; i++ {
-		markers = append(markers, "?")
+		markers = append(markers, d.Placeholder(i+1))
 	}
 
 	return "(" + strings.Join(markers, ",") + ")"
@@ -530,31 +910,33 @@ func fieldNameMarkers(fields []string) string {
 	return "(" + strings.Join(fields, ", ") + ")"
 }
 
-// fieldValues returns a (fieldName,...,fieldName) string which represents
-// all filedNames extrated from the provided TableField.
-func fieldValues(names []string, fields map[string]interface{}) []interface{} {
+// fieldValues returns the values of the provided fields in the order of the giving
+// names, converted into values which can be bound as query arguments.
+func fieldValues(names []string, fields map[string]interface{}) ([]interface{}, error) {
 	var vals []interface{}
 
 	for _, name := range names {
-		vals = append(vals, fields[name])
+		val, err := bindValue(fields[name])
+		if err != nil {
+			return nil, fmt.Errorf("Field %q: %s", name, err)
+		}
+
+		vals = append(vals, val)
 	}
 
-	return vals
+	return vals, nil
 }
 
-func setValues(fields map[string]interface{}) (string, error) {
-	var vals []string
+// setMarkers returns a fieldName=?,...,fieldName=? string for the giving names,
+// using the bind markers of the provided dialect.
+func setMarkers(d dialects.Dialect, names []string) string {
+	var sets []string
 
-	for name, val := range fields {
-		rv, err := printLiteral(val)
-		if err != nil {
-			return "", err
-		}
-
-		vals = append(vals, fmt.Sprintf("%s=%s", name, rv))
+	for index, name := range names {
+		sets = append(sets, fmt.Sprintf("%s=%s", name, d.Placeholder(index+1)))
 	}
 
-	return strings.Join(vals, ","), nil
+	return strings.Join(sets, ",")
 }
 
 // naturalizeMap returns a new map where all values of []bytes are converted to strings
@@ -575,20 +957,8 @@ func naturalizeMap(fields map[string]interface{}) map[string]interface{} {
 	return nz
 }
 
-// fieldNamesFromMap returns a (fieldName,...,fieldName) string which represents
-// all filedNames extrated from the provided TableField.
-func fieldNamesFromMap(fields map[string]interface{}) []string {
-	var names []string
-
-	for key := range fields {
-		names = append(names, key)
-	}
-
-	return names
-}
-
-// fieldNames returns a (fieldName,...,fieldName) string which represents
-// all filedNames extrated from the provided TableField.
+// fieldNames returns a sorted list of all fieldNames extracted from the provided
+// TableField.
 func fieldNames(fields map[string]interface{}) []string {
 	var names []string
 
@@ -596,26 +966,7 @@ func fieldNames(fields map[string]interface{}) []string {
 		names = append(names, key)
 	}
 
-	return names
-}
+	sort.Strings(names)
 
-// printLiteral attempts to provide a function to allow us easily convert
-// simple values like int, float, uint to string for use in queries.
-func printLiteral(item interface{}) (string, error) {
-	switch rl := item.(type) {
-	case int, int64, int32:
-		return strconv.Itoa(rl.(int)), nil
-	case float32, float64:
-		return strconv.FormatFloat(rl.(float64), 'f', 2, 64), nil
-	case string:
-		return strconv.Quote(rl), nil
-	case []byte:
-		return strconv.Quote(string(rl)), nil
-	case time.Time:
-		return strconv.Quote(rl.String()), nil
-	case byte:
-		return strconv.QuoteRune(rune(rl)), nil
-	default:
-		return "", errors.New("Not basic type")
-	}
+	return names
 }
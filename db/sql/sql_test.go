@@ -70,7 +70,7 @@ func TestSQLAPI(t *testing.T) {
 
 	nw, err := user.New(user.NewUser{
 		Email:    "bob@guma.com",
-		Password: "glow",
+		Password: "Glow-Worm-Lantern-42",
 	})
 	if err != nil {
 		tests.Failed("Should have successfully created new user: %+q.", err)
@@ -0,0 +1,941 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/sql"
+	"github.com/influx6/backoffice/db/sql/dialects"
+	"github.com/influx6/backoffice/db/sql/tables"
+	"github.com/influx6/backoffice/migrations/sqltables"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/naming"
+	"github.com/influx6/faux/tests"
+)
+
+func TestSQLiteAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backoffice")
+	if err != nil {
+		tests.Failed("Should have successfully created temporary directory: %+q.", err)
+	}
+	tests.Passed("Should have successfully created temporary directory.")
+
+	defer os.RemoveAll(dir)
+
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "test"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	nw, err := user.New(user.NewUser{
+		Email:    "bob@guma.com",
+		Password: "Glow-Worm-Lantern-42",
+	})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: filepath.Join(dir, "backoffice.db"),
+	}
+
+	db := sql.New(log, conn, sqltables.BasicTables(basicNamer)...)
+
+	t.Logf("Given the need to validate sql api operations against sqlite")
+	{
+
+		t.Log("\tWhen saving user record")
+		{
+			if err := db.Save(userTable, nw); err != nil {
+				tests.Failed("Should have successfully saved record to db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully saved record to db table %q.", userTable.Table())
+		}
+
+		t.Log("\tWhen counting user records")
+		{
+			total, err := db.Count(userTable)
+			if err != nil {
+				tests.Failed("Should have successfully counted records in db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully counted records in db table %q.", userTable.Table())
+
+			if total != 1 {
+				tests.Failed("Should have successfully recieved a count of 1.")
+			}
+			tests.Passed("Should have successfully recieved a count of 1.")
+		}
+
+		t.Log("\tWhen retrieving all user record")
+		{
+			records, err := db.GetAll(userTable, "asc", "public_id")
+			if err != nil {
+				tests.Failed("Should have successfully retrieved all records from db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully retrieved all records from db table %q.", userTable.Table())
+
+			if len(records) != 1 {
+				tests.Failed("Should have successfully retrieved one record from db table %q.", userTable.Table())
+			}
+			tests.Passed("Should have successfully retrieved one record from db table %q.", userTable.Table())
+		}
+
+		t.Log("\tWhen retrieving user record")
+		{
+			var nu user.User
+			if err := db.Get(userTable, &nu, "public_id", nw.PublicID); err != nil {
+				tests.Failed("Should have successfully retrieved record from db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully retrieved record from db table %q.", userTable.Table())
+
+			if nu.PublicID != nw.PublicID {
+				tests.Info("Expected: %+q", nw.Fields())
+				tests.Info("Recieved: %+q", nu.Fields())
+				tests.Failed("Should have successfully matched original user with user retrieved from db.")
+			}
+			tests.Passed("Should have successfully matched original user with user retrieved from db.")
+		}
+
+		t.Log("\tWhen updating user record")
+		{
+			if err := db.Update(userTable, nw, "public_id"); err != nil {
+				tests.Failed("Should have successfully updated record to db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully updated record to db table %q.", userTable.Table())
+		}
+
+		t.Logf("\tWhen deleting user record")
+		{
+			if err := db.Delete(userTable, "public_id", nw.PublicID); err != nil {
+				tests.Failed("Should have successfully deleted record to db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully deleted record to db table %q.", userTable.Table())
+
+			total, err := db.Count(userTable)
+			if err != nil {
+				tests.Failed("Should have successfully counted records in db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully counted records in db table %q.", userTable.Table())
+
+			if total != 0 {
+				tests.Failed("Should have successfully removed record from db table %q.", userTable.Table())
+			}
+			tests.Passed("Should have successfully removed record from db table %q.", userTable.Table())
+		}
+	}
+}
+
+type record map[string]interface{}
+
+func (r record) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(r))
+	for key, value := range r {
+		fields[key] = value
+	}
+	return fields
+}
+
+func (r record) WithFields(fields map[string]interface{}) error {
+	for key, value := range fields {
+		r[key] = value
+	}
+	return nil
+}
+
+func TestSQLiteTypedValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backoffice")
+	if err != nil {
+		tests.Failed("Should have successfully created temporary directory: %+q.", err)
+	}
+	tests.Passed("Should have successfully created temporary directory.")
+
+	defer os.RemoveAll(dir)
+
+	recordTable := db.TableName{Name: "records"}
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: filepath.Join(dir, "backoffice.db"),
+	}
+
+	db := sql.New(log, conn, tables.TableMigration{
+		TableName:   recordTable.Table(),
+		Timestamped: true,
+		Fields: []tables.FieldMigration{
+			{FieldName: "public_id", FieldType: "VARCHAR(255)", PrimaryKey: true, NotNull: true},
+			{FieldName: "total", FieldType: "BIGINT", NotNull: true},
+			{FieldName: "size", FieldType: "INTEGER", NotNull: true},
+			{FieldName: "active", FieldType: "BOOLEAN", NotNull: true},
+			{FieldName: "ratio", FieldType: "REAL", NotNull: true},
+			{FieldName: "note", FieldType: "VARCHAR(255)"},
+			{FieldName: "seen", FieldType: "timestamp", NotNull: true},
+		},
+	})
+
+	note := "hello"
+	publicID := `bob"; DROP TABLE records; --`
+
+	t.Logf("Given the need to validate typed values against sqlite")
+	{
+		t.Log("\tWhen saving a record with typed values")
+		{
+			if err := db.Save(recordTable, record{
+				"public_id": publicID,
+				"total":     int64(math.MaxInt64),
+				"size":      uint32(20),
+				"active":    true,
+				"ratio":     float32(0.5),
+				"note":      &note,
+				"seen":      time.Now().UTC(),
+			}); err != nil {
+				tests.Failed("Should have successfully saved record: %+q.", err)
+			}
+			tests.Passed("Should have successfully saved record.")
+		}
+
+		t.Log("\tWhen updating a record with typed values")
+		{
+			if err := db.Update(recordTable, record{
+				"public_id": publicID,
+				"size":      uint(40),
+				"active":    false,
+				"note":      nil,
+			}, "public_id"); err != nil {
+				tests.Failed("Should have successfully updated record: %+q.", err)
+			}
+			tests.Passed("Should have successfully updated record.")
+		}
+
+		t.Log("\tWhen retrieving a record with typed values")
+		{
+			rc := make(record)
+			if err := db.Get(recordTable, rc, "public_id", publicID); err != nil {
+				tests.Failed("Should have successfully retrieved record: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved record.")
+
+			if rc["total"] != int64(math.MaxInt64) {
+				tests.Info("Recieved: %#v", rc["total"])
+				tests.Failed("Should have retrieved int64 value.")
+			}
+			tests.Passed("Should have retrieved int64 value.")
+
+			if rc["size"] != int64(40) {
+				tests.Info("Recieved: %#v", rc["size"])
+				tests.Failed("Should have retrieved updated uint value.")
+			}
+			tests.Passed("Should have retrieved updated uint value.")
+
+			if rc["note"] != nil {
+				tests.Info("Recieved: %#v", rc["note"])
+				tests.Failed("Should have retrieved nil value.")
+			}
+			tests.Passed("Should have retrieved nil value.")
+		}
+
+		t.Log("\tWhen deleting a record with typed values")
+		{
+			if err := db.Delete(recordTable, "public_id", &publicID); err != nil {
+				tests.Failed("Should have successfully deleted record: %+q.", err)
+			}
+			tests.Passed("Should have successfully deleted record.")
+
+		}
+
+		t.Log("\tWhen saving a record with overflowing values")
+		{
+			if err := db.Save(recordTable, record{"public_id": "bad", "total": uint64(math.MaxUint64)}); err == nil {
+				tests.Failed("Should have failed to save overflowing unsigned value.")
+			}
+			tests.Passed("Should have failed to save overflowing unsigned value.")
+		}
+	}
+}
+
+func TestSQLitePool(t *testing.T) {
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "pool"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: ":memory:",
+	}
+
+	db := sql.NewWithPool(log, conn, sql.Pool{MaxOpen: 1, MaxIdle: 1}, sqltables.BasicTables(basicNamer)...)
+
+	t.Logf("Given the need to validate a pooled sqlite in-memory db")
+	{
+		t.Log("\tWhen opening the db")
+		{
+			if err := db.Open(); err != nil {
+				tests.Failed("Should have successfully opened db: %+q.", err)
+			}
+			tests.Passed("Should have successfully opened db.")
+
+			defer db.Close()
+		}
+
+		t.Log("\tWhen saving records across calls")
+		{
+			for i := 0; i < 3; i++ {
+				nw, err := user.New(user.NewUser{Email: fmt.Sprintf("bob%d@guma.com", i), Password: "Glow-Worm-Lantern-42"})
+				if err != nil {
+					tests.Failed("Should have successfully created new user: %+q.", err)
+				}
+
+				if err := db.Save(userTable, nw); err != nil {
+					tests.Failed("Should have successfully saved record to db table %q: %+q.", userTable.Table(), err)
+				}
+			}
+			tests.Passed("Should have successfully saved records to db table %q.", userTable.Table())
+
+			total, err := db.Count(userTable)
+			if err != nil {
+				tests.Failed("Should have successfully counted records in db table %q: %+q.", userTable.Table(), err)
+			}
+			tests.Passed("Should have successfully counted records in db table %q.", userTable.Table())
+
+			if total != 3 {
+				tests.Failed("Should have kept all records within the pooled in-memory db.")
+			}
+			tests.Passed("Should have kept all records within the pooled in-memory db.")
+		}
+
+		t.Log("\tWhen pinging the db")
+		{
+			if err := db.Ping(); err != nil {
+				tests.Failed("Should have successfully pinged db: %+q.", err)
+			}
+			tests.Passed("Should have successfully pinged db.")
+		}
+	}
+}
+
+func TestSQLiteTx(t *testing.T) {
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "tx"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: ":memory:",
+	}
+
+	store := sql.NewWithPool(log, conn, sql.Pool{MaxOpen: 1, MaxIdle: 1}, sqltables.BasicTables(basicNamer)...)
+	defer store.Close()
+
+	nw, err := user.New(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	t.Logf("Given the need to validate sqlite transactions")
+	{
+		t.Log("\tWhen a transaction fails")
+		{
+			failure := errors.New("failed")
+
+			err := store.WithTx(func(tx db.DB) error {
+				if err := tx.Save(userTable, nw); err != nil {
+					return err
+				}
+
+				var nu user.User
+				if err := tx.Get(userTable, &nu, "public_id", nw.PublicID); err != nil {
+					return err
+				}
+
+				return failure
+			})
+			if err != failure {
+				tests.Failed("Should have received transaction failure: %+q.", err)
+			}
+			tests.Passed("Should have received transaction failure.")
+
+			if total, _ := store.Count(userTable); total != 0 {
+				tests.Failed("Should have rolled back saved record.")
+			}
+			tests.Passed("Should have rolled back saved record.")
+		}
+
+		t.Log("\tWhen a transaction succeeds")
+		{
+			err := store.WithTx(func(tx db.DB) error {
+				return tx.Save(userTable, nw)
+			})
+			if err != nil {
+				tests.Failed("Should have successfully committed transaction: %+q.", err)
+			}
+			tests.Passed("Should have successfully committed transaction.")
+
+			if total, _ := store.Count(userTable); total != 1 {
+				tests.Failed("Should have committed saved record.")
+			}
+			tests.Passed("Should have committed saved record.")
+		}
+	}
+}
+
+func TestSQLiteMigrator(t *testing.T) {
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "versioned"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: ":memory:",
+	}
+
+	store := sql.NewWithPool(log, conn, sql.Pool{MaxOpen: 1, MaxIdle: 1})
+	defer store.Close()
+
+	// The custom migration follows all migrations of the backoffice tables.
+	builtin := sqltables.Migrations(basicNamer)
+	custom := len(builtin) + 1
+
+	migrations := append(builtin, tables.Migration{
+		Version: custom,
+		Name:    "users_nickname",
+		Up: []tables.Step{
+			tables.AddColumn{TableName: userTable.Table(), Field: tables.FieldMigration{FieldName: "nickname", FieldType: "VARCHAR(255)"}},
+			tables.AddIndex{TableName: userTable.Table(), Index: tables.IndexMigration{IndexName: "nickname", Field: "nickname"}},
+		},
+		Down: []tables.Step{
+			tables.DropIndex{TableName: userTable.Table(), IndexName: "nickname"},
+			tables.DropColumn{TableName: userTable.Table(), FieldName: "nickname"},
+		},
+	})
+
+	migrator := sql.NewMigrator(log, store, migrations...)
+
+	t.Logf("Given the need to validate versioned migrations against sqlite")
+	{
+		t.Log("\tWhen applying migrations")
+		{
+			applied, err := migrator.Apply()
+			if err != nil {
+				tests.Failed("Should have successfully applied migrations: %+q.", err)
+			}
+			tests.Passed("Should have successfully applied migrations.")
+
+			if len(applied) != custom {
+				tests.Failed("Should have applied %d migrations.", custom)
+			}
+			tests.Passed("Should have applied %d migrations.", custom)
+
+			if err := store.Save(userTable, record{"public_id": "1", "private_id": "1", "email": "bob@guma.com", "hash": "-", "nickname": "bob"}); err != nil {
+				tests.Failed("Should have successfully saved record with added column: %+q.", err)
+			}
+			tests.Passed("Should have successfully saved record with added column.")
+		}
+
+		t.Log("\tWhen re-applying migrations")
+		{
+			applied, err := migrator.Apply()
+			if err != nil {
+				tests.Failed("Should have successfully re-applied migrations: %+q.", err)
+			}
+			tests.Passed("Should have successfully re-applied migrations.")
+
+			if len(applied) != 0 {
+				tests.Failed("Should have not applied migrations twice.")
+			}
+			tests.Passed("Should have not applied migrations twice.")
+		}
+
+		t.Log("\tWhen rolling back a migration")
+		{
+			reverted, err := migrator.Rollback(1)
+			if err != nil {
+				tests.Failed("Should have successfully rolled back migration: %+q.", err)
+			}
+			tests.Passed("Should have successfully rolled back migration.")
+
+			if len(reverted) != 1 || reverted[0].Version != custom {
+				tests.Failed("Should have rolled back migration version %d.", custom)
+			}
+			tests.Passed("Should have rolled back migration version %d.", custom)
+
+			statuses, err := migrator.Status()
+			if err != nil {
+				tests.Failed("Should have successfully retrieved migration status: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved migration status.")
+
+			if len(statuses) != custom || statuses[custom-1].Applied {
+				tests.Info("Statuses: %+v", statuses)
+				tests.Failed("Should have rolled back only the custom migration.")
+			}
+
+			for _, status := range statuses[:custom-1] {
+				if !status.Applied {
+					tests.Info("Statuses: %+v", statuses)
+					tests.Failed("Should have kept version %d applied.", status.Version)
+				}
+			}
+			tests.Passed("Should have rolled back only the custom migration.")
+
+			if total, err := store.Count(userTable); err != nil || total != 1 {
+				tests.Failed("Should have kept records of table from version 1: %+q.", err)
+			}
+			tests.Passed("Should have kept records of table from version 1.")
+		}
+	}
+}
+
+// TestSQLiteNormalizeEmails validates that the unique emails migration normalizes the
+// emails of existing users as user.NormalizeEmail does.
+func TestSQLiteNormalizeEmails(t *testing.T) {
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "normalized"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: ":memory:",
+	}
+
+	store := sql.NewWithPool(log, conn, sql.Pool{MaxOpen: 1, MaxIdle: 1})
+	defer store.Close()
+
+	migrations := sqltables.Migrations(basicNamer)
+
+	t.Logf("Given the need to normalize the emails of existing users")
+	{
+		t.Log("\tWhen applying the unique emails migration")
+		{
+			if _, err := sql.NewMigrator(log, store, migrations[:6]...).Apply(); err != nil {
+				tests.Failed("Should have successfully applied migrations before unique emails: %+q.", err)
+			}
+			tests.Passed("Should have successfully applied migrations before unique emails.")
+
+			for id, email := range map[string]string{"1": " Bob@Guma.com ", "2": "alice@Bücher.de", "3": "not-an-email "} {
+				if err := store.Save(userTable, record{"public_id": id, "private_id": id, "email": email, "hash": "-"}); err != nil {
+					tests.Failed("Should have successfully saved user: %+q.", err)
+				}
+			}
+			tests.Passed("Should have successfully saved users.")
+
+			if _, err := sql.NewMigrator(log, store, migrations...).Apply(); err != nil {
+				tests.Failed("Should have successfully applied remaining migrations: %+q.", err)
+			}
+			tests.Passed("Should have successfully applied remaining migrations.")
+
+			records, err := store.GetAll(userTable, "asc", "public_id")
+			if err != nil {
+				tests.Failed("Should have successfully retrieved users: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved users.")
+
+			var emails []string
+			for _, record := range records {
+				emails = append(emails, record["email"].(string))
+			}
+
+			if got := strings.Join(emails, ","); got != "bob@guma.com,alice@xn--bcher-kva.de,not-an-email" {
+				tests.Info("Emails: %s", got)
+				tests.Failed("Should have normalized emails as user.NormalizeEmail does.")
+			}
+			tests.Passed("Should have normalized emails as user.NormalizeEmail does.")
+		}
+
+		t.Log("\tWhen existing users share an email once normalized")
+		{
+			// The shared in-memory database already holds the migrated users table.
+			dir, err := ioutil.TempDir("", "backoffice")
+			if err != nil {
+				tests.Failed("Should have successfully created temporary directory: %+q.", err)
+			}
+			tests.Passed("Should have successfully created temporary directory.")
+
+			defer os.RemoveAll(dir)
+
+			sharedConn := conn
+			sharedConn.Database = filepath.Join(dir, "backoffice.db")
+
+			sharedStore := sql.NewWithPool(log, sharedConn, sql.Pool{MaxOpen: 1, MaxIdle: 1})
+			defer sharedStore.Close()
+
+			if _, err := sql.NewMigrator(log, sharedStore, migrations[:6]...).Apply(); err != nil {
+				tests.Failed("Should have successfully applied migrations before unique emails: %+q.", err)
+			}
+			tests.Passed("Should have successfully applied migrations before unique emails.")
+
+			for id, email := range map[string]string{"1": "Bob@Guma.com", "2": " bob@guma.com", "3": "alice@guma.com"} {
+				if err := sharedStore.Save(userTable, record{"public_id": id, "private_id": id, "email": email, "hash": "-"}); err != nil {
+					tests.Failed("Should have successfully saved user: %+q.", err)
+				}
+			}
+			tests.Passed("Should have successfully saved users.")
+
+			_, err = sql.NewMigrator(log, sharedStore, migrations...).Apply()
+			if err == nil || !strings.Contains(err.Error(), `"bob@guma.com" by 1, 2`) {
+				tests.Failed("Should have failed migration listing users sharing an email: %+q.", err)
+			}
+			tests.Passed("Should have failed migration listing users sharing an email.")
+
+			records, err := sharedStore.GetAll(userTable, "asc", "public_id")
+			if err != nil || len(records) != 3 || records[0]["email"] != "Bob@Guma.com" {
+				tests.Info("Records: %+v", records)
+				tests.Failed("Should have left emails as they were: %+q.", err)
+			}
+			tests.Passed("Should have left emails as they were.")
+		}
+	}
+}
+
+func TestSQLiteNotFound(t *testing.T) {
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "missing"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: ":memory:",
+	}
+
+	store := sql.NewWithPool(log, conn, sql.Pool{MaxOpen: 1, MaxIdle: 1}, sqltables.BasicTables(basicNamer)...)
+	defer store.Close()
+
+	nw, err := user.New(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	t.Logf("Given the need to detect missing sqlite records")
+	{
+		t.Log("\tWhen retrieving a missing record")
+		{
+			var nu user.User
+			if err := store.Get(userTable, &nu, "public_id", nw.PublicID); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have failed with db.ErrNotFound: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrNotFound.")
+		}
+
+		t.Log("\tWhen updating a missing record")
+		{
+			if err := store.Update(userTable, nw, "public_id"); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have failed with db.ErrNotFound: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrNotFound.")
+		}
+
+		t.Log("\tWhen deleting a missing record")
+		{
+			if err := store.Delete(userTable, "public_id", nw.PublicID); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have failed with db.ErrNotFound: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrNotFound.")
+		}
+
+		t.Log("\tWhen saving a duplicate record")
+		{
+			if err := store.Save(userTable, nw); err != nil {
+				tests.Failed("Should have successfully saved record: %+q.", err)
+			}
+			tests.Passed("Should have successfully saved record.")
+
+			if err := store.Save(userTable, nw); !errors.Is(err, db.ErrConflict) {
+				tests.Failed("Should have failed with db.ErrConflict: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrConflict.")
+		}
+
+		t.Log("\tWhen saving a record with the email of another")
+		{
+			other, err := user.New(user.NewUser{Email: nw.Email, Password: "Glow-Worm-Lantern-42"})
+			if err != nil {
+				tests.Failed("Should have successfully created new user: %+q.", err)
+			}
+			tests.Passed("Should have successfully created new user.")
+
+			if err := store.Save(userTable, other); !errors.Is(err, db.ErrConflict) {
+				tests.Failed("Should have failed with db.ErrConflict on unique email: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrConflict on unique email.")
+		}
+
+		t.Log("\tWhen updating and deleting an existing record")
+		{
+			if err := store.Update(userTable, nw, "public_id"); err != nil {
+				tests.Failed("Should have successfully updated record: %+q.", err)
+			}
+			tests.Passed("Should have successfully updated record.")
+
+			if err := store.Delete(userTable, "public_id", nw.PublicID); err != nil {
+				tests.Failed("Should have successfully deleted record: %+q.", err)
+			}
+			tests.Passed("Should have successfully deleted record.")
+		}
+	}
+}
+
+func TestSQLiteFind(t *testing.T) {
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "find"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: ":memory:",
+	}
+
+	store := sql.NewWithPool(log, conn, sql.Pool{MaxOpen: 1, MaxIdle: 1}, sqltables.BasicTables(basicNamer)...)
+	defer store.Close()
+
+	var bob *user.User
+
+	for _, email := range []string{"bob@guma.com", "alice@guma.com", "carl@other.com"} {
+		nw, err := user.New(user.NewUser{Email: email, Password: "Glow-Worm-Lantern-42"})
+		if err != nil {
+			tests.Failed("Should have successfully created new user: %+q.", err)
+		}
+
+		if err := store.Save(userTable, nw); err != nil {
+			tests.Failed("Should have successfully saved user: %+q.", err)
+		}
+
+		if bob == nil {
+			bob = nw
+		}
+	}
+	tests.Passed("Should have successfully saved users.")
+
+	t.Logf("Given the need to find sqlite records with a query")
+	{
+		t.Log("\tWhen filtering with like and ordering by email")
+		{
+			records, err := store.Find(userTable, db.Query{
+				Where: []db.Predicate{db.Where("email", db.Like, "%GUMA%")},
+				Order: []db.Order{{Field: "email"}},
+			})
+			if err != nil {
+				tests.Failed("Should have successfully found records: %+q.", err)
+			}
+			tests.Passed("Should have successfully found records.")
+
+			if len(records) != 2 || records[0]["email"] != "alice@guma.com" || records[1]["email"] != "bob@guma.com" {
+				tests.Info("Records: %#v", records)
+				tests.Failed("Should have found ordered guma users.")
+			}
+			tests.Passed("Should have found ordered guma users.")
+		}
+
+		t.Log("\tWhen filtering with an or group, limit and projection")
+		{
+			records, err := store.Find(userTable, db.Query{
+				Where:  []db.Predicate{db.Or(db.Where("email", db.Eq, "carl@other.com"), db.Where("email", db.In, []string{"bob@guma.com"}))},
+				Order:  []db.Order{{Field: "email", Desc: true}},
+				Limit:  1,
+				Fields: []string{"email"},
+			})
+			if err != nil {
+				tests.Failed("Should have successfully found records: %+q.", err)
+			}
+			tests.Passed("Should have successfully found records.")
+
+			if len(records) != 1 || len(records[0]) != 1 || records[0]["email"] != "carl@other.com" {
+				tests.Info("Records: %#v", records)
+				tests.Failed("Should have found only the projected carl record.")
+			}
+			tests.Passed("Should have found only the projected carl record.")
+		}
+
+		t.Log("\tWhen filtering on timestamps and offset")
+		{
+			records, err := store.Find(userTable, db.Query{
+				Where:  []db.Predicate{db.Where("created_at", db.Gt, time.Now().UTC().Add(-time.Hour)), db.Where("email", db.Ne, "carl@other.com")},
+				Order:  []db.Order{{Field: "email"}},
+				Offset: 1,
+			})
+			if err != nil {
+				tests.Failed("Should have successfully found records: %+q.", err)
+			}
+			tests.Passed("Should have successfully found records.")
+
+			if len(records) != 1 || records[0]["email"] != "bob@guma.com" {
+				tests.Info("Records: %#v", records)
+				tests.Failed("Should have found bob after offset.")
+			}
+			tests.Passed("Should have found bob after offset.")
+		}
+
+		t.Log("\tWhen counting records matching predicates")
+		{
+			total, err := store.CountWhere(userTable, []db.Predicate{db.Or(db.Where("email", db.Like, "%GUMA%"), db.Where("email", db.In, []string{"carl@other.com"})), db.Where("email", db.Ne, "bob@guma.com")})
+			if err != nil {
+				tests.Failed("Should have successfully counted records: %+q.", err)
+			}
+			tests.Passed("Should have successfully counted records.")
+
+			if total != 2 {
+				tests.Failed("Should have counted 2 records, got %d.", total)
+			}
+			tests.Passed("Should have counted 2 records.")
+
+			if _, err := store.CountWhere(userTable, []db.Predicate{db.Where("email; DROP", db.Eq, "")}); err == nil {
+				tests.Failed("Should have failed to count records with invalid field.")
+			}
+			tests.Passed("Should have failed to count records with invalid field.")
+		}
+
+		t.Log("\tWhen updating records still matching predicates")
+		{
+			swap := []db.Predicate{db.Where("public_id", db.Eq, bob.PublicID), db.Where("hash", db.Eq, bob.Hash)}
+
+			updated, err := store.UpdateWhere(userTable, user.UpdateUserHash{PublicID: bob.PublicID, Hash: "swapped"}, swap)
+			if err != nil || updated != 1 {
+				tests.Failed("Should have updated the matching record, got %d: %+q.", updated, err)
+			}
+			tests.Passed("Should have updated the matching record.")
+
+			updated, err = store.UpdateWhere(userTable, user.UpdateUserHash{PublicID: bob.PublicID, Hash: "lost"}, swap)
+			if err != nil || updated != 0 {
+				tests.Failed("Should have updated no record once it changed, got %d: %+q.", updated, err)
+			}
+			tests.Passed("Should have updated no record once it changed.")
+
+			total, err := store.CountWhere(userTable, []db.Predicate{db.Where("hash", db.Eq, "swapped")})
+			if err != nil || total != 1 {
+				tests.Failed("Should have kept the first update, got %d: %+q.", total, err)
+			}
+			tests.Passed("Should have kept the first update.")
+		}
+	}
+}
+
+func TestSQLitePages(t *testing.T) {
+	basicNamer := naming.NewNamer("%s_%s", naming.PrefixNamer{Prefix: "pages"})
+	userTable := db.TableName{Name: basicNamer.New("users")}
+
+	conn := sql.Conn{
+		Log:      log,
+		Dialect:  dialects.SQLite{},
+		Database: ":memory:",
+	}
+
+	store := sql.NewWithPool(log, conn, sql.Pool{MaxOpen: 1, MaxIdle: 1}, sqltables.BasicTables(basicNamer)...)
+	defer store.Close()
+
+	save := func(email string) {
+		nw, err := user.New(user.NewUser{Email: email, Password: "Glow-Worm-Lantern-42"})
+		if err != nil {
+			tests.Failed("Should have successfully created new user: %+q.", err)
+		}
+
+		if err := store.Save(userTable, nw); err != nil {
+			tests.Failed("Should have successfully saved user: %+q.", err)
+		}
+	}
+
+	for _, email := range []string{"u0@guma.com", "u1@guma.com", "u2@guma.com", "u3@guma.com", "u4@guma.com"} {
+		save(email)
+	}
+	tests.Passed("Should have successfully saved users.")
+
+	emails := func(records []map[string]interface{}) string {
+		var list []string
+		for _, record := range records {
+			list = append(list, record["email"].(string))
+		}
+		return strings.Join(list, ",")
+	}
+
+	t.Logf("Given the need to retrieve pages of sqlite records")
+	{
+		t.Log("\tWhen retrieving pages by offset")
+		{
+			records, total, err := store.GetAllPerPage(userTable, "asc", "email", 2, 2)
+			if err != nil {
+				tests.Failed("Should have successfully retrieved page: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved page.")
+
+			if total != 5 || emails(records) != "u2@guma.com,u3@guma.com" {
+				tests.Info("Total: %d, Records: %s", total, emails(records))
+				tests.Failed("Should have retrieved the second page of 2 records.")
+			}
+			tests.Passed("Should have retrieved the second page of 2 records.")
+
+			records, _, err = store.GetAllPerPage(userTable, "asc", "email", 3, 2)
+			if err != nil || emails(records) != "u4@guma.com" {
+				tests.Info("Records: %s", emails(records))
+				tests.Failed("Should have retrieved the last record on the third page: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the last record on the third page.")
+
+			records, _, err = store.GetAllPerPage(userTable, "asc", "email", 4, 2)
+			if err != nil || len(records) != 0 {
+				tests.Failed("Should have retrieved no records past the last page: %+q.", err)
+			}
+			tests.Passed("Should have retrieved no records past the last page.")
+
+			records, _, err = store.GetAllPerPage(userTable, "desc", "email", 1, 2)
+			if err != nil || emails(records) != "u4@guma.com,u3@guma.com" {
+				tests.Info("Records: %s", emails(records))
+				tests.Failed("Should have retrieved the first page in descending order: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the first page in descending order.")
+
+			if _, _, err := store.GetAllPerPage(userTable, "asc", "email; DROP TABLE users", 1, 2); !errors.Is(err, db.ErrInvalidQuery) {
+				tests.Failed("Should have refused to order by an invalid column with db.ErrInvalidQuery: %+q.", err)
+			}
+			tests.Passed("Should have refused to order by an invalid column with db.ErrInvalidQuery.")
+
+			if _, err := store.GetAll(userTable, "asc", "1=1 --"); !errors.Is(err, db.ErrInvalidQuery) {
+				tests.Failed("Should have refused to order all records by an invalid column with db.ErrInvalidQuery: %+q.", err)
+			}
+			tests.Passed("Should have refused to order all records by an invalid column with db.ErrInvalidQuery.")
+		}
+
+		t.Log("\tWhen retrieving pages by cursor with concurrent inserts")
+		{
+			first, err := db.FindPage(context.Background(), store, userTable, nil, "", 2)
+			if err != nil || emails(first.Records) != "u0@guma.com,u1@guma.com" || first.Next == "" || first.Prev != "" {
+				tests.Info("Page: %s %+v", emails(first.Records), first)
+				tests.Failed("Should have retrieved the first page with only a next cursor: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the first page with only a next cursor.")
+
+			second, err := db.FindPage(context.Background(), store, userTable, nil, first.Next, 2)
+			if err != nil || emails(second.Records) != "u2@guma.com,u3@guma.com" || second.Next == "" || second.Prev == "" {
+				tests.Info("Page: %s %+v", emails(second.Records), second)
+				tests.Failed("Should have retrieved the second page with both cursors: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the second page with both cursors.")
+
+			save("u5@guma.com")
+
+			third, err := db.FindPage(context.Background(), store, userTable, nil, second.Next, 2)
+			if err != nil || emails(third.Records) != "u4@guma.com,u5@guma.com" || third.Next != "" {
+				tests.Info("Page: %s %+v", emails(third.Records), third)
+				tests.Failed("Should have retrieved the last page including the new record: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the last page including the new record.")
+
+			previous, err := db.FindPage(context.Background(), store, userTable, nil, third.Prev, 2)
+			if err != nil || emails(previous.Records) != "u2@guma.com,u3@guma.com" || previous.Prev == "" || previous.Next == "" {
+				tests.Info("Page: %s %+v", emails(previous.Records), previous)
+				tests.Failed("Should have retrieved the second page again by prev cursor: %+q.", err)
+			}
+			tests.Passed("Should have retrieved the second page again by prev cursor.")
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+
+	"github.com/influx6/backoffice/db/sql/dialects"
 )
 
 // TableMigration defines a struct which defines a query field to be run against.
@@ -15,79 +17,74 @@ type TableMigration struct {
 	Queries     []string         `json:"queries"` // complete sql queries which will be ran.
 }
 
-// String returns the index query for the giving table migration.
+// String returns the index query for the giving table migration using the
+// MySQL dialect.
 func (table TableMigration) String() string {
-	var b bytes.Buffer
-
-	if table.TableName != "" {
-		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", table.TableName)
+	return strings.Join(table.Statements(dialects.MySQL{}), "\r\n")
+}
 
-		total := len(table.Fields) - 1
-		hasFields := table.Fields != nil
+// Statements returns the list of queries to be executed in order for the giving table
+// migration, generated for the provided dialect.
+func (table TableMigration) Statements(d dialects.Dialect) []string {
+	var statements []string
 
-		for index, field := range table.Fields {
-			fmt.Fprintf(&b, "\t%s", field.String())
+	if table.TableName != "" {
+		var b bytes.Buffer
+		var columns, indexes []string
 
-			if index < total {
-				fmt.Fprintf(&b, ",\n")
-			}
+		for _, field := range table.Fields {
+			columns = append(columns, field.Build(d))
 		}
 
 		if table.Timestamped {
-			if hasFields {
-				fmt.Fprint(&b, ",\n")
-			}
-
-			fmt.Fprint(&b, "\tcreated_at timestamp NOT NULL,\n")
-			fmt.Fprint(&b, "\tupdated_at timestamp NOT NULL")
-
-			if len(table.Indexes) != 0 && len(table.Fields) != 0 {
-				fmt.Fprint(&b, ",")
-			}
+			columns = append(columns, fmt.Sprintf("created_at %s NOT NULL", d.Timestamp()))
+			columns = append(columns, fmt.Sprintf("updated_at %s NOT NULL", d.Timestamp()))
 		}
 
-		if len(table.Indexes) != 0 && len(table.Fields) != 0 {
-			if !table.Timestamped {
-				fmt.Fprint(&b, ",")
-			}
+		if len(table.Fields) != 0 {
+			for _, ind := range table.Indexes {
+				clause, inline := d.Index(table.TableName, ind.IndexName, ind.Field)
+				if !inline {
+					indexes = append(indexes, clause+";")
+					continue
+				}
 
-			if hasFields {
-				fmt.Fprint(&b, "\n")
+				columns = append(columns, clause)
 			}
+		}
 
-			totalIndexes := len(table.Indexes) - 1
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", table.TableName)
 
-			for index, ind := range table.Indexes {
-				fmt.Fprintf(&b, "\t%s", ind.String())
+		for index, column := range columns {
+			fmt.Fprintf(&b, "\t%s", column)
 
-				if index < totalIndexes {
-					fmt.Fprintf(&b, ",\n")
-				}
+			if index < len(columns)-1 {
+				fmt.Fprint(&b, ",")
 			}
+
+			fmt.Fprint(&b, "\n")
 		}
 
-		fmt.Fprint(&b, "\n);\r\n")
-	}
+		fmt.Fprint(&b, ");")
 
-	if len(table.Queries) != 0 {
-		fmt.Fprint(&b, "\r\n")
+		statements = append(statements, b.String())
+		statements = append(statements, indexes...)
+	}
 
-		for _, query := range table.Queries {
-			// Attempt to swap in tablename incase of format string
+	for _, query := range table.Queries {
+		// Attempt to swap in tablename incase of format string
+		if strings.Contains(query, "%s") {
 			query = fmt.Sprintf(query, table.TableName)
+		}
 
-			if strings.HasSuffix(query, ";") {
-				fmt.Fprintf(&b, "%s\n", query)
-				continue
-			}
-
-			fmt.Fprintf(&b, "%s;\n", query)
+		if !strings.HasSuffix(query, ";") {
+			query += ";"
 		}
 
-		fmt.Fprint(&b, "\r\n")
+		statements = append(statements, query)
 	}
 
-	return b.String()
+	return statements
 }
 
 // FieldMigration defines a struct which defines the fields for a tableMigrations.
@@ -96,16 +93,34 @@ type FieldMigration struct {
 	FieldType     string `json:"field_type"`
 	NotNull       bool   `json:"not_null"`
 	PrimaryKey    bool   `json:"primary_key"`
+	Unique        bool   `json:"unique"`
 	AutoIncrement bool   `json:"auto_increment"`
 }
 
-// String returns the index query for the giving field migration.
+// String returns the index query for the giving field migration using the
+// MySQL dialect.
 func (field FieldMigration) String() string {
+	return field.Build(dialects.MySQL{})
+}
+
+// Build returns the column definition for the giving field migration generated
+// for the provided dialect.
+func (field FieldMigration) Build(d dialects.Dialect) string {
 	var b bytes.Buffer
 
+	fieldType := field.FieldType
+	if strings.EqualFold(fieldType, "timestamp") {
+		fieldType = d.Timestamp()
+	}
+
+	var autoIncrement string
+	if field.AutoIncrement {
+		fieldType, autoIncrement = d.AutoIncrement(fieldType)
+	}
+
 	fmt.Fprintf(&b, "%s", field.FieldName)
 	fmt.Fprintf(&b, " ")
-	fmt.Fprintf(&b, "%s", field.FieldType)
+	fmt.Fprintf(&b, "%s", fieldType)
 
 	if field.NotNull {
 		fmt.Fprintf(&b, " ")
@@ -117,9 +132,14 @@ func (field FieldMigration) String() string {
 		fmt.Fprintf(&b, "PRIMARY KEY")
 	}
 
-	if field.AutoIncrement {
+	if field.Unique {
+		fmt.Fprintf(&b, " ")
+		fmt.Fprintf(&b, "UNIQUE")
+	}
+
+	if autoIncrement != "" {
 		fmt.Fprintf(&b, " ")
-		fmt.Fprintf(&b, "AUTO_INCREMENT")
+		fmt.Fprintf(&b, "%s", autoIncrement)
 	}
 
 	return b.String()
@@ -134,5 +154,5 @@ type IndexMigration struct {
 
 // String returns the index query for the giving index migration.
 func (index IndexMigration) String() string {
-	return fmt.Sprintf("INDEX %s (%s)", index.Field, index.IndexName)
+	return fmt.Sprintf("INDEX %s (%s)", index.IndexName, index.Field)
 }
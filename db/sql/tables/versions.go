@@ -0,0 +1,153 @@
+package tables
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/influx6/backoffice/db/sql/dialects"
+	"github.com/jmoiron/sqlx"
+)
+
+// Step defines an interface for a single change to a db schema, which returns the
+// statements to be executed in order to apply the change for the provided dialect.
+// A TableMigration is a Step which creates it's table.
+type Step interface {
+	Statements(dialects.Dialect) []string
+}
+
+// Migration defines a versioned change to a db schema. The Up steps are executed to
+// apply the migration while the Down steps are executed to revert it.
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Up      []Step `json:"-"`
+	Down    []Step `json:"-"`
+}
+
+// UpStatements returns the statements which apply the migration for the provided dialect.
+func (m Migration) UpStatements(d dialects.Dialect) []string {
+	return stepStatements(d, m.Up)
+}
+
+// DownStatements returns the statements which revert the migration for the provided dialect.
+func (m Migration) DownStatements(d dialects.Dialect) []string {
+	return stepStatements(d, m.Down)
+}
+
+// stepStatements returns the statements of all steps in order.
+func stepStatements(d dialects.Dialect, steps []Step) []string {
+	var statements []string
+
+	for _, step := range steps {
+		statements = append(statements, step.Statements(d)...)
+	}
+
+	return statements
+}
+
+// DropTable defines a Step which drops a table.
+type DropTable struct {
+	TableName string `json:"table_name"`
+}
+
+// Statements returns the DROP TABLE statement for the table.
+func (dt DropTable) Statements(d dialects.Dialect) []string {
+	return []string{fmt.Sprintf("DROP TABLE IF EXISTS %s;", dt.TableName)}
+}
+
+// AddColumn defines a Step which adds a new column to an existing table.
+type AddColumn struct {
+	TableName string         `json:"table_name"`
+	Field     FieldMigration `json:"field"`
+}
+
+// Statements returns the ALTER TABLE statement adding the column.
+func (ac AddColumn) Statements(d dialects.Dialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", ac.TableName, ac.Field.Build(d))}
+}
+
+// DropColumn defines a Step which removes a column from an existing table.
+type DropColumn struct {
+	TableName string `json:"table_name"`
+	FieldName string `json:"field_name"`
+}
+
+// Statements returns the ALTER TABLE statement dropping the column.
+func (dc DropColumn) Statements(d dialects.Dialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", dc.TableName, dc.FieldName)}
+}
+
+// RenameColumn defines a Step which renames a column of an existing table.
+type RenameColumn struct {
+	TableName string `json:"table_name"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+// Statements returns the ALTER TABLE statement renaming the column.
+func (rc RenameColumn) Statements(d dialects.Dialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", rc.TableName, rc.From, rc.To)}
+}
+
+// RenameTable defines a Step which renames an existing table.
+type RenameTable struct {
+	TableName string `json:"table_name"`
+	To        string `json:"to"`
+}
+
+// Statements returns the ALTER TABLE statement renaming the table.
+func (rt RenameTable) Statements(d dialects.Dialect) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", rt.TableName, rt.To)}
+}
+
+// AddIndex defines a Step which adds an index to an existing table.
+type AddIndex struct {
+	TableName string         `json:"table_name"`
+	Index     IndexMigration `json:"index"`
+	Unique    bool           `json:"unique"`
+}
+
+// Statements returns the CREATE INDEX statement for the index.
+func (ai AddIndex) Statements(d dialects.Dialect) []string {
+	return []string{d.CreateIndex(ai.TableName, ai.Index.IndexName, ai.Index.Field, ai.Unique) + ";"}
+}
+
+// DropIndex defines a Step which removes an index from an existing table.
+type DropIndex struct {
+	TableName string `json:"table_name"`
+	IndexName string `json:"index_name"`
+}
+
+// Statements returns the DROP INDEX statement for the index.
+func (di DropIndex) Statements(d dialects.Dialect) []string {
+	return []string{d.DropIndex(di.TableName, di.IndexName) + ";"}
+}
+
+// Raw defines a Step of complete sql queries which are executed as is for all dialects.
+type Raw []string
+
+// Statements returns the queries of the step.
+func (r Raw) Statements(d dialects.Dialect) []string {
+	var statements []string
+
+	for _, query := range r {
+		if !strings.HasSuffix(query, ";") {
+			query += ";"
+		}
+
+		statements = append(statements, query)
+	}
+
+	return statements
+}
+
+// Func defines a Step of Go code which is run within the transaction of it's migration,
+// in order with the statements of the other steps, for changes to records which no sql
+// statement can express. It has no statements of it's own.
+type Func func(ctx context.Context, tx *sqlx.Tx, d dialects.Dialect) error
+
+// Statements returns no statements, as the Migrator runs the Func itself.
+func (f Func) Statements(d dialects.Dialect) []string {
+	return nil
+}
@@ -0,0 +1,81 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// bindValue converts the giving value into one which can be safely bound as a
+// query argument by all sql drivers. It supports all go scalar types (including
+// named types based on them), time.Time, pointers to these (where a nil pointer
+// becomes NULL) and any driver.Valuer such as sql.NullString.
+func bindValue(item interface{}) (interface{}, error) {
+	if rv := reflect.ValueOf(item); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+
+	switch rl := item.(type) {
+	case nil:
+		return nil, nil
+	case driver.Valuer:
+		return rl, nil
+	case string, []byte, bool, int64, float64, time.Time:
+		return rl, nil
+	case int:
+		return int64(rl), nil
+	case int8:
+		return int64(rl), nil
+	case int16:
+		return int64(rl), nil
+	case int32:
+		return int64(rl), nil
+	case uint:
+		return bindUint(uint64(rl))
+	case uint8:
+		return int64(rl), nil
+	case uint16:
+		return int64(rl), nil
+	case uint32:
+		return int64(rl), nil
+	case uint64:
+		return bindUint(rl)
+	case float32:
+		return float64(rl), nil
+	}
+
+	rv := reflect.ValueOf(item)
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		return bindValue(rv.Elem().Interface())
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return bindUint(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("Unsupported value type %T", item)
+}
+
+// bindUint returns the giving unsigned value as an int64, which is the integer type
+// accepted by the sql drivers, erroring if it does not fit.
+func bindUint(value uint64) (interface{}, error) {
+	if value > math.MaxInt64 {
+		return nil, fmt.Errorf("Unsigned value %d overflows int64", value)
+	}
+
+	return int64(value), nil
+}
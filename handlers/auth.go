@@ -1,22 +1,22 @@
 package handlers
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/influx6/backoffice/db"
-	"github.com/influx6/backoffice/models/session"
-	"github.com/influx6/backoffice/utils"
+	"github.com/influx6/backoffice/models/user"
 	"github.com/influx6/faux/sink"
 	"github.com/influx6/faux/sink/sinks"
 )
 
 // DefferedBearerAuth returns a new function which can be used to generate a new copy of the
-// BearerAuth.
-func DefferedBearerAuth(log sink.Sink, dbr db.DB) func(db.TableIdentity, db.TableIdentity, db.TableIdentity, time.Duration) BearerAuth {
+// BearerAuth, whoes session tokens are hashed with the secret.
+func DefferedBearerAuth(log sink.Sink, dbr db.DB, secret []byte) func(db.TableIdentity, db.TableIdentity, db.TableIdentity, time.Duration) BearerAuth {
 	return func(ut db.TableIdentity, pt db.TableIdentity, st db.TableIdentity, expiry time.Duration) BearerAuth {
 		users := UsersFactory(log, dbr, ut, pt)
-		sessions := SessionsFactory(log, dbr, expiry, st)
+		sessions := SessionsFactory(log, dbr, secret, expiry, st)
 		return BearerAuth{
 			Users:    users,
 			Sessions: sessions,
@@ -25,10 +25,10 @@ func DefferedBearerAuth(log sink.Sink, dbr db.DB) func(db.TableIdentity, db.Tabl
 }
 
 // BearerAuthFactory returns a function which returns a given can be used to generate a
-// new Users instance to make request with.
-func BearerAuthFactory(log sink.Sink, dbr db.DB, ut db.TableIdentity, pt, st db.TableIdentity, expiry time.Duration) BearerAuth {
+// new Users instance to make request with, whoes session tokens are hashed with the secret.
+func BearerAuthFactory(log sink.Sink, dbr db.DB, secret []byte, ut db.TableIdentity, pt, st db.TableIdentity, expiry time.Duration) BearerAuth {
 	users := UsersFactory(log, dbr, ut, pt)
-	sessions := SessionsFactory(log, dbr, expiry, st)
+	sessions := SessionsFactory(log, dbr, secret, expiry, st)
 	return BearerAuth{
 		Users:    users,
 		Sessions: sessions,
@@ -36,13 +36,17 @@ func BearerAuthFactory(log sink.Sink, dbr db.DB, ut db.TableIdentity, pt, st db.
 }
 
 // BearerAuth defines an handler which provides authorization handling for
-// a request, needing user authentication.
+// a request, needing user authentication. Permissions are granted to the roles of users
+// by the Policy, which defaults to the DefaultPolicy if it holds no roles.
 type BearerAuth struct {
 	Users
 	Sessions Sessions
+	Policy   Policy
 }
 
-// CheckAuthorization handles receiving requests to verify user authorization.
+// CheckAuthorization handles receiving requests to verify user authorization, which
+// must name an unexpired session of an existing user. The user is not retrieved if the
+// Sessions issues stateless tokens.
 /* Service API
 HTTP Method: GET
 Header:
@@ -50,82 +54,150 @@ Header:
 			"Authorization":"Bearer <TOKEN>",
 		}
 
-		WHERE: <TOKEN> = <USERID>:<SESSIONTOKEN>
+		WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>, or a signed JWT with JWTTokens
 */
 func (u BearerAuth) CheckAuthorization(authorization string) error {
+	return u.CheckAuthorizationCtx(context.Background(), authorization)
+}
+
+// CheckAuthorizationCtx is the same as CheckAuthorization but uses the provided context for all db operations.
+func (u BearerAuth) CheckAuthorizationCtx(ctx context.Context, authorization string) error {
 	defer u.Log.Emit(sinks.Info("Authenticate Authorization").WithFields(sink.Fields{
-		"authorization": authorization,
+		"authorization": fingerprint(authorization),
 	}).Trace("Auth.CheckAuthorization").End())
 
-	// Retrieve authorization header.
-	authType, token, err := utils.ParseAuthorization(authorization)
+	// Retrieve the user session named by the authorization.
+	userSession, err := u.Sessions.AuthorizeCtx(ctx, authorization)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
+			"authorization": fingerprint(authorization),
 		}))
 
 		return err
 	}
 
-	if authType != "Bearer" {
-		err := errors.New("Only `Bearer` Authorization supported")
+	// Stateless tokens are verified without a db operation, hence the tokens of a removed
+	// user remain valid until they expire, unless their sessions are revoked.
+	if u.Sessions.Stateless() {
+		return nil
+	}
+
+	// Ensure user does exists, where it's profile is not needed.
+	users := u.Users
+	users.Profiles = nil
+
+	if _, err := users.GetCtx(ctx, userSession.UserID); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
+			"session_id": userSession.PublicID,
 		}))
 
-		return err
+		return credentialsErr(err)
 	}
 
-	// Retrieve Authorization UserID and Token.
-	sessionUserID, sessionToken, err := session.ParseToken(token)
-	if err != nil {
-		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
-		}))
+	return nil
+}
+
+// CheckPermission verifies the authorization as CheckAuthorization does, where the user of
+// the session must also be granted the permission by the Policy. Owner is the public id
+// of the user owning the records acted on, which grants permissions of self scope if it
+// is the authorized user. If the token of the session carries scopes, the permission must
+// also be granted by them. It returns an error matching ErrForbidden if the permission is
+// not granted.
+/* Service API
+HTTP Method: GET
+Header:
+		{
+			"Authorization":"Bearer <TOKEN>",
+		}
+
+		WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>, or a signed JWT with JWTTokens
+*/
+func (u BearerAuth) CheckPermission(authorization string, permission string, owner string) error {
+	return u.CheckPermissionCtx(context.Background(), authorization, permission, owner)
+}
+
+// CheckPermissionCtx is the same as CheckPermission but uses the provided context for all db operations.
+func (u BearerAuth) CheckPermissionCtx(ctx context.Context, authorization string, permission string, owner string) error {
+	defer u.Log.Emit(sinks.Info("Check Authorization Permission").WithFields(sink.Fields{
+		"authorization": fingerprint(authorization),
+		"permission":    permission,
+		"owner":         owner,
+	}).Trace("Auth.CheckPermission").End())
 
+	principal, err := u.PrincipalCtx(ctx, authorization)
+	if err != nil {
 		return err
 	}
 
-	// Ensure user does exists.
-	if _, err := u.Users.Get(sessionUserID); err != nil {
+	if !principal.Allows(permission, owner) {
+		err := fmt.Errorf("User is not granted %q: %w", permission, ErrForbidden)
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
+			"user_id": principal.User.PublicID,
+			"owner":   owner,
 		}))
 
 		return err
 	}
 
-	// Retrieve user session record.
-	userSession, err := u.Sessions.Get(sessionUserID)
+	return nil
+}
+
+// Principal returns the Principal of the authorization, which must name an unexpired
+// session of an existing user. The user is not retrieved if the Sessions issues stateless
+// tokens, where the User of the Principal holds only the public id and roles carried by
+// the token.
+func (u BearerAuth) Principal(authorization string) (*Principal, error) {
+	return u.PrincipalCtx(context.Background(), authorization)
+}
+
+// PrincipalCtx is the same as Principal but uses the provided context for all db operations.
+func (u BearerAuth) PrincipalCtx(ctx context.Context, authorization string) (*Principal, error) {
+	defer u.Log.Emit(sinks.Info("Get Authorization Principal").WithFields(sink.Fields{
+		"authorization": fingerprint(authorization),
+	}).Trace("Auth.Principal").End())
+
+	userSession, err := u.Sessions.AuthorizeCtx(ctx, authorization)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
+			"authorization": fingerprint(authorization),
 		}))
 
-		return err
+		return nil, err
 	}
 
-	// if session token does not match UserSession, probably faked request or messed up old session.
-	if !userSession.ValidateToken(sessionToken) {
-		err := errors.New("Invalid user session's token")
+	if u.Sessions.Stateless() {
+		return &Principal{
+			User:    &user.User{PublicID: userSession.UserID, Roles: userSession.Roles},
+			Session: userSession,
+			Policy:  u.policy(),
+		}, nil
+	}
+
+	// The user's profile is not needed.
+	users := u.Users
+	users.Profiles = nil
 
+	nu, err := users.GetCtx(ctx, userSession.UserID)
+	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
+			"session_id": userSession.PublicID,
 		}))
 
-		return err
+		return nil, credentialsErr(err)
 	}
 
-	// If session has expired, then we fail the request.
-	if userSession.Expired() {
-		err := errors.New("User session has expired")
-
-		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
-		}))
+	return &Principal{
+		User:    nu,
+		Session: userSession,
+		Policy:  u.policy(),
+	}, nil
+}
 
-		return err
+// policy returns the Policy of the BearerAuth, or the DefaultPolicy if it holds no roles.
+func (u BearerAuth) policy() Policy {
+	if u.Policy.Roles == nil {
+		return DefaultPolicy
 	}
 
-	return nil
+	return u.Policy
 }
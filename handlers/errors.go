@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/models/user"
+)
+
+// contains the errors returned by the handlers in addition to db.ErrNotFound and
+// db.ErrConflict, which callers can check for with errors.Is.
+var (
+	// ErrInvalidCredentials is returned when a user fails to authenticate, either through
+	// an invalid email and password or an invalid Authorization header.
+	ErrInvalidCredentials = errors.New("Invalid credentials")
+
+	// ErrForbidden is returned when an authenticated user is not granted the permission
+	// needed for a request.
+	ErrForbidden = errors.New("Forbidden")
+
+	// ErrUnverified is returned when a user who has not verified it's email is refused,
+	// and matches ErrForbidden.
+	ErrUnverified = fmt.Errorf("Email is not verified: %w", ErrForbidden)
+
+	// ErrNoSecret is returned when handlers without a Secret are asked to issue or
+	// verify tokens, whoes signatures and hashes would otherwise be keyed by nothing.
+	ErrNoSecret = errors.New("No secret to sign and hash tokens with")
+
+	// ErrValidation is matched by all ValidationErrors.
+	ErrValidation = errors.New("Validation failed")
+)
+
+// ValidationError defines an error returned when the data provided to a handler is
+// invalid, containing a message for each invalid field.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+// Invalid returns a ValidationError for the giving field and message.
+func Invalid(field string, message string) ValidationError {
+	return ValidationError{Fields: map[string]string{field: message}}
+}
+
+// Error implements the error interface.
+func (v ValidationError) Error() string {
+	var fields []string
+
+	for field, message := range v.Fields {
+		fields = append(fields, fmt.Sprintf("%s %s", field, message))
+	}
+
+	sort.Strings(fields)
+
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(fields, ", "))
+}
+
+// Is reports if the target is ErrValidation, allowing errors.Is to match all
+// ValidationErrors.
+func (v ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// FieldErrors returns the message of each invalid field.
+func (v ValidationError) FieldErrors() map[string]string {
+	return v.Fields
+}
+
+// credentialsErr wraps the giving error with ErrInvalidCredentials if it is the result
+// of a missing record, leaving all other failures as is.
+func credentialsErr(err error) error {
+	if errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("%s: %w", err, ErrInvalidCredentials)
+	}
+
+	return err
+}
+
+// passwordErr returns a ValidationError of the password field listing the violations of
+// a user.PasswordError, leaving all other failures as is.
+func passwordErr(err error) error {
+	var weak user.PasswordError
+	if errors.As(err, &weak) {
+		return Invalid("password", strings.Join(weak.Violations, ", "))
+	}
+
+	return err
+}
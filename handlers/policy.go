@@ -0,0 +1,115 @@
+package handlers
+
+import "strings"
+
+// contains the roles of the DefaultPolicy.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// contains the scopes of permissions.
+const (
+	ScopeAny  = "any"
+	ScopeSelf = "self"
+)
+
+// DefaultPolicy grants admins all permissions, and users the permissions over their own
+// user, profile and sessions. Users holding no roles are treated as users.
+var DefaultPolicy = Policy{
+	Roles: map[string][]string{
+		RoleAdmin: {"*"},
+		RoleUser: {
+			"users:update:self",
+			"users:delete:self",
+			"profiles:create:self",
+			"profiles:read:self",
+			"profiles:update:self",
+			"profiles:delete:self",
+			"sessions:read:self",
+			"sessions:delete:self",
+		},
+	},
+	DefaultRoles: []string{RoleUser},
+}
+
+// Policy defines the permissions granted to each role. Permissions are in the form
+// <resource>:<action>:<scope>, where the scope is either any, granting the action over
+// all records, or self, granting it only over the records owned by the user. A "*"
+// grants all permissions, or all resources or actions in place of either, where a granted
+// permission without a scope grants any.
+type Policy struct {
+	Roles map[string][]string
+
+	// DefaultRoles are the roles of users holding none.
+	DefaultRoles []string
+}
+
+// Permissions returns the permissions granted to the roles.
+func (p Policy) Permissions(roles []string) []string {
+	if len(roles) == 0 {
+		roles = p.DefaultRoles
+	}
+
+	var permissions []string
+
+	for _, role := range roles {
+		permissions = append(permissions, p.Roles[role]...)
+	}
+
+	return permissions
+}
+
+// Allows returns true/false if the roles are granted the permission, where self reports
+// if the records acted on are owned by the user holding the roles. A permission without
+// a scope is the same as one of self scope, which is granted by either scope.
+func (p Policy) Allows(roles []string, permission string, self bool) bool {
+	return grants(p.Permissions(roles), permission, self)
+}
+
+// grants returns true/false if any of the granted permissions grants the permission.
+func grants(granted []string, permission string, self bool) bool {
+	resource, action, scope := splitPermission(permission)
+
+	for _, grant := range granted {
+		if grant == "*" {
+			return true
+		}
+
+		grantResource, grantAction, grantScope := splitPermission(grant)
+
+		if grantResource != "*" && grantResource != resource {
+			continue
+		}
+
+		if grantAction != "*" && grantAction != action {
+			continue
+		}
+
+		switch grantScope {
+		case ScopeAny, "*", "":
+			return true
+		case ScopeSelf:
+			if self && scope != ScopeAny {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// splitPermission returns the resource, action and scope of the permission, leaving any
+// missing part empty.
+func splitPermission(permission string) (resource string, action string, scope string) {
+	parts := strings.SplitN(permission, ":", 3)
+
+	switch len(parts) {
+	case 3:
+		return parts[0], parts[1], parts[2]
+	case 2:
+		return parts[0], parts[1], ""
+	}
+
+	return parts[0], "", ""
+}
@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influx6/backoffice/db/memory"
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/models/session"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/tests"
+)
+
+// TestPolicy validates the permissions granted to roles by a Policy.
+func TestPolicy(t *testing.T) {
+	policy := handlers.Policy{
+		Roles: map[string][]string{
+			"admin":   {"*"},
+			"auditor": {"users:read:any", "sessions:*:any"},
+			"user":    {"users:read:self", "users:update:self", "profiles:update"},
+		},
+		DefaultRoles: []string{"user"},
+	}
+
+	for _, check := range []struct {
+		roles      []string
+		permission string
+		self       bool
+		allowed    bool
+	}{
+		{roles: []string{"admin"}, permission: "users:delete:any", allowed: true},
+		{roles: []string{"auditor"}, permission: "users:read:any", allowed: true},
+		{roles: []string{"auditor"}, permission: "users:read:self", self: true, allowed: true},
+		{roles: []string{"auditor"}, permission: "users:update:self", self: true, allowed: false},
+		{roles: []string{"auditor"}, permission: "sessions:delete:any", allowed: true},
+		{roles: []string{"user"}, permission: "users:read:self", self: true, allowed: true},
+		{roles: []string{"user"}, permission: "users:read:self", self: false, allowed: false},
+		{roles: []string{"user"}, permission: "users:read:any", self: true, allowed: false},
+		{roles: []string{"user"}, permission: "users:read", self: true, allowed: true},
+		{roles: []string{"user"}, permission: "profiles:update:any", allowed: true},
+		{roles: nil, permission: "users:update:self", self: true, allowed: true},
+		{roles: []string{"unknown"}, permission: "users:update:self", self: true, allowed: false},
+	} {
+		if policy.Allows(check.roles, check.permission, check.self) != check.allowed {
+			tests.Failed("Should have allowed %q to %v for %+q with self %t.", check.permission, check.allowed, check.roles, check.self)
+		}
+		tests.Passed("Should have allowed %q to %v for %+q with self %t.", check.permission, check.allowed, check.roles, check.self)
+	}
+}
+
+// TestCheckPermission validates the permissions of authorized users against a memory store.
+func TestCheckPermission(t *testing.T) {
+	store := memory.New()
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	auth := handlers.BearerAuthFactory(log, store, sessionSecret, usersTable, profilesTable, sessionsTable, time.Hour)
+
+	bob, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	current, err := auth.Sessions.Create(bob, session.Device{})
+	if err != nil {
+		tests.Failed("Should have successfully created session: %+q.", err)
+	}
+	tests.Passed("Should have successfully created session.")
+
+	authorization := "Bearer " + current.SessionToken()
+
+	t.Logf("Given the need to check the permissions of an authorized user")
+	{
+		t.Log("\tWhen the user holds no roles")
+		{
+			if err := auth.CheckPermission(authorization, "users:update:self", bob.PublicID); err != nil {
+				tests.Failed("Should have permitted user to update itself: %+q.", err)
+			}
+			tests.Passed("Should have permitted user to update itself.")
+
+			if err := auth.CheckPermission(authorization, "users:update:self", "alice"); !errors.Is(err, handlers.ErrForbidden) {
+				tests.Failed("Should have forbidden user to update another user: %+q.", err)
+			}
+			tests.Passed("Should have forbidden user to update another user.")
+
+			if err := auth.CheckPermission(authorization, "users:read:any", ""); !errors.Is(err, handlers.ErrForbidden) {
+				tests.Failed("Should have forbidden user to read all users: %+q.", err)
+			}
+			tests.Passed("Should have forbidden user to read all users.")
+		}
+
+		t.Log("\tWhen the user holds the admin role")
+		{
+			if err := users.SetRoles(user.UpdateUserRoles{PublicID: bob.PublicID, Roles: []string{handlers.RoleAdmin}}); err != nil {
+				tests.Failed("Should have successfully set roles of user: %+q.", err)
+			}
+			tests.Passed("Should have successfully set roles of user.")
+
+			if err := auth.CheckPermission(authorization, "users:read:any", ""); err != nil {
+				tests.Failed("Should have permitted admin to read all users: %+q.", err)
+			}
+			tests.Passed("Should have permitted admin to read all users.")
+
+			if err := auth.CheckPermission("Bearer invalid", "users:read:any", ""); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have rejected invalid authorization: %+q.", err)
+			}
+			tests.Passed("Should have rejected invalid authorization.")
+		}
+	}
+}
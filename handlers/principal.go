@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/influx6/backoffice/models/session"
+	"github.com/influx6/backoffice/models/user"
+)
+
+// Principal defines the user authorized by a BearerAuth along with it's session, which is
+// attached to the context of authorized requests so handlers only act on the records the
+// user is permitted to.
+type Principal struct {
+	User    *user.User
+	Session *session.Session
+	Policy  Policy
+}
+
+// Allows returns true/false if the user is granted the permission by the Policy over the
+// records owned by the user with the owner public id, where the permission must also be
+// granted by the scopes of the session's token if it carries any.
+func (p Principal) Allows(permission string, owner string) bool {
+	self := owner != "" && owner == p.User.PublicID
+
+	if !p.Policy.Allows(p.User.Roles, permission, self) {
+		return false
+	}
+
+	if p.Session != nil && p.Session.Scopes != nil {
+		return grants(p.Session.Scopes, permission, self)
+	}
+
+	return true
+}
+
+// principalKey is the context key of the Principal attached by WithPrincipal.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of the context carrying the principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the Principal attached to the context by WithPrincipal.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// authorize returns an error matching ErrForbidden if the context carries a Principal
+// not granted the permission over the records owned by the user with the owner public id.
+// Contexts without a Principal are of trusted callers, such as the backoffice command,
+// which may act on all records.
+func authorize(ctx context.Context, permission string, owner string) error {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok || principal.Allows(permission, owner) {
+		return nil
+	}
+
+	return fmt.Errorf("User is not granted %q: %w", permission, ErrForbidden)
+}
@@ -0,0 +1,130 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influx6/backoffice/db/memory"
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/models/profile"
+	"github.com/influx6/backoffice/models/session"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/tests"
+)
+
+// TestPrincipal validates the ownership of records enforced by the handlers on the
+// Principal of a context.
+func TestPrincipal(t *testing.T) {
+	store := memory.New()
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	auth := handlers.BearerAuthFactory(log, store, sessionSecret, usersTable, profilesTable, sessionsTable, time.Hour)
+
+	bob, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	alice, err := users.Create(user.NewUser{Email: "alice@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created another user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created another user.")
+
+	current, err := auth.Sessions.Create(bob, session.Device{})
+	if err != nil {
+		tests.Failed("Should have successfully created session: %+q.", err)
+	}
+	tests.Passed("Should have successfully created session.")
+
+	principal, err := auth.Principal("Bearer " + current.SessionToken())
+	if err != nil {
+		tests.Failed("Should have successfully retrieved principal: %+q.", err)
+	}
+	tests.Passed("Should have successfully retrieved principal.")
+
+	if principal.User.PublicID != bob.PublicID || principal.Session.PublicID != current.PublicID {
+		tests.Failed("Should have retrieved principal of authorized session.")
+	}
+	tests.Passed("Should have retrieved principal of authorized session.")
+
+	ctx := handlers.WithPrincipal(context.Background(), principal)
+
+	t.Logf("Given the need to act on records with the principal of a user")
+	{
+		t.Log("\tWhen acting on the records of another user")
+		{
+			if err := users.UpdateCtx(ctx, user.UpdateUser{PublicID: alice.PublicID, Email: "mallory@guma.com"}); !errors.Is(err, handlers.ErrForbidden) {
+				tests.Failed("Should have forbidden update of another user: %+q.", err)
+			}
+			tests.Passed("Should have forbidden update of another user.")
+
+			if err := users.UpdatePasswordCtx(ctx, user.UpdateUserPassword{PublicID: alice.PublicID, Password: "Grow-Fern-Garden-58"}); !errors.Is(err, handlers.ErrForbidden) {
+				tests.Failed("Should have forbidden password change of another user: %+q.", err)
+			}
+			tests.Passed("Should have forbidden password change of another user.")
+
+			if err := users.DeleteCtx(ctx, alice.PublicID); !errors.Is(err, handlers.ErrForbidden) {
+				tests.Failed("Should have forbidden delete of another user: %+q.", err)
+			}
+			tests.Passed("Should have forbidden delete of another user.")
+
+			if err := users.Profiles.UpdateCtx(ctx, profile.UpdateProfile{PublicID: alice.Profile.PublicID}); !errors.Is(err, handlers.ErrForbidden) {
+				tests.Failed("Should have forbidden update of another user's profile: %+q.", err)
+			}
+			tests.Passed("Should have forbidden update of another user's profile.")
+
+			if err := users.SetRolesCtx(ctx, user.UpdateUserRoles{PublicID: bob.PublicID, Roles: []string{handlers.RoleAdmin}}); !errors.Is(err, handlers.ErrForbidden) {
+				tests.Failed("Should have forbidden user granting itself roles: %+q.", err)
+			}
+			tests.Passed("Should have forbidden user granting itself roles.")
+		}
+
+		t.Log("\tWhen changing the user's own password")
+		{
+			if err := users.UpdatePasswordCtx(ctx, user.UpdateUserPassword{PublicID: bob.PublicID, Password: "Grow-Fern-Garden-58"}); !errors.Is(err, handlers.ErrValidation) {
+				tests.Failed("Should have required current password: %+q.", err)
+			}
+			tests.Passed("Should have required current password.")
+
+			if err := users.UpdatePasswordCtx(ctx, user.UpdateUserPassword{PublicID: bob.PublicID, CurrentPassword: "Slow-Snail-Trail-24", Password: "Grow-Fern-Garden-58"}); !errors.Is(err, handlers.ErrValidation) {
+				tests.Failed("Should have rejected wrong current password: %+q.", err)
+			}
+			tests.Passed("Should have rejected wrong current password.")
+
+			if err := users.UpdatePasswordCtx(ctx, user.UpdateUserPassword{PublicID: bob.PublicID, CurrentPassword: "Glow-Worm-Lantern-42", Password: "Grow-Fern-Garden-58"}); err != nil {
+				tests.Failed("Should have successfully changed password: %+q.", err)
+			}
+			tests.Passed("Should have successfully changed password.")
+		}
+
+		t.Log("\tWhen acting on the records of another user as an admin")
+		{
+			if err := users.SetRoles(user.UpdateUserRoles{PublicID: bob.PublicID, Roles: []string{handlers.RoleAdmin}}); err != nil {
+				tests.Failed("Should have successfully set admin role: %+q.", err)
+			}
+			tests.Passed("Should have successfully set admin role.")
+
+			admin, err := auth.Principal("Bearer " + current.SessionToken())
+			if err != nil {
+				tests.Failed("Should have successfully retrieved principal: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved principal.")
+
+			ctx := handlers.WithPrincipal(context.Background(), admin)
+
+			if err := users.UpdatePasswordCtx(ctx, user.UpdateUserPassword{PublicID: alice.PublicID, Password: "Grow-Fern-Garden-58"}); err != nil {
+				tests.Failed("Should have successfully changed password of another user: %+q.", err)
+			}
+			tests.Passed("Should have successfully changed password of another user.")
+
+			if err := users.DeleteCtx(ctx, alice.PublicID); err != nil {
+				tests.Failed("Should have successfully deleted another user: %+q.", err)
+			}
+			tests.Passed("Should have successfully deleted another user.")
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 
 	"github.com/influx6/backoffice/db"
@@ -39,15 +40,26 @@ type Profiles struct {
 	TableIdentity db.TableIdentity
 }
 
-// Create adds a new profile for the specified profile.
+// Create adds a new profile for the specified profile. If the context carries a Principal,
+// it must be granted profiles:create over the user.
 func (p Profiles) Create(nu *user.User, np *profile.NewProfile) (*profile.Profile, error) {
+	return p.CreateCtx(context.Background(), nu, np)
+}
+
+// CreateCtx is the same as Create but uses the provided context for all db operations.
+func (p Profiles) CreateCtx(ctx context.Context, nu *user.User, np *profile.NewProfile) (*profile.Profile, error) {
 	defer p.Log.Emit(sinks.Info("Create New Profile").WithFields(sink.Fields{
 		"user_email": nu.Email,
 		"user_id":    nu.PublicID,
 	}).Trace("Profiles.Create").End())
 
 	if np != nil && np.UserID != nu.PublicID {
-		err := errors.New("Invalid NewProfile.UserID: Does not match given user")
+		err := Invalid("user_id", "does not match given user")
+		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
+		return nil, err
+	}
+
+	if err := authorize(ctx, "profiles:create:self", nu.PublicID); err != nil {
 		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
 		return nil, err
 	}
@@ -55,9 +67,13 @@ func (p Profiles) Create(nu *user.User, np *profile.NewProfile) (*profile.Profil
 	var newProfile profile.Profile
 	profileSeen := true
 
-	// Attempt to retrieve profile from db if we still have an outstanding non-expired profile.
-	if err := p.DB.Get(p.TableIdentity, &newProfile, profile.UniqueIndex, nu.PublicID); err != nil {
-		p.Log.Emit(sinks.Error("Failed to retrieve profile: %+q", err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
+	// Attempt to retrieve the existing profile of the user, creating one only if none exists.
+	if err := db.WithContext(p.DB).GetCtx(ctx, p.TableIdentity, &newProfile, profile.UniqueIndex, nu.PublicID); err != nil {
+		if !errors.Is(err, db.ErrNotFound) {
+			p.Log.Emit(sinks.Error("Failed to retrieve profile: %+q", err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
+			return nil, err
+		}
+
 		profileSeen = false
 	}
 
@@ -80,7 +96,7 @@ func (p Profiles) Create(nu *user.User, np *profile.NewProfile) (*profile.Profil
 		newProfile.LastName = np.LastName
 	}
 
-	if err := p.DB.Save(p.TableIdentity, &newProfile); err != nil {
+	if err := db.WithContext(p.DB).SaveCtx(ctx, p.TableIdentity, &newProfile); err != nil {
 		p.Log.Emit(sinks.Error("Failed to save profile: %+q", err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
 		return nil, err
 	}
@@ -96,22 +112,30 @@ func (p Profiles) Create(nu *user.User, np *profile.NewProfile) (*profile.Profil
 }
 
 // ProfileRecords defines a struct which returns the total fields and page details
-// used in retrieving the records.
+// used in retrieving the records. Next and Prev are the cursors of the following and
+// preceding pages of records retrieved with GetPage.
 type ProfileRecords struct {
 	Total           int               `json:"total"`
 	Page            int               `json:"page"`
 	ResponsePerPage int               `json:"responsePerPage"`
 	Records         []profile.Profile `json:"records"`
+	Next            string            `json:"next,omitempty"`
+	Prev            string            `json:"prev,omitempty"`
 }
 
 // GetAll handles receiving requests to retrieve all profile from the database.
 func (p Profiles) GetAll(page, responsePerPage int) (ProfileRecords, error) {
+	return p.GetAllCtx(context.Background(), page, responsePerPage)
+}
+
+// GetAllCtx is the same as GetAll but uses the provided context for all db operations.
+func (p Profiles) GetAllCtx(ctx context.Context, page, responsePerPage int) (ProfileRecords, error) {
 	defer p.Log.Emit(sinks.Info("Get Existing User").WithFields(sink.Fields{
 		"page":            page,
 		"responsePerPage": responsePerPage,
 	}).Trace("handlers.Users.Create").End())
 
-	records, realTotalRecords, err := p.DB.GetAllPerPage(p.TableIdentity, "asc", "public_id", page, responsePerPage)
+	records, realTotalRecords, err := db.WithContext(p.DB).GetAllPerPageCtx(ctx, p.TableIdentity, "asc", "public_id", page, responsePerPage)
 	if err != nil {
 		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"page":            page,
@@ -144,8 +168,62 @@ func (p Profiles) GetAll(page, responsePerPage int) (ProfileRecords, error) {
 	}, nil
 }
 
+// GetPage handles receiving requests to retrieve a page of at most limit profiles, which are
+// ordered by their creation. The page starts after, or before, the position of the
+// cursor, which is one of the Next and Prev cursors of a previous page, or from the first
+// profile if empty.
+func (p Profiles) GetPage(cursor string, limit int) (ProfileRecords, error) {
+	return p.GetPageCtx(context.Background(), cursor, limit)
+}
+
+// GetPageCtx is the same as GetPage but uses the provided context for all db operations.
+func (p Profiles) GetPageCtx(ctx context.Context, cursor string, limit int) (ProfileRecords, error) {
+	defer p.Log.Emit(sinks.Info("Get Page of Profiles").WithFields(sink.Fields{
+		"cursor": cursor,
+		"limit":  limit,
+	}).Trace("handlers.Profiles.GetPage").End())
+
+	page, err := db.FindPage(ctx, p.DB, p.TableIdentity, nil, cursor, limit)
+	if err != nil {
+		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+		return ProfileRecords{}, err
+	}
+
+	total, err := db.WithContext(p.DB).CountCtx(ctx, p.TableIdentity)
+	if err != nil {
+		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+		return ProfileRecords{}, err
+	}
+
+	records := make([]profile.Profile, 0, len(page.Records))
+
+	for _, record := range page.Records {
+		var nw profile.Profile
+
+		if err := nw.WithFields(record); err != nil {
+			p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+			return ProfileRecords{}, err
+		}
+
+		records = append(records, nw)
+	}
+
+	return ProfileRecords{
+		Total:           total,
+		ResponsePerPage: limit,
+		Records:         records,
+		Next:            page.Next,
+		Prev:            page.Prev,
+	}, nil
+}
+
 // Get retrieves the profile associated with the giving profile_id.
 func (p Profiles) Get(profileID string) (*profile.Profile, error) {
+	return p.GetCtx(context.Background(), profileID)
+}
+
+// GetCtx is the same as Get but uses the provided context for all db operations.
+func (p Profiles) GetCtx(ctx context.Context, profileID string) (*profile.Profile, error) {
 	defer p.Log.Emit(sinks.Info("Get Existing Profile").WithFields(sink.Fields{
 		"profile_id": profileID,
 	}).Trace("Profiles.Get").End())
@@ -153,7 +231,7 @@ func (p Profiles) Get(profileID string) (*profile.Profile, error) {
 	var existingProfile profile.Profile
 
 	// Attempt to retrieve profile from db if we still have an outstanding non-expired profile.
-	if err := p.DB.Get(p.TableIdentity, &existingProfile, "public_id", profileID); err != nil {
+	if err := db.WithContext(p.DB).GetCtx(ctx, p.TableIdentity, &existingProfile, "public_id", profileID); err != nil {
 		p.Log.Emit(sinks.Error("Failed to retrieve profile from db: %+q", err).WithFields(sink.Fields{"profile_id": profileID}))
 		return nil, err
 	}
@@ -163,6 +241,11 @@ func (p Profiles) Get(profileID string) (*profile.Profile, error) {
 
 // GetByUser retrieves the profile associated with the giving UserID.
 func (p Profiles) GetByUser(userID string) (*profile.Profile, error) {
+	return p.GetByUserCtx(context.Background(), userID)
+}
+
+// GetByUserCtx is the same as GetByUser but uses the provided context for all db operations.
+func (p Profiles) GetByUserCtx(ctx context.Context, userID string) (*profile.Profile, error) {
 	defer p.Log.Emit(sinks.Info("Get Existing Profile").WithFields(sink.Fields{
 		"user_id": userID,
 	}).Trace("Profiles.GetByUser").End())
@@ -170,7 +253,7 @@ func (p Profiles) GetByUser(userID string) (*profile.Profile, error) {
 	var existingProfile profile.Profile
 
 	// Attempt to retrieve profile from db if we still have an outstanding non-expired profile.
-	if err := p.DB.Get(p.TableIdentity, &existingProfile, profile.UniqueIndex, userID); err != nil {
+	if err := db.WithContext(p.DB).GetCtx(ctx, p.TableIdentity, &existingProfile, profile.UniqueIndex, userID); err != nil {
 		p.Log.Emit(sinks.Error("Failed to retrieve profile from db: %+q", err).WithFields(sink.Fields{"user_id": userID}))
 		return nil, err
 	}
@@ -180,12 +263,17 @@ func (p Profiles) GetByUser(userID string) (*profile.Profile, error) {
 
 // DeleteByUser removes an existing profile from the db for a specified profile.
 func (p Profiles) DeleteByUser(userID string) error {
+	return p.DeleteByUserCtx(context.Background(), userID)
+}
+
+// DeleteByUserCtx is the same as DeleteByUser but uses the provided context for all db operations.
+func (p Profiles) DeleteByUserCtx(ctx context.Context, userID string) error {
 	defer p.Log.Emit(sinks.Info("Delete Existing Profile").WithFields(sink.Fields{
 		"user_id": userID,
 	}).Trace("Profiles.DeleteByUser").End())
 
 	// Delete this profile
-	if err := p.DB.Delete(p.TableIdentity, profile.UniqueIndex, userID); err != nil {
+	if err := db.WithContext(p.DB).DeleteCtx(ctx, p.TableIdentity, profile.UniqueIndex, userID); err != nil {
 		p.Log.Emit(sinks.Error("Failed to delete profile profile from db: %+q", err).WithFields(sink.Fields{"user_id": userID}))
 		return err
 	}
@@ -193,14 +281,25 @@ func (p Profiles) DeleteByUser(userID string) error {
 	return nil
 }
 
-// Delete removes an existing profile from the db for a specified profile by its id.
+// Delete removes an existing profile from the db for a specified profile by its id. If the
+// context carries a Principal, it must be granted profiles:delete over the profile's user.
 func (p Profiles) Delete(profileID string) error {
+	return p.DeleteCtx(context.Background(), profileID)
+}
+
+// DeleteCtx is the same as Delete but uses the provided context for all db operations.
+func (p Profiles) DeleteCtx(ctx context.Context, profileID string) error {
 	defer p.Log.Emit(sinks.Info("Delete Existing Profile").WithFields(sink.Fields{
 		"profile_id": profileID,
 	}).Trace("Profiles.Delete").End())
 
+	if err := p.authorize(ctx, "profiles:delete:self", profileID); err != nil {
+		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"profile_id": profileID}))
+		return err
+	}
+
 	// Delete this profile
-	if err := p.DB.Delete(p.TableIdentity, "public_id", profileID); err != nil {
+	if err := db.WithContext(p.DB).DeleteCtx(ctx, p.TableIdentity, "public_id", profileID); err != nil {
 		p.Log.Emit(sinks.Error("Failed to delete profile from db: %+q", err).WithFields(sink.Fields{"profile_id": profileID}))
 		return err
 	}
@@ -208,12 +307,19 @@ func (p Profiles) Delete(profileID string) error {
 	return nil
 }
 
-// Update handles receiving requests to update a profile identified by it's public_id.
+// Update handles receiving requests to update a profile identified by it's public_id. If
+// the context carries a Principal, it must be granted profiles:update over the profile's
+// user.
 func (p Profiles) Update(nw profile.UpdateProfile) error {
+	return p.UpdateCtx(context.Background(), nw)
+}
+
+// UpdateCtx is the same as Update but uses the provided context for all db operations.
+func (p Profiles) UpdateCtx(ctx context.Context, nw profile.UpdateProfile) error {
 	defer p.Log.Emit(sinks.Info("Update User").With("profile_id", nw.PublicID).Trace("handlers.Users.Update").End())
 
 	if nw.PublicID == "" {
-		err := errors.New("JSON User.PublicID is empty")
+		err := Invalid("public_id", "is required")
 		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"profile_id": nw.PublicID,
 		}))
@@ -221,7 +327,15 @@ func (p Profiles) Update(nw profile.UpdateProfile) error {
 		return err
 	}
 
-	if err := p.DB.Update(p.TableIdentity, nw, "public_id"); err != nil {
+	if err := p.authorize(ctx, "profiles:update:self", nw.PublicID); err != nil {
+		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"profile_id": nw.PublicID,
+		}))
+
+		return err
+	}
+
+	if err := db.WithContext(p.DB).UpdateCtx(ctx, p.TableIdentity, nw, "public_id"); err != nil {
 		p.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"profile_id": nw.PublicID,
 		}))
@@ -231,3 +345,18 @@ func (p Profiles) Update(nw profile.UpdateProfile) error {
 
 	return nil
 }
+
+// authorize returns an error matching ErrForbidden if the context carries a Principal not
+// granted the permission over the user of the profile with the giving public id.
+func (p Profiles) authorize(ctx context.Context, permission string, profileID string) error {
+	if _, ok := PrincipalFrom(ctx); !ok {
+		return nil
+	}
+
+	existingProfile, err := p.GetCtx(ctx, profileID)
+	if err != nil {
+		return err
+	}
+
+	return authorize(ctx, permission, existingProfile.UserID)
+}
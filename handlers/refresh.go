@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/models/session"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+)
+
+// errRefreshReused is returned within the transaction of a refresh if the refresh token
+// was already exchanged, where it's session is revoked once the transaction is done.
+var errRefreshReused = errors.New("Refresh token was already used")
+
+// unreplaced matches the records of refresh tokens which were not yet exchanged.
+var unreplaced = db.Or(db.Where("replaced_by", db.IsNull, true), db.Where("replaced_by", db.Eq, ""))
+
+// Refreshable returns true/false if the Sessions issues refresh tokens.
+func (s Sessions) Refreshable() bool {
+	return s.Refreshes != nil
+}
+
+// IssueRefresh adds a new refresh token for the giving session, which is exchanged for
+// a new token of the session with Refresh.
+func (s Sessions) IssueRefresh(current *session.Session) (*session.RefreshToken, error) {
+	return s.IssueRefreshCtx(context.Background(), current)
+}
+
+// IssueRefreshCtx is the same as IssueRefresh but uses the provided context for all db operations.
+func (s Sessions) IssueRefreshCtx(ctx context.Context, current *session.Session) (*session.RefreshToken, error) {
+	defer s.Log.Emit(sinks.Info("Issue Refresh Token").WithFields(sink.Fields{
+		"user_id":    current.UserID,
+		"session_id": current.PublicID,
+	}).Trace("Sessions.IssueRefresh").End())
+
+	if !s.Refreshable() {
+		return nil, errors.New("Sessions issue no refresh tokens: no refresh table set")
+	}
+
+	if err := s.keyed(); err != nil {
+		return nil, err
+	}
+
+	refresh := session.NewRefreshToken(*current, time.Now().Add(s.RefreshExpiration), s.Secret)
+
+	if err := db.WithContext(s.DB).SaveCtx(ctx, s.Refreshes, refresh); err != nil {
+		s.Log.Emit(sinks.Error("Failed to save refresh token: %+q", err).WithFields(sink.Fields{"user_id": current.UserID, "session_id": current.PublicID}))
+		return nil, err
+	}
+
+	return refresh, nil
+}
+
+// Refresh exchanges the giving encoded refresh token for a new token of it's session,
+// which expires after Expiration, along with a new refresh token replacing the exchanged
+// one. If an already exchanged refresh token is used again, then it was probably stolen,
+// hence the session is revoked along with all it's refresh tokens.
+func (s Sessions) Refresh(refreshToken string) (*session.Session, *session.RefreshToken, error) {
+	return s.RefreshCtx(context.Background(), refreshToken)
+}
+
+// RefreshCtx is the same as Refresh but uses the provided context for all db operations.
+func (s Sessions) RefreshCtx(ctx context.Context, refreshToken string) (*session.Session, *session.RefreshToken, error) {
+	defer s.Log.Emit(sinks.Info("Refresh Session").Trace("Sessions.Refresh").End())
+
+	if !s.Refreshable() {
+		err := fmt.Errorf("Sessions issue no refresh tokens: %w", ErrInvalidCredentials)
+		s.Log.Emit(sinks.Error(err))
+		return nil, nil, err
+	}
+
+	if err := s.keyed(); err != nil {
+		return nil, nil, err
+	}
+
+	sessionID, refreshID, token, err := session.ParseRefreshToken(refreshToken)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err))
+		return nil, nil, fmt.Errorf("%s: %w", err, ErrInvalidCredentials)
+	}
+
+	var current session.Session
+	var next *session.RefreshToken
+
+	err = db.WithContext(s.DB).WithTxCtx(ctx, func(tx db.DB) error {
+		txdb := db.WithContext(tx)
+
+		var old session.RefreshToken
+
+		if err := txdb.GetCtx(ctx, s.Refreshes, &old, "public_id", refreshID); err != nil {
+			return credentialsErr(err)
+		}
+
+		if old.SessionID != sessionID || !old.ValidateToken(s.Secret, token) {
+			return fmt.Errorf("Invalid refresh token: %w", ErrInvalidCredentials)
+		}
+
+		if old.Replaced() {
+			return errRefreshReused
+		}
+
+		if old.Expired() {
+			return fmt.Errorf("Refresh token has expired: %w", ErrInvalidCredentials)
+		}
+
+		if err := txdb.GetCtx(ctx, s.TableIdentity, &current, "public_id", sessionID); err != nil {
+			return credentialsErr(err)
+		}
+
+		current.Rotate(time.Now().Add(s.Expiration), s.Secret)
+		next = session.NewRefreshToken(current, time.Now().Add(s.RefreshExpiration), s.Secret)
+		old.Upgrade(s.Secret)
+
+		// Marking the refresh token as replaced claims it, as only one of concurrent
+		// refreshes with the same token finds it unreplaced, where the others are treated
+		// as reuse.
+		claimed, err := txdb.UpdateWhereCtx(ctx, s.Refreshes, session.UpdateRefreshReplaced{ReplacedBy: next.PublicID, TokenHash: old.TokenHash}, []db.Predicate{
+			db.Where("public_id", db.Eq, old.PublicID),
+			unreplaced,
+		})
+		if err != nil {
+			return err
+		}
+
+		if claimed == 0 {
+			return errRefreshReused
+		}
+
+		if err := txdb.UpdateCtx(ctx, s.TableIdentity, &current, "public_id"); err != nil {
+			return err
+		}
+
+		return txdb.SaveCtx(ctx, s.Refreshes, next)
+	})
+
+	if errors.Is(err, errRefreshReused) {
+		if revokeErr := s.revokeFamily(ctx, sessionID); revokeErr != nil {
+			s.Log.Emit(sinks.Error("Failed to revoke session of reused refresh token: %+q", revokeErr).WithFields(sink.Fields{"session_id": sessionID}))
+			return nil, nil, revokeErr
+		}
+
+		err = fmt.Errorf("%s, session revoked: %w", err, ErrInvalidCredentials)
+	}
+
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"session_id": sessionID}))
+		return nil, nil, err
+	}
+
+	return &current, next, nil
+}
+
+// revokeFamily removes the session with the giving public id along with all it's
+// refresh tokens.
+func (s Sessions) revokeFamily(ctx context.Context, sessionID string) error {
+	if err := db.WithContext(s.DB).DeleteCtx(ctx, s.TableIdentity, "public_id", sessionID); err != nil && !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+
+	return s.deleteRefreshes(ctx, session.FamilyIndex, sessionID)
+}
+
+// deleteRefreshes removes the refresh tokens whoes index matches the value, if the
+// Sessions issues refresh tokens.
+func (s Sessions) deleteRefreshes(ctx context.Context, index string, value string) error {
+	if !s.Refreshable() {
+		return nil
+	}
+
+	if err := db.WithContext(s.DB).DeleteCtx(ctx, s.Refreshes, index, value); err != nil && !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// stale returns true/false if the session can no longer be used, being expired and past
+// the expiry of any refresh token issued for it.
+func (s Sessions) stale(old session.Session) bool {
+	expires := old.Expires
+
+	// Refresh tokens are issued at the latest along with the last token of the session.
+	if s.Refreshable() {
+		expires = expires.Add(s.RefreshExpiration)
+	}
+
+	return time.Now().After(expires)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/mail"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+)
+
+// ErrResetsDisabled is returned when requesting password resets of Users without Resets.
+var ErrResetsDisabled = errors.New("Password resets are not enabled")
+
+// PasswordResetsFactory returns a new PasswordResets which mails tickets expiring after
+// the expiration through the mailer, stored within the resets table. The tokens of
+// tickets are signed and hashed with the secret, and the sessions of users who reset
+// their password are revoked with sessions if not nil.
+func PasswordResetsFactory(log sink.Sink, dbr db.DB, resetsT db.TableIdentity, secret []byte, expiration time.Duration, mailer mail.Mailer, sessions *Sessions) PasswordResets {
+	return PasswordResets{
+		DB:            dbr,
+		Log:           log,
+		Mailer:        mailer,
+		Secret:        secret,
+		Sessions:      sessions,
+		Expiration:    expiration,
+		TableIdentity: resetsT,
+	}
+}
+
+// PasswordResets defines the password resets of Users, where users who forgot their
+// password are mailed a ticket.Ticket whoes signed token is returned with a new password
+// to ResetPassword. Secret keys the signatures and hashes of the tokens, where no tickets
+// are mailed or verified while it is empty.
+type PasswordResets struct {
+	DB            db.DB
+	Log           sink.Sink
+	Mailer        mail.Mailer
+	Secret        []byte
+	Expiration    time.Duration
+	TableIdentity db.TableIdentity
+
+	// Sessions revokes all sessions of users who reset their password, if set.
+	Sessions *Sessions
+
+	// Link is the link mailed to users holding "%s" in place of the token, such as
+	// "https://example.com/reset?token=%s", where the token is mailed as is if empty.
+	Link string
+
+	// Message returns the message mailed to the user with the link, defaulting to a
+	// plain message holding the link.
+	Message func(nu *user.User, link string) mail.Message
+}
+
+// tickets returns the tickets issuing and redeeming the password resets.
+func (p PasswordResets) tickets() tickets {
+	return tickets{
+		DB:            p.DB,
+		Log:           p.Log,
+		Mailer:        p.Mailer,
+		Secret:        p.Secret,
+		Expiration:    p.Expiration,
+		TableIdentity: p.TableIdentity,
+		Link:          p.Link,
+		Message:       p.Message,
+		Kind:          "password reset",
+		Subject:       "Reset your password",
+		Body:          "Reset your password with the link below, which expires in %s and can be used once. Ignore this message if you did not ask to reset your password:\n\n%s\n",
+	}
+}
+
+// RequestPasswordReset mails a new ticket to the user with the giving email to reset it's
+// password, replacing any ticket mailed before. No error is returned if no user has the
+// email, so callers can not learn which emails have users.
+func (u Users) RequestPasswordReset(email string) error {
+	return u.RequestPasswordResetCtx(context.Background(), email)
+}
+
+// RequestPasswordResetCtx is the same as RequestPasswordReset but uses the provided context for all db operations.
+func (u Users) RequestPasswordResetCtx(ctx context.Context, email string) error {
+	defer u.Log.Emit(sinks.Info("Request User Password Reset").WithFields(sink.Fields{
+		"user_email": email,
+	}).Trace("handlers.Users.RequestPasswordReset").End())
+
+	if u.Resets == nil {
+		u.Log.Emit(sinks.Error(ErrResetsDisabled).WithFields(sink.Fields{"user_email": email}))
+		return ErrResetsDisabled
+	}
+
+	resets := u.Resets
+	if len(resets.Secret) == 0 {
+		resets.Log.Emit(sinks.Error(ErrNoSecret).WithFields(sink.Fields{"user_email": email}))
+		return ErrNoSecret
+	}
+
+	users := u
+	users.Profiles = nil
+
+	nu, err := users.GetByEmailCtx(ctx, email)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil
+		}
+
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": email}))
+		return err
+	}
+
+	return resets.tickets().issueCtx(ctx, nu)
+}
+
+// ResetPassword sets the new password of the user of the signed token mailed by
+// RequestPasswordReset, revoking all sessions of the user. The token must not have
+// expired, and is valid only once and only while the user's email is the one it was
+// mailed to, else an error matching ErrValidation is returned, as is for a new password
+// which does not meet the PasswordPolicy, where the token remains valid.
+func (u Users) ResetPassword(token string, newPassword string) error {
+	return u.ResetPasswordCtx(context.Background(), token, newPassword)
+}
+
+// ResetPasswordCtx is the same as ResetPassword but uses the provided context for all db operations.
+func (u Users) ResetPasswordCtx(ctx context.Context, token string, newPassword string) error {
+	defer u.Log.Emit(sinks.Info("Reset User Password").Trace("handlers.Users.ResetPassword").End())
+
+	if u.Resets == nil {
+		u.Log.Emit(sinks.Error(ErrResetsDisabled))
+		return ErrResetsDisabled
+	}
+
+	resets := u.Resets
+	if len(resets.Secret) == 0 {
+		resets.Log.Emit(sinks.Error(ErrNoSecret))
+		return ErrNoSecret
+	}
+
+	if newPassword == "" {
+		err := Invalid("password", "is required")
+		u.Log.Emit(sinks.Error(err))
+		return err
+	}
+
+	_, err := resets.tickets().redeemCtx(ctx, u, token, func(tx db.DB, nu *user.User) error {
+		if err := nu.ChangePasswordWithPolicy(newPassword, u.passwordPolicy()); err != nil {
+			return passwordErr(err)
+		}
+
+		if err := db.WithContext(tx).UpdateCtx(ctx, u.TableIdentity, user.UpdateUserHash{PublicID: nu.PublicID, Hash: nu.Hash}, "public_id"); err != nil {
+			return err
+		}
+
+		// Sessions taken over with the forgotten password must not outlive the reset.
+		if resets.Sessions != nil {
+			sessions := *resets.Sessions
+			sessions.DB = tx
+
+			if err := sessions.DeleteByUserCtx(ctx, nu.PublicID); err != nil && !errors.Is(err, db.ErrNotFound) {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return err
+}
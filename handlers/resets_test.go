@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/memory"
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/models/session"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/tests"
+)
+
+var resetsTable = db.TableName{Name: "password_resets"}
+
+// TestPasswordResets validates the password resets of the Users handler against a memory store.
+func TestPasswordResets(t *testing.T) {
+	store := memory.New()
+	box := new(mailbox)
+
+	sessions := handlers.SessionsFactory(log, store, sessionSecret, time.Hour, sessionsTable)
+
+	resets := handlers.PasswordResetsFactory(log, store, resetsTable, []byte("reset-secret"), time.Hour, box, &sessions)
+	resets.Link = "https://guma.com/reset?token=%s"
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	users.Resets = &resets
+
+	nu, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	current, err := sessions.Create(nu, session.Device{UserAgent: "laptop-agent"})
+	if err != nil {
+		tests.Failed("Should have successfully created session: %+q.", err)
+	}
+	tests.Passed("Should have successfully created session.")
+
+	t.Logf("Given the need to reset the forgotten password of a user")
+	{
+		t.Log("\tWhen requesting a password reset")
+		{
+			if err := users.RequestPasswordReset("BOB@guma.com"); err != nil {
+				tests.Failed("Should have successfully requested password reset: %+q.", err)
+			}
+			tests.Passed("Should have successfully requested password reset.")
+
+			if len(box.messages) != 1 || box.messages[0].To != "bob@guma.com" {
+				tests.Info("Messages: %+v", box.messages)
+				tests.Failed("Should have mailed password reset to user.")
+			}
+			tests.Passed("Should have mailed password reset to user.")
+		}
+
+		t.Log("\tWhen requesting a password reset for an unknown email")
+		{
+			if err := users.RequestPasswordReset("alice@guma.com"); err != nil || len(box.messages) != 1 {
+				tests.Failed("Should have silently ignored unknown email: %+q.", err)
+			}
+			tests.Passed("Should have silently ignored unknown email.")
+		}
+
+		t.Log("\tWhen resetting the password with the mailed token")
+		{
+			token := box.last("bob@guma.com")
+
+			if err := users.ResetPassword(token, ""); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have required new password.")
+			}
+			tests.Passed("Should have required new password.")
+
+			if err := users.ResetPassword(token, "sunshine"); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused weak password.")
+			}
+			tests.Passed("Should have refused weak password.")
+
+			if err := users.ResetPassword(token, "Shine-Bright-Moon-17"); err != nil {
+				tests.Failed("Should have successfully reset password: %+q.", err)
+			}
+			tests.Passed("Should have successfully reset password.")
+
+			if _, err := users.Authenticate("bob@guma.com", "Glow-Worm-Lantern-42"); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have refused old password.")
+			}
+			tests.Passed("Should have refused old password.")
+
+			if _, err := users.Authenticate("bob@guma.com", "Shine-Bright-Moon-17"); err != nil {
+				tests.Failed("Should have authenticated with new password: %+q.", err)
+			}
+			tests.Passed("Should have authenticated with new password.")
+
+			if _, err := sessions.Get(current.PublicID); !errors.Is(err, db.ErrNotFound) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have revoked existing sessions.")
+			}
+			tests.Passed("Should have revoked existing sessions.")
+
+			if err := users.ResetPassword(token, "Glare-Sun-Visor-93"); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused to reuse token.")
+			}
+			tests.Passed("Should have refused to reuse token.")
+		}
+
+		t.Log("\tWhen resetting the password with an expired token")
+		{
+			expired := resets
+			expired.Expiration = -time.Minute
+
+			stale := users
+			stale.Resets = &expired
+
+			if err := stale.RequestPasswordReset("bob@guma.com"); err != nil {
+				tests.Failed("Should have successfully requested password reset: %+q.", err)
+			}
+			tests.Passed("Should have successfully requested password reset.")
+
+			if err := users.ResetPassword(box.last("bob@guma.com"), "Glare-Sun-Visor-93"); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused expired token.")
+			}
+			tests.Passed("Should have refused expired token.")
+		}
+
+		t.Log("\tWhen password resets are not enabled")
+		{
+			disabled := handlers.UsersFactory(log, store, usersTable, profilesTable)
+
+			if err := disabled.RequestPasswordReset("bob@guma.com"); !errors.Is(err, handlers.ErrResetsDisabled) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused password reset.")
+			}
+			tests.Passed("Should have refused password reset.")
+		}
+
+		t.Log("\tWhen the password resets have no secret")
+		{
+			unkeyed := resets
+			unkeyed.Secret = nil
+
+			keyless := users
+			keyless.Resets = &unkeyed
+
+			if err := keyless.RequestPasswordReset("bob@guma.com"); !errors.Is(err, handlers.ErrNoSecret) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused to mail password reset.")
+			}
+			tests.Passed("Should have refused to mail password reset.")
+		}
+	}
+}
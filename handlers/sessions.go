@@ -1,22 +1,29 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/influx6/backoffice/db"
 	"github.com/influx6/backoffice/models/session"
 	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/backoffice/utils"
 	"github.com/influx6/faux/sink"
 	"github.com/influx6/faux/sink/sinks"
 )
 
 // DeferredSessionsFactory returns a function which allows easily create a new copy
-// of a Sessions struct.
-func DeferredSessionsFactory(log sink.Sink, dbr db.DB) func(db.TableIdentity, time.Duration) Sessions {
+// of a Sessions struct, whoes tokens are hashed with the secret.
+func DeferredSessionsFactory(log sink.Sink, dbr db.DB, secret []byte) func(db.TableIdentity, time.Duration) Sessions {
 	return func(us db.TableIdentity, expiry time.Duration) Sessions {
 		return Sessions{
 			DB:            dbr,
 			Log:           log,
+			Secret:        secret,
 			Expiration:    expiry,
 			TableIdentity: us,
 		}
@@ -24,82 +31,151 @@ func DeferredSessionsFactory(log sink.Sink, dbr db.DB) func(db.TableIdentity, ti
 }
 
 // SessionsFactory returns a function which returns a given a new instance of a
-// Sessions.
-func SessionsFactory(log sink.Sink, dbr db.DB, expiry time.Duration, session db.TableIdentity) Sessions {
+// Sessions, whoes tokens are hashed with the secret.
+func SessionsFactory(log sink.Sink, dbr db.DB, secret []byte, expiry time.Duration, session db.TableIdentity) Sessions {
 	return Sessions{
 		DB:            dbr,
 		Log:           log,
+		Secret:        secret,
 		Expiration:    expiry,
 		TableIdentity: session,
 	}
 }
 
+// RefreshSessionsFactory returns a new instance of a Sessions which issues refresh tokens
+// into the refresh table, each valid for the refresh expiry. The expiry of sessions should
+// then be short, as they are renewed by exchanging their refresh tokens. Session and
+// refresh tokens are hashed with the secret.
+func RefreshSessionsFactory(log sink.Sink, dbr db.DB, secret []byte, expiry time.Duration, session db.TableIdentity, refresh db.TableIdentity, refreshExpiry time.Duration) Sessions {
+	return Sessions{
+		DB:                dbr,
+		Log:               log,
+		Secret:            secret,
+		Expiration:        expiry,
+		TableIdentity:     session,
+		Refreshes:         refresh,
+		RefreshExpiration: refreshExpiry,
+	}
+}
+
 // Sessions defines a handler which provides session related methods.
 type Sessions struct {
 	DB            db.DB
 	Log           sink.Sink
 	Expiration    time.Duration
 	TableIdentity db.TableIdentity
+
+	// Secret keys the HMAC-SHA256 hash of session and refresh tokens, which is stored in
+	// place of the tokens. Changing it invalidates all issued tokens. No tokens are
+	// issued or verified while it is empty.
+	Secret []byte
+
+	// Sliding extends the expiry of a session to Expiration from the time it is used to
+	// authorize a request, at most once every LastSeenInterval.
+	Sliding bool
+
+	// Tokens issues and verifies the tokens of sessions, where opaque tokens verified
+	// against the sessions table are used if it is nil.
+	Tokens TokenStrategy
+
+	// Refreshes is the table of refresh tokens, which are only issued if it is set.
+	Refreshes         db.TableIdentity
+	RefreshExpiration time.Duration
+}
+
+// keyed returns ErrNoSecret if the sessions have no Secret to hash tokens with.
+func (s Sessions) keyed() error {
+	if len(s.Secret) == 0 {
+		s.Log.Emit(sinks.Error(ErrNoSecret))
+		return ErrNoSecret
+	}
+
+	return nil
 }
 
-// Create adds a new session for the specified user.
-func (s Sessions) Create(nu *user.User) (*session.Session, error) {
+// LastSeenInterval defines the least duration between updates of the last seen time of
+// a session as it is used to authorize requests.
+const LastSeenInterval = time.Minute
+
+// Create adds a new session for the specified user on the giving device. Each device
+// holds a session of it's own, where only the expired sessions of the user are removed.
+func (s Sessions) Create(nu *user.User, device session.Device) (*session.Session, error) {
+	return s.CreateCtx(context.Background(), nu, device)
+}
+
+// CreateCtx is the same as Create but uses the provided context for all db operations.
+func (s Sessions) CreateCtx(ctx context.Context, nu *user.User, device session.Device) (*session.Session, error) {
 	defer s.Log.Emit(sinks.Info("Create New Session").WithFields(sink.Fields{
 		"user_email": nu.Email,
 		"user_id":    nu.PublicID,
+		"user_agent": device.UserAgent,
+		"ip":         device.IP,
 	}).Trace("Sessions.Create").End())
 
-	currentTime := time.Now()
-
-	var newSession session.Session
+	if err := s.keyed(); err != nil {
+		return nil, err
+	}
 
-	// Attempt to retrieve session from db if we still have an outstanding non-expired session.
-	if err := s.DB.Get(s.TableIdentity, &newSession, session.UniqueIndex, nu.PublicID); err == nil {
+	existing, err := s.ListByUserCtx(ctx, nu.PublicID)
+	if err != nil {
+		s.Log.Emit(sinks.Error("Failed to retrieve sessions: %+q", err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
+		return nil, err
+	}
 
-		// We have an existing session and the time of expiring is still counting, simly return
-		if !newSession.Expires.IsZero() && currentTime.Before(newSession.Expires) {
-			return &newSession, nil
+	// Kick out the expired sessions of the user, as they can no longer be used.
+	for _, old := range existing {
+		if !s.stale(old) {
+			continue
 		}
 
-		// 	If we still have active session, then simply kick it and return safely.
-		if newSession.Expires.IsZero() || currentTime.After(newSession.Expires) {
+		if err := db.WithContext(s.DB).DeleteCtx(ctx, s.TableIdentity, "public_id", old.PublicID); err != nil && !errors.Is(err, db.ErrNotFound) {
+			s.Log.Emit(sinks.Error("Failed to delete old session: %+q", err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
+			return nil, err
+		}
 
-			// Delete this sessions
-			if err := s.DB.Delete(s.TableIdentity, session.UniqueIndex, nu.PublicID); err != nil {
-				s.Log.Emit(sinks.Error("Failed to delete old session: %+q", err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
-				return nil, err
-			}
+		if err := s.deleteRefreshes(ctx, session.FamilyIndex, old.PublicID); err != nil {
+			s.Log.Emit(sinks.Error("Failed to delete old refresh tokens: %+q", err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
+			return nil, err
 		}
 	}
 
 	// Create new session and store session into db.
-	newSession = *session.New(nu.PublicID, time.Now().Add(s.Expiration))
+	newSession := session.New(nu.PublicID, time.Now().Add(s.Expiration), device, s.Secret)
+	newSession.Roles = nu.Roles
 
-	if err := s.DB.Save(s.TableIdentity, &newSession); err != nil {
+	if err := db.WithContext(s.DB).SaveCtx(ctx, s.TableIdentity, newSession); err != nil {
 		s.Log.Emit(sinks.Error("Failed to save new session: %+q", err).WithFields(sink.Fields{"user_email": nu.Email, "user_id": nu.PublicID}))
 		return nil, err
 	}
 
-	return &newSession, nil
+	return newSession, nil
 }
 
 // SessionRecords defines a struct which returns the total fields and page details
-// used in retrieving the records.
+// used in retrieving the records. Next and Prev are the cursors of the following and
+// preceding pages of records retrieved with GetPage.
 type SessionRecords struct {
 	Total           int               `json:"total"`
 	Page            int               `json:"page"`
 	ResponsePerPage int               `json:"responsePerPage"`
 	Records         []session.Session `json:"records"`
+	Next            string            `json:"next,omitempty"`
+	Prev            string            `json:"prev,omitempty"`
 }
 
 // GetAll handles receiving requests to retrieve all user from the database.
 func (s Sessions) GetAll(page, responsePerPage int) (SessionRecords, error) {
+	return s.GetAllCtx(context.Background(), page, responsePerPage)
+}
+
+// GetAllCtx is the same as GetAll but uses the provided context for all db operations.
+func (s Sessions) GetAllCtx(ctx context.Context, page, responsePerPage int) (SessionRecords, error) {
 	defer s.Log.Emit(sinks.Info("Get Existing User").WithFields(sink.Fields{
 		"page":            page,
 		"responsePerPage": responsePerPage,
 	}).Trace("handlers.Users.Create").End())
 
-	records, realTotalRecords, err := s.DB.GetAllPerPage(s.TableIdentity, "asc", "public_id", page, responsePerPage)
+	records, realTotalRecords, err := db.WithContext(s.DB).GetAllPerPageCtx(ctx, s.TableIdentity, "asc", "public_id", page, responsePerPage)
 	if err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"page":            page,
@@ -132,34 +208,442 @@ func (s Sessions) GetAll(page, responsePerPage int) (SessionRecords, error) {
 	}, nil
 }
 
-// Get retrieves the session associated with the giving User.
-func (s Sessions) Get(userID string) (*session.Session, error) {
+// GetPage handles receiving requests to retrieve a page of at most limit sessions, which are
+// ordered by their creation. The page starts after, or before, the position of the
+// cursor, which is one of the Next and Prev cursors of a previous page, or from the first
+// session if empty.
+func (s Sessions) GetPage(cursor string, limit int) (SessionRecords, error) {
+	return s.GetPageCtx(context.Background(), cursor, limit)
+}
+
+// GetPageCtx is the same as GetPage but uses the provided context for all db operations.
+func (s Sessions) GetPageCtx(ctx context.Context, cursor string, limit int) (SessionRecords, error) {
+	defer s.Log.Emit(sinks.Info("Get Page of Sessions").WithFields(sink.Fields{
+		"cursor": cursor,
+		"limit":  limit,
+	}).Trace("handlers.Sessions.GetPage").End())
+
+	page, err := db.FindPage(ctx, s.DB, s.TableIdentity, nil, cursor, limit)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+		return SessionRecords{}, err
+	}
+
+	total, err := db.WithContext(s.DB).CountCtx(ctx, s.TableIdentity)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+		return SessionRecords{}, err
+	}
+
+	records := make([]session.Session, 0, len(page.Records))
+
+	for _, record := range page.Records {
+		var nw session.Session
+
+		if err := nw.WithFields(record); err != nil {
+			s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+			return SessionRecords{}, err
+		}
+
+		records = append(records, nw)
+	}
+
+	return SessionRecords{
+		Total:           total,
+		ResponsePerPage: limit,
+		Records:         records,
+		Next:            page.Next,
+		Prev:            page.Prev,
+	}, nil
+}
+
+// Get retrieves the session with the giving public id.
+func (s Sessions) Get(sessionID string) (*session.Session, error) {
+	return s.GetCtx(context.Background(), sessionID)
+}
+
+// GetCtx is the same as Get but uses the provided context for all db operations.
+func (s Sessions) GetCtx(ctx context.Context, sessionID string) (*session.Session, error) {
 	defer s.Log.Emit(sinks.Info("Get Existing Session").WithFields(sink.Fields{
-		"user_id": userID,
+		"session_id": sessionID,
 	}).Trace("Sessions.Get").End())
 
 	var existingSession session.Session
 
-	// Attempt to retrieve session from db if we still have an outstanding non-expired session.
-	if err := s.DB.Get(s.TableIdentity, &existingSession, session.UniqueIndex, userID); err != nil {
-		s.Log.Emit(sinks.Error("Failed to retrieve session from db: %+q", err).WithFields(sink.Fields{"user_id": userID}))
+	if err := db.WithContext(s.DB).GetCtx(ctx, s.TableIdentity, &existingSession, "public_id", sessionID); err != nil {
+		s.Log.Emit(sinks.Error("Failed to retrieve session from db: %+q", err).WithFields(sink.Fields{"session_id": sessionID}))
 		return nil, err
 	}
 
 	return &existingSession, nil
 }
 
-// Delete removes an existing session from the db for a specified user.
-func (s Sessions) Delete(userID string) error {
-	defer s.Log.Emit(sinks.Info("Delete Existing Session").WithFields(sink.Fields{
+// ListByUser retrieves all sessions of the giving user, ordered by their creation.
+func (s Sessions) ListByUser(userID string) ([]session.Session, error) {
+	return s.ListByUserCtx(context.Background(), userID)
+}
+
+// ListByUserCtx is the same as ListByUser but uses the provided context for all db operations.
+func (s Sessions) ListByUserCtx(ctx context.Context, userID string) ([]session.Session, error) {
+	defer s.Log.Emit(sinks.Info("List User Sessions").WithFields(sink.Fields{
 		"user_id": userID,
-	}).Trace("Sessions.Delete").End())
+	}).Trace("Sessions.ListByUser").End())
+
+	records, err := db.WithContext(s.DB).FindCtx(ctx, s.TableIdentity, db.Query{
+		Where: []db.Predicate{db.Where(session.UniqueIndex, db.Eq, userID)},
+		Order: []db.Order{{Field: "created_at"}, {Field: "public_id"}},
+	})
+	if err != nil {
+		s.Log.Emit(sinks.Error("Failed to retrieve user sessions from db: %+q", err).WithFields(sink.Fields{"user_id": userID}))
+		return nil, err
+	}
+
+	sessions := make([]session.Session, 0, len(records))
+
+	for _, record := range records {
+		var nw session.Session
+
+		if err := nw.WithFields(record); err != nil {
+			s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_id": userID}))
+			return nil, err
+		}
+
+		sessions = append(sessions, nw)
+	}
+
+	return sessions, nil
+}
+
+// Revoke removes the session of the giving user with the giving public id. It returns
+// an error wrapping db.ErrNotFound if the user holds no such session.
+func (s Sessions) Revoke(userID string, sessionID string) error {
+	return s.RevokeCtx(context.Background(), userID, sessionID)
+}
+
+// RevokeCtx is the same as Revoke but uses the provided context for all db operations.
+func (s Sessions) RevokeCtx(ctx context.Context, userID string, sessionID string) error {
+	defer s.Log.Emit(sinks.Info("Revoke Existing Session").WithFields(sink.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	}).Trace("Sessions.Revoke").End())
+
+	existing, err := s.GetCtx(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Sessions of other users are reported as missing, not revealing their existence.
+	if existing.UserID != userID {
+		err := fmt.Errorf("Session %q of user %q: %w", sessionID, userID, db.ErrNotFound)
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_id": userID, "session_id": sessionID}))
+		return err
+	}
 
-	// Delete this sessions
-	if err := s.DB.Delete(s.TableIdentity, session.UniqueIndex, userID); err != nil {
-		s.Log.Emit(sinks.Error("Failed to delete user session from db: %+q", err).WithFields(sink.Fields{"user_id": userID}))
+	if err := db.WithContext(s.DB).DeleteCtx(ctx, s.TableIdentity, "public_id", sessionID); err != nil {
+		s.Log.Emit(sinks.Error("Failed to delete user session from db: %+q", err).WithFields(sink.Fields{"user_id": userID, "session_id": sessionID}))
+		return err
+	}
+
+	if err := s.deleteRefreshes(ctx, session.FamilyIndex, sessionID); err != nil {
+		s.Log.Emit(sinks.Error("Failed to delete session refresh tokens from db: %+q", err).WithFields(sink.Fields{"user_id": userID, "session_id": sessionID}))
 		return err
 	}
 
 	return nil
 }
+
+// RevokeOthers removes all sessions of the giving user except the session with the
+// giving public id, returning the total of sessions removed.
+func (s Sessions) RevokeOthers(userID string, sessionID string) (int, error) {
+	return s.RevokeOthersCtx(context.Background(), userID, sessionID)
+}
+
+// RevokeOthersCtx is the same as RevokeOthers but uses the provided context for all db operations.
+func (s Sessions) RevokeOthersCtx(ctx context.Context, userID string, sessionID string) (int, error) {
+	defer s.Log.Emit(sinks.Info("Revoke Other Sessions").WithFields(sink.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	}).Trace("Sessions.RevokeOthers").End())
+
+	sessions, err := s.ListByUserCtx(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var revoked int
+
+	for _, other := range sessions {
+		if other.PublicID == sessionID {
+			continue
+		}
+
+		// A session removed since it was listed, such as by a concurrent logout, is already revoked.
+		if err := db.WithContext(s.DB).DeleteCtx(ctx, s.TableIdentity, "public_id", other.PublicID); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				continue
+			}
+
+			s.Log.Emit(sinks.Error("Failed to delete user session from db: %+q", err).WithFields(sink.Fields{"user_id": userID, "session_id": other.PublicID}))
+			return revoked, err
+		}
+
+		if err := s.deleteRefreshes(ctx, session.FamilyIndex, other.PublicID); err != nil {
+			s.Log.Emit(sinks.Error("Failed to delete session refresh tokens from db: %+q", err).WithFields(sink.Fields{"user_id": userID, "session_id": other.PublicID}))
+			return revoked, err
+		}
+
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// DeleteByUser removes all sessions of the specified user from the db.
+func (s Sessions) DeleteByUser(userID string) error {
+	return s.DeleteByUserCtx(context.Background(), userID)
+}
+
+// DeleteByUserCtx is the same as DeleteByUser but uses the provided context for all db operations.
+func (s Sessions) DeleteByUserCtx(ctx context.Context, userID string) error {
+	defer s.Log.Emit(sinks.Info("Delete Existing Sessions").WithFields(sink.Fields{
+		"user_id": userID,
+	}).Trace("Sessions.DeleteByUser").End())
+
+	if err := db.WithContext(s.DB).DeleteCtx(ctx, s.TableIdentity, session.UniqueIndex, userID); err != nil {
+		s.Log.Emit(sinks.Error("Failed to delete user sessions from db: %+q", err).WithFields(sink.Fields{"user_id": userID}))
+		return err
+	}
+
+	if err := s.deleteRefreshes(ctx, session.UniqueIndex, userID); err != nil {
+		s.Log.Emit(sinks.Error("Failed to delete user refresh tokens from db: %+q", err).WithFields(sink.Fields{"user_id": userID}))
+		return err
+	}
+
+	return nil
+}
+
+// legacyTokens matches the records of sessions and refresh tokens created before tokens
+// were hashed, which hold no token_hash.
+var legacyTokens = db.Or(db.Where("token_hash", db.IsNull, true), db.Where("token_hash", db.Eq, ""))
+
+// HashLegacyTokens replaces the tokens which sessions and refresh tokens created before
+// tokens were hashed hold as is with their hash, returning the number of records upgraded.
+// Legacy sessions are otherwise only upgraded on their next use with Authorize.
+func (s Sessions) HashLegacyTokens() (int, error) {
+	return s.HashLegacyTokensCtx(context.Background())
+}
+
+// HashLegacyTokensCtx is the same as HashLegacyTokens but uses the provided context for all db operations.
+func (s Sessions) HashLegacyTokensCtx(ctx context.Context) (int, error) {
+	defer s.Log.Emit(sinks.Info("Hash Legacy Tokens").Trace("Sessions.HashLegacyTokens").End())
+
+	var upgraded int
+
+	if err := s.keyed(); err != nil {
+		return upgraded, err
+	}
+
+	records, err := db.WithContext(s.DB).FindCtx(ctx, s.TableIdentity, db.Query{Where: []db.Predicate{legacyTokens}})
+	if err != nil {
+		s.Log.Emit(sinks.Error("Failed to retrieve legacy sessions from db: %+q", err))
+		return upgraded, err
+	}
+
+	for _, record := range records {
+		var legacy session.Session
+
+		if err := legacy.WithFields(record); err != nil {
+			s.Log.Emit(sinks.Error(err))
+			return upgraded, err
+		}
+
+		legacy.Upgrade(s.Secret)
+
+		updated, err := s.upgradeLegacy(ctx, s.TableIdentity, legacy.PublicID, legacy.TokenHash)
+		if err != nil {
+			s.Log.Emit(sinks.Error("Failed to update legacy session: %+q", err).WithFields(sink.Fields{"session_id": legacy.PublicID}))
+			return upgraded, err
+		}
+
+		upgraded += updated
+	}
+
+	if !s.Refreshable() {
+		return upgraded, nil
+	}
+
+	records, err = db.WithContext(s.DB).FindCtx(ctx, s.Refreshes, db.Query{Where: []db.Predicate{legacyTokens}})
+	if err != nil {
+		s.Log.Emit(sinks.Error("Failed to retrieve legacy refresh tokens from db: %+q", err))
+		return upgraded, err
+	}
+
+	for _, record := range records {
+		var legacy session.RefreshToken
+
+		if err := legacy.WithFields(record); err != nil {
+			s.Log.Emit(sinks.Error(err))
+			return upgraded, err
+		}
+
+		legacy.Upgrade(s.Secret)
+
+		updated, err := s.upgradeLegacy(ctx, s.Refreshes, legacy.PublicID, legacy.TokenHash)
+		if err != nil {
+			s.Log.Emit(sinks.Error("Failed to update legacy refresh token: %+q", err).WithFields(sink.Fields{"refresh_id": legacy.PublicID}))
+			return upgraded, err
+		}
+
+		upgraded += updated
+	}
+
+	return upgraded, nil
+}
+
+// upgradeLegacy replaces the token of the legacy record of the table with the giving public
+// id with it's hash, returning the number of records upgraded. Only the hash is written,
+// and only while the record still holds no hash, as a concurrent refresh may have rotated
+// it's token since it was retrieved.
+func (s Sessions) upgradeLegacy(ctx context.Context, table db.TableIdentity, publicID string, tokenHash string) (int, error) {
+	return db.WithContext(s.DB).UpdateWhereCtx(ctx, table, session.UpdateTokenHash{TokenHash: tokenHash}, []db.Predicate{
+		db.Where("public_id", db.Eq, publicID),
+		legacyTokens,
+	})
+}
+
+// Authorize returns the session of the giving Bearer authorization header, whoes token
+// is verified by the TokenStrategy of the Sessions.
+/* Service API
+Header:
+		{
+			"Authorization":"Bearer <TOKEN>",
+		}
+
+		WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>, or a signed JWT with JWTTokens
+*/
+func (s Sessions) Authorize(authorization string) (*session.Session, error) {
+	return s.AuthorizeCtx(context.Background(), authorization)
+}
+
+// AuthorizeCtx is the same as Authorize but uses the provided context for all db operations.
+func (s Sessions) AuthorizeCtx(ctx context.Context, authorization string) (*session.Session, error) {
+	defer s.Log.Emit(sinks.Info("Authorize Session").WithFields(sink.Fields{
+		"authorization": fingerprint(authorization),
+	}).Trace("Sessions.Authorize").End())
+
+	if err := s.keyed(); err != nil {
+		return nil, err
+	}
+
+	// Retrieve authorization header.
+	authType, token, err := utils.ParseAuthorization(authorization)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"authorization": fingerprint(authorization)}))
+		return nil, fmt.Errorf("%s: %w", err, ErrInvalidCredentials)
+	}
+
+	if authType != "Bearer" {
+		err := fmt.Errorf("Only `Bearer` Authorization supported: %w", ErrInvalidCredentials)
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"authorization": fingerprint(authorization)}))
+		return nil, err
+	}
+
+	return s.tokens().VerifyCtx(ctx, s, token)
+}
+
+// fingerprint returns a short hash of the authorization, which identifies it within logs
+// without revealing it's token.
+func fingerprint(authorization string) string {
+	sum := sha256.Sum256([]byte(authorization))
+	return hex.EncodeToString(sum[:6])
+}
+
+// verifyOpaque returns the session of the opaque token, which must match the token of
+// an unexpired session of the user it names. It records the use of the session as it's
+// last seen time, at most once every LastSeenInterval, extending the session's expiry
+// if Sliding is set.
+func (s Sessions) verifyOpaque(ctx context.Context, token string) (*session.Session, error) {
+	// Retrieve Authorization UserID, SessionID and Token.
+	userID, sessionID, sessionToken, err := session.ParseToken(token)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err))
+		return nil, fmt.Errorf("%s: %w", err, ErrInvalidCredentials)
+	}
+
+	var userSession *session.Session
+
+	if sessionID == "" {
+		userSession, err = s.legacySession(ctx, userID, sessionToken)
+	} else {
+		userSession, err = s.GetCtx(ctx, sessionID)
+	}
+
+	if err != nil {
+		return nil, credentialsErr(err)
+	}
+
+	sessionID = userSession.PublicID
+
+	// if the session is not the user's or the token does not match, probably faked request
+	// or messed up old session.
+	if userSession.UserID != userID || !userSession.ValidateToken(s.Secret, sessionToken) {
+		err := fmt.Errorf("Invalid user session's token: %w", ErrInvalidCredentials)
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"session_id": sessionID}))
+		return nil, err
+	}
+
+	// If session has expired, then we fail the request.
+	if userSession.Expired() {
+		err := fmt.Errorf("User session has expired: %w", ErrInvalidCredentials)
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"session_id": sessionID}))
+		return nil, err
+	}
+
+	// Sessions created before tokens were hashed are upgraded as they are used. Failing
+	// to upgrade or record the use of a valid session does not fail the request, where
+	// a legacy session is upgraded on it's next use.
+	if userSession.Legacy() {
+		userSession.Upgrade(s.Secret)
+
+		if _, err := s.upgradeLegacy(ctx, s.TableIdentity, userSession.PublicID, userSession.TokenHash); err != nil {
+			s.Log.Emit(sinks.Error("Failed to upgrade legacy session: %+q", err).WithFields(sink.Fields{"session_id": userSession.PublicID}))
+		}
+	}
+
+	if time.Since(userSession.LastSeen) >= LastSeenInterval {
+		seen := session.UpdateSessionSeen{PublicID: userSession.PublicID, LastSeen: time.Now().UTC()}
+		userSession.LastSeen = seen.LastSeen
+
+		if s.Sliding {
+			seen.Expires = seen.LastSeen.Add(s.Expiration)
+			userSession.Expires = seen.Expires
+		}
+
+		// Only the use is written, as a concurrent refresh may have rotated the token of
+		// the session since it was retrieved.
+		if err := db.WithContext(s.DB).UpdateCtx(ctx, s.TableIdentity, seen, "public_id"); err != nil {
+			s.Log.Emit(sinks.Error("Failed to update session last seen: %+q", err).WithFields(sink.Fields{"session_id": userSession.PublicID}))
+		}
+	}
+
+	return userSession, nil
+}
+
+// legacySession returns the session of the user whoes token matches the giving token, for
+// tokens issued before users held a session for each device, which name only the user.
+func (s Sessions) legacySession(ctx context.Context, userID string, token string) (*session.Session, error) {
+	sessions, err := s.ListByUserCtx(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range sessions {
+		if sessions[index].ValidateToken(s.Secret, token) {
+			return &sessions[index], nil
+		}
+	}
+
+	err = fmt.Errorf("No session of user %q matches token: %w", userID, db.ErrNotFound)
+	s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_id": userID}))
+	return nil, err
+}
@@ -0,0 +1,570 @@
+package handlers_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influx6/backoffice/auth/jwt"
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/memory"
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/models/session"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/tests"
+)
+
+var sessionsTable = db.TableName{Name: "sessions"}
+
+// sessionSecret keys the hashes of session tokens issued within tests.
+var sessionSecret = []byte("session-secret")
+
+// TestSessions validates the per-device sessions of the Sessions handler against a memory store.
+func TestSessions(t *testing.T) {
+	store := memory.New()
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	sessions := handlers.SessionsFactory(log, store, sessionSecret, time.Hour, sessionsTable)
+	auth := handlers.BearerAuthFactory(log, store, sessionSecret, usersTable, profilesTable, sessionsTable, time.Hour)
+
+	nu, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	t.Logf("Given the need to hold a session for each device of a user")
+	{
+		laptop, err := sessions.Create(nu, session.Device{UserAgent: "laptop-agent", IP: "10.0.0.1", Label: "laptop"})
+		if err != nil {
+			tests.Failed("Should have successfully created laptop session: %+q.", err)
+		}
+		tests.Passed("Should have successfully created laptop session.")
+
+		phone, err := sessions.Create(nu, session.Device{UserAgent: "phone-agent", IP: "10.0.0.2"})
+		if err != nil {
+			tests.Failed("Should have successfully created phone session: %+q.", err)
+		}
+		tests.Passed("Should have successfully created phone session.")
+
+		tablet, err := sessions.Create(nu, session.Device{UserAgent: "tablet-agent", IP: "10.0.0.3"})
+		if err != nil {
+			tests.Failed("Should have successfully created tablet session: %+q.", err)
+		}
+		tests.Passed("Should have successfully created tablet session.")
+
+		t.Log("\tWhen listing the sessions of the user")
+		{
+			list, err := sessions.ListByUser(nu.PublicID)
+			if err != nil {
+				tests.Failed("Should have successfully listed sessions: %+q.", err)
+			}
+			tests.Passed("Should have successfully listed sessions.")
+
+			if len(list) != 3 || list[0].PublicID != laptop.PublicID {
+				tests.Info("Sessions: %+v", list)
+				tests.Failed("Should have listed all sessions in order of creation.")
+			}
+			tests.Passed("Should have listed all sessions in order of creation.")
+
+			if list[0].UserAgent != "laptop-agent" || list[0].IP != "10.0.0.1" || list[0].Label != "laptop" || list[0].CreatedAt.IsZero() {
+				tests.Info("Session: %+v", list[0])
+				tests.Failed("Should have recorded device of session.")
+			}
+			tests.Passed("Should have recorded device of session.")
+		}
+
+		t.Log("\tWhen authorizing with the token of each session")
+		{
+			for _, current := range []*session.Session{laptop, phone} {
+				if err := auth.CheckAuthorization("Bearer " + current.SessionToken()); err != nil {
+					tests.Failed("Should have successfully authorized session %q: %+q.", current.Label, err)
+				}
+			}
+			tests.Passed("Should have successfully authorized all sessions.")
+
+			forged := *phone
+			forged.PublicID = laptop.PublicID
+
+			if err := auth.CheckAuthorization("Bearer " + forged.SessionToken()); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have rejected token of another session: %+q.", err)
+			}
+			tests.Passed("Should have rejected token of another session.")
+		}
+
+		t.Log("\tWhen revoking a single session")
+		{
+			if err := sessions.Revoke("unknown", phone.PublicID); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have refused revoking session of another user: %+q.", err)
+			}
+			tests.Passed("Should have refused revoking session of another user.")
+
+			if err := sessions.Revoke(nu.PublicID, phone.PublicID); err != nil {
+				tests.Failed("Should have successfully revoked session: %+q.", err)
+			}
+			tests.Passed("Should have successfully revoked session.")
+
+			if err := auth.CheckAuthorization("Bearer " + phone.SessionToken()); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have rejected revoked session: %+q.", err)
+			}
+			tests.Passed("Should have rejected revoked session.")
+
+			if err := auth.CheckAuthorization("Bearer " + laptop.SessionToken()); err != nil {
+				tests.Failed("Should have kept other sessions: %+q.", err)
+			}
+			tests.Passed("Should have kept other sessions.")
+		}
+
+		t.Log("\tWhen revoking all other sessions")
+		{
+			revoked, err := sessions.RevokeOthers(nu.PublicID, tablet.PublicID)
+			if err != nil {
+				tests.Failed("Should have successfully revoked other sessions: %+q.", err)
+			}
+			tests.Passed("Should have successfully revoked other sessions.")
+
+			if revoked != 1 {
+				tests.Failed("Should have revoked 1 session, got %d.", revoked)
+			}
+			tests.Passed("Should have revoked 1 session.")
+
+			list, err := sessions.ListByUser(nu.PublicID)
+			if err != nil || len(list) != 1 || list[0].PublicID != tablet.PublicID {
+				tests.Failed("Should have kept only the current session: %+q.", err)
+			}
+			tests.Passed("Should have kept only the current session.")
+		}
+
+		t.Log("\tWhen the sessions have no secret")
+		{
+			unkeyed := sessions
+			unkeyed.Secret = nil
+
+			if _, err := unkeyed.Create(nu, session.Device{UserAgent: "laptop-agent"}); !errors.Is(err, handlers.ErrNoSecret) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused to issue session.")
+			}
+			tests.Passed("Should have refused to issue session.")
+
+			if _, err := unkeyed.Authorize("Bearer " + tablet.SessionToken()); !errors.Is(err, handlers.ErrNoSecret) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused to verify session.")
+			}
+			tests.Passed("Should have refused to verify session.")
+		}
+	}
+}
+
+// TestSessionsRefresh validates the rotation and reuse detection of refresh tokens, and the
+// sliding expiry of sessions against a memory store.
+func TestSessionsRefresh(t *testing.T) {
+	store := memory.New()
+	refreshTable := db.TableName{Name: "refresh_tokens"}
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	sessions := handlers.RefreshSessionsFactory(log, store, sessionSecret, time.Hour, sessionsTable, refreshTable, 24*time.Hour)
+	auth := handlers.BearerAuthFactory(log, store, sessionSecret, usersTable, profilesTable, sessionsTable, time.Hour)
+
+	nu, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	t.Logf("Given the need to renew sessions with refresh tokens")
+	{
+		current, err := sessions.Create(nu, session.Device{Label: "laptop"})
+		if err != nil {
+			tests.Failed("Should have successfully created session: %+q.", err)
+		}
+		tests.Passed("Should have successfully created session.")
+
+		first, err := sessions.IssueRefresh(current)
+		if err != nil {
+			tests.Failed("Should have successfully issued refresh token: %+q.", err)
+		}
+		tests.Passed("Should have successfully issued refresh token.")
+
+		var renewed *session.Session
+		var second *session.RefreshToken
+
+		t.Log("\tWhen exchanging a refresh token")
+		{
+			renewed, second, err = sessions.Refresh(first.EncodedToken())
+			if err != nil {
+				tests.Failed("Should have successfully refreshed session: %+q.", err)
+			}
+			tests.Passed("Should have successfully refreshed session.")
+
+			if renewed.PublicID != current.PublicID || renewed.Token == current.Token || second.PublicID == first.PublicID {
+				tests.Failed("Should have rotated tokens of the same session.")
+			}
+			tests.Passed("Should have rotated tokens of the same session.")
+
+			if err := auth.CheckAuthorization("Bearer " + current.SessionToken()); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have rejected previous session token: %+q.", err)
+			}
+			tests.Passed("Should have rejected previous session token.")
+
+			if err := auth.CheckAuthorization("Bearer " + renewed.SessionToken()); err != nil {
+				tests.Failed("Should have successfully authorized renewed session token: %+q.", err)
+			}
+			tests.Passed("Should have successfully authorized renewed session token.")
+		}
+
+		t.Log("\tWhen reusing an exchanged refresh token")
+		{
+			if _, _, err := sessions.Refresh(first.EncodedToken()); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have rejected reused refresh token: %+q.", err)
+			}
+			tests.Passed("Should have rejected reused refresh token.")
+
+			if _, err := sessions.Get(current.PublicID); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have revoked session of reused refresh token: %+q.", err)
+			}
+			tests.Passed("Should have revoked session of reused refresh token.")
+
+			if _, _, err := sessions.Refresh(second.EncodedToken()); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have revoked all refresh tokens of the session: %+q.", err)
+			}
+			tests.Passed("Should have revoked all refresh tokens of the session.")
+		}
+	}
+
+	t.Logf("Given the need to exchange a refresh token only once")
+	{
+		current, err := sessions.Create(nu, session.Device{Label: "tablet"})
+		if err != nil {
+			tests.Failed("Should have successfully created session: %+q.", err)
+		}
+		tests.Passed("Should have successfully created session.")
+
+		refresh, err := sessions.IssueRefresh(current)
+		if err != nil {
+			tests.Failed("Should have successfully issued refresh token: %+q.", err)
+		}
+		tests.Passed("Should have successfully issued refresh token.")
+
+		t.Log("\tWhen exchanging a refresh token concurrently")
+		{
+			const attempts = 8
+
+			errs := make(chan error, attempts)
+
+			var wg sync.WaitGroup
+			for i := 0; i < attempts; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					_, _, err := sessions.Refresh(refresh.EncodedToken())
+					errs <- err
+				}()
+			}
+
+			wg.Wait()
+			close(errs)
+
+			var exchanged int
+			for err := range errs {
+				if err == nil {
+					exchanged++
+					continue
+				}
+
+				if !errors.Is(err, handlers.ErrInvalidCredentials) {
+					tests.Failed("Should have rejected concurrent refresh as reuse: %+q.", err)
+				}
+			}
+			tests.Passed("Should have rejected concurrent refreshes as reuse.")
+
+			if exchanged != 1 {
+				tests.Failed("Should have exchanged refresh token once, got %d.", exchanged)
+			}
+			tests.Passed("Should have exchanged refresh token once.")
+
+			if _, err := sessions.Get(current.PublicID); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have revoked session of reused refresh token: %+q.", err)
+			}
+			tests.Passed("Should have revoked session of reused refresh token.")
+		}
+	}
+
+	t.Logf("Given the need to extend sessions on activity")
+	{
+		auth.Sessions.Sliding = true
+
+		current, err := sessions.Create(nu, session.Device{Label: "phone"})
+		if err != nil {
+			tests.Failed("Should have successfully created session: %+q.", err)
+		}
+		tests.Passed("Should have successfully created session.")
+
+		current.LastSeen = time.Now().Add(-2 * handlers.LastSeenInterval)
+		current.Expires = time.Now().Add(time.Minute)
+
+		if err := store.Update(sessionsTable, current, "public_id"); err != nil {
+			tests.Failed("Should have successfully aged session: %+q.", err)
+		}
+		tests.Passed("Should have successfully aged session.")
+
+		t.Log("\tWhen authorizing with a sliding session")
+		{
+			if err := auth.CheckAuthorization("Bearer " + current.SessionToken()); err != nil {
+				tests.Failed("Should have successfully authorized session: %+q.", err)
+			}
+			tests.Passed("Should have successfully authorized session.")
+
+			extended, err := sessions.Get(current.PublicID)
+			if err != nil {
+				tests.Failed("Should have successfully retrieved session: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved session.")
+
+			if time.Until(extended.Expires) < 50*time.Minute {
+				tests.Info("Expires: %s", extended.Expires)
+				tests.Failed("Should have extended session expiry by the session expiration.")
+			}
+			tests.Passed("Should have extended session expiry by the session expiration.")
+		}
+	}
+}
+
+// legacyRecord defines a record stored as is, as sessions and refresh tokens were stored
+// before tokens were hashed.
+type legacyRecord map[string]interface{}
+
+// Fields returns the record.
+func (l legacyRecord) Fields() map[string]interface{} {
+	return l
+}
+
+// TestSessionsLegacyTokens validates that sessions and refresh tokens created before tokens
+// were hashed remain valid and are upgraded to hashed tokens against a memory store.
+func TestSessionsLegacyTokens(t *testing.T) {
+	store := memory.New()
+	refreshTable := db.TableName{Name: "refresh_tokens"}
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	sessions := handlers.RefreshSessionsFactory(log, store, sessionSecret, time.Hour, sessionsTable, refreshTable, 24*time.Hour)
+
+	nu, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	for _, id := range []string{"laptop", "phone", "desktop"} {
+		if err := store.Save(sessionsTable, legacyRecord{"user_id": nu.PublicID, "public_id": id, "token": id + "-token", "expires": expires}); err != nil {
+			tests.Failed("Should have successfully saved legacy session: %+q.", err)
+		}
+	}
+
+	if err := store.Save(refreshTable, legacyRecord{"public_id": "refresh", "session_id": "phone", "user_id": nu.PublicID, "token": "refresh-token", "expires": expires}); err != nil {
+		tests.Failed("Should have successfully saved legacy refresh token: %+q.", err)
+	}
+	tests.Passed("Should have successfully saved legacy records.")
+
+	t.Logf("Given the need to keep sessions created before tokens were hashed")
+	{
+		t.Log("\tWhen authorizing with the token of a legacy session")
+		{
+			authorization := "Bearer " + session.Session{UserID: nu.PublicID, PublicID: "laptop", Token: "laptop-token"}.SessionToken()
+
+			current, err := sessions.Authorize(authorization)
+			if err != nil {
+				tests.Failed("Should have successfully authorized legacy session: %+q.", err)
+			}
+			tests.Passed("Should have successfully authorized legacy session.")
+
+			if current.Legacy() || current.TokenHash != session.HashToken(sessionSecret, "laptop-token") {
+				tests.Failed("Should have upgraded legacy session to the hash of it's token.")
+			}
+			tests.Passed("Should have upgraded legacy session to the hash of it's token.")
+
+			stored, err := sessions.Get("laptop")
+			if err != nil || stored.Legacy() {
+				tests.Failed("Should have stored upgraded legacy session: %+q.", err)
+			}
+			tests.Passed("Should have stored upgraded legacy session.")
+
+			if _, err := sessions.Authorize(authorization); err != nil {
+				tests.Failed("Should have successfully authorized upgraded session: %+q.", err)
+			}
+			tests.Passed("Should have successfully authorized upgraded session.")
+		}
+
+		t.Log("\tWhen authorizing with a token issued before sessions were per device")
+		{
+			authorization := "Bearer " + base64.StdEncoding.EncodeToString([]byte(nu.PublicID+":desktop-token"))
+
+			current, err := sessions.Authorize(authorization)
+			if err != nil {
+				tests.Failed("Should have successfully authorized legacy token: %+q.", err)
+			}
+			tests.Passed("Should have successfully authorized legacy token.")
+
+			if current.PublicID != "desktop" {
+				tests.Failed("Should have authorized the session of the legacy token, got %q.", current.PublicID)
+			}
+			tests.Passed("Should have authorized the session of the legacy token.")
+
+			stored, err := sessions.Get("desktop")
+			if err != nil || stored.Legacy() {
+				tests.Failed("Should have stored upgraded legacy session: %+q.", err)
+			}
+			tests.Passed("Should have stored upgraded legacy session.")
+
+			if _, err := sessions.Authorize(authorization); err != nil {
+				tests.Failed("Should have successfully authorized legacy token of upgraded session: %+q.", err)
+			}
+			tests.Passed("Should have successfully authorized legacy token of upgraded session.")
+
+			forged := "Bearer " + base64.StdEncoding.EncodeToString([]byte(nu.PublicID+":forged-token"))
+
+			if _, err := sessions.Authorize(forged); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have rejected legacy token matching no session: %+q.", err)
+			}
+			tests.Passed("Should have rejected legacy token matching no session.")
+		}
+
+		t.Log("\tWhen hashing all legacy tokens")
+		{
+			upgraded, err := sessions.HashLegacyTokens()
+			if err != nil {
+				tests.Failed("Should have successfully hashed legacy tokens: %+q.", err)
+			}
+			tests.Passed("Should have successfully hashed legacy tokens.")
+
+			if upgraded != 2 {
+				tests.Failed("Should have hashed the remaining session and refresh token, got %d.", upgraded)
+			}
+			tests.Passed("Should have hashed the remaining session and refresh token.")
+
+			records, err := store.Find(refreshTable, db.Query{})
+			if err != nil || len(records) != 1 || records[0]["token"] != "" {
+				tests.Info("Records: %+v", records)
+				tests.Failed("Should have removed token of legacy refresh token: %+q.", err)
+			}
+			tests.Passed("Should have removed token of legacy refresh token.")
+
+			refresh := session.RefreshToken{SessionID: "phone", PublicID: "refresh", Token: "refresh-token"}
+
+			if _, _, err := sessions.Refresh(refresh.EncodedToken()); err != nil {
+				tests.Failed("Should have successfully exchanged hashed legacy refresh token: %+q.", err)
+			}
+			tests.Passed("Should have successfully exchanged hashed legacy refresh token.")
+		}
+	}
+}
+
+// TestSessionsJWT validates the issue and verification of sessions as signed JWTs, along
+// with the revocation check of their sessions against a memory store.
+func TestSessionsJWT(t *testing.T) {
+	store := memory.New()
+
+	keys, err := jwt.NewKeys("current", jwt.HS256("current", []byte("secret")))
+	if err != nil {
+		tests.Failed("Should have successfully created signing keys: %+q.", err)
+	}
+	tests.Passed("Should have successfully created signing keys.")
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	auth := handlers.BearerAuthFactory(log, store, sessionSecret, usersTable, profilesTable, sessionsTable, time.Hour)
+	auth.Sessions.Tokens = handlers.JWTTokens{Keys: keys, Scopes: []string{"profile"}}
+
+	nu, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	if err := users.SetRoles(user.UpdateUserRoles{PublicID: nu.PublicID, Roles: []string{handlers.RoleAdmin}}); err != nil {
+		tests.Failed("Should have successfully set roles of user: %+q.", err)
+	}
+	tests.Passed("Should have successfully set roles of user.")
+
+	nu.Roles = []string{handlers.RoleAdmin}
+
+	t.Logf("Given the need to authorize sessions with signed tokens")
+	{
+		current, err := auth.Sessions.Create(nu, session.Device{Label: "laptop"})
+		if err != nil {
+			tests.Failed("Should have successfully created session: %+q.", err)
+		}
+		tests.Passed("Should have successfully created session.")
+
+		fields, err := auth.Sessions.TokenFields(current)
+		if err != nil {
+			tests.Failed("Should have successfully issued session token: %+q.", err)
+		}
+		tests.Passed("Should have successfully issued session token.")
+
+		authorization := "Bearer " + fields["token"].(string)
+
+		t.Log("\tWhen authorizing with the signed token")
+		{
+			verified, err := auth.Sessions.Authorize(authorization)
+			if err != nil {
+				tests.Failed("Should have successfully authorized signed token: %+q.", err)
+			}
+			tests.Passed("Should have successfully authorized signed token.")
+
+			if verified.UserID != nu.PublicID || verified.PublicID != current.PublicID || len(verified.Scopes) != 1 {
+				tests.Info("Session: %+v", verified)
+				tests.Failed("Should have matched session of signed token.")
+			}
+			tests.Passed("Should have matched session of signed token.")
+
+			if err := auth.CheckAuthorization("Bearer " + current.SessionToken()); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have rejected opaque token: %+q.", err)
+			}
+			tests.Passed("Should have rejected opaque token.")
+		}
+
+		t.Log("\tWhen retrieving the principal of the signed token")
+		{
+			if err := store.Delete(usersTable, "public_id", nu.PublicID); err != nil {
+				tests.Failed("Should have successfully removed user: %+q.", err)
+			}
+			tests.Passed("Should have successfully removed user.")
+
+			principal, err := auth.Principal(authorization)
+			if err != nil {
+				tests.Failed("Should have built principal without retrieving user: %+q.", err)
+			}
+			tests.Passed("Should have built principal without retrieving user.")
+
+			if principal.User.PublicID != nu.PublicID || len(principal.User.Roles) != 1 || principal.User.Roles[0] != handlers.RoleAdmin {
+				tests.Info("User: %+v", principal.User)
+				tests.Failed("Should have carried roles of user within signed token.")
+			}
+			tests.Passed("Should have carried roles of user within signed token.")
+		}
+
+		t.Log("\tWhen revoking the session of the signed token")
+		{
+			if err := auth.Sessions.Revoke(nu.PublicID, current.PublicID); err != nil {
+				tests.Failed("Should have successfully revoked session: %+q.", err)
+			}
+			tests.Passed("Should have successfully revoked session.")
+
+			if err := auth.CheckAuthorization(authorization); err != nil {
+				tests.Failed("Should have authorized signed token without it's session: %+q.", err)
+			}
+			tests.Passed("Should have authorized signed token without it's session.")
+
+			auth.Sessions.Tokens = handlers.JWTTokens{Keys: keys, CheckRevoked: true}
+
+			if err := auth.CheckAuthorization(authorization); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have rejected signed token of revoked session: %+q.", err)
+			}
+			tests.Passed("Should have rejected signed token of revoked session.")
+		}
+	}
+}
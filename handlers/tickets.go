@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/mail"
+	"github.com/influx6/backoffice/models/ticket"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+)
+
+// tickets issues and redeems the ticket.Tickets mailed to users, shared by Verifications
+// and PasswordResets. Only the last ticket mailed to a user is valid, which is valid once.
+type tickets struct {
+	DB            db.DB
+	Log           sink.Sink
+	Mailer        mail.Mailer
+	Secret        []byte
+	Expiration    time.Duration
+	TableIdentity db.TableIdentity
+	Link          string
+	Message       func(nu *user.User, link string) mail.Message
+
+	// Kind names the tickets within errors and logs, such as "verification".
+	Kind string
+
+	// Subject and Body are the default message mailed, where Body is formatted with the
+	// expiration and the link.
+	Subject string
+	Body    string
+}
+
+// issueCtx mails a new ticket to the user, replacing any ticket mailed before.
+func (t tickets) issueCtx(ctx context.Context, nu *user.User) error {
+	if len(t.Secret) == 0 {
+		t.Log.Emit(sinks.Error(ErrNoSecret).WithFields(sink.Fields{"user_id": nu.PublicID}))
+		return ErrNoSecret
+	}
+
+	newTicket := ticket.New(nu.PublicID, nu.Email, time.Now().Add(t.Expiration), t.Secret)
+
+	// Replace earlier tickets, so only the last mailed is valid.
+	err := db.WithContext(t.DB).WithTxCtx(ctx, func(tx db.DB) error {
+		if err := db.WithContext(tx).DeleteCtx(ctx, t.TableIdentity, ticket.UserIndex, nu.PublicID); err != nil && !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+
+		return db.WithContext(tx).SaveCtx(ctx, t.TableIdentity, newTicket)
+	})
+
+	if err != nil {
+		t.Log.Emit(sinks.Error("Failed to save %s: %+q", t.Kind, err).WithFields(sink.Fields{"user_id": nu.PublicID}))
+		return err
+	}
+
+	if err := t.Mailer.Send(ctx, t.message(nu, newTicket.SignedToken(t.Secret))); err != nil {
+		t.Log.Emit(sinks.Error("Failed to mail %s: %+q", t.Kind, err).WithFields(sink.Fields{"user_id": nu.PublicID}))
+		return err
+	}
+
+	return nil
+}
+
+// redeemCtx calls fn with the user of the signed token mailed by issueCtx, within the
+// transaction removing the user's tickets, returning the user. The token must not have
+// expired, and is valid only while the user's email is the one it was mailed to, else an
+// error matching ErrValidation is returned. The ticket remains valid if fn fails.
+func (t tickets) redeemCtx(ctx context.Context, users Users, token string, fn func(tx db.DB, nu *user.User) error) (*user.User, error) {
+	if len(t.Secret) == 0 {
+		t.Log.Emit(sinks.Error(ErrNoSecret))
+		return nil, ErrNoSecret
+	}
+
+	invalid := Invalid("token", "is invalid or has expired")
+
+	ticketID, secretToken, err := ticket.ParseToken(t.Secret, strings.TrimSpace(token))
+	if err != nil {
+		t.Log.Emit(sinks.Error(err))
+		return nil, invalid
+	}
+
+	users.Profiles = nil
+
+	var nu *user.User
+
+	err = db.WithContext(t.DB).WithTxCtx(ctx, func(tx db.DB) error {
+		txdb := db.WithContext(tx)
+
+		var existing ticket.Ticket
+
+		if err := txdb.GetCtx(ctx, t.TableIdentity, &existing, "public_id", ticketID); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return fmt.Errorf("%s: %w", err, invalid)
+			}
+
+			return err
+		}
+
+		if !existing.ValidateToken(t.Secret, secretToken) || existing.Expired() {
+			return fmt.Errorf("Invalid %s ticket: %w", t.Kind, invalid)
+		}
+
+		var err error
+
+		nu, err = users.withDB(tx).GetCtx(ctx, existing.UserID)
+		if err != nil {
+			return err
+		}
+
+		if nu.Email != existing.Email {
+			return fmt.Errorf("User's email changed since %s was mailed: %w", t.Kind, invalid)
+		}
+
+		if err := fn(tx, nu); err != nil {
+			return err
+		}
+
+		return txdb.DeleteCtx(ctx, t.TableIdentity, ticket.UserIndex, nu.PublicID)
+	})
+
+	if err != nil {
+		fields := sink.Fields{"ticket_id": ticketID}
+		if nu != nil {
+			fields["user_id"] = nu.PublicID
+		}
+
+		t.Log.Emit(sinks.Error(err).WithFields(fields))
+		return nil, err
+	}
+
+	return nu, nil
+}
+
+// message returns the message mailed to the user with the signed token.
+func (t tickets) message(nu *user.User, token string) mail.Message {
+	link := linkOf(t.Link, token)
+
+	if t.Message != nil {
+		msg := t.Message(nu, link)
+		msg.To = nu.Email
+		return msg
+	}
+
+	return mail.Message{
+		To:      nu.Email,
+		Subject: t.Subject,
+		Body:    fmt.Sprintf(t.Body, t.Expiration, link),
+	}
+}
+
+// linkOf returns the link mailed with the signed token, where the token is mailed as is
+// if link is empty.
+func linkOf(link string, token string) string {
+	if link == "" {
+		return token
+	}
+
+	return fmt.Sprintf(link, token)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influx6/backoffice/auth/jwt"
+	"github.com/influx6/backoffice/models/session"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+)
+
+// TokenStrategy defines the format of the tokens a Sessions issues for it's sessions,
+// which are sent as the Bearer token of requests.
+type TokenStrategy interface {
+	// Issue returns the token of the giving new or rotated session.
+	Issue(current *session.Session) (string, error)
+
+	// VerifyCtx returns the session named by the token if the token is valid.
+	VerifyCtx(ctx context.Context, s Sessions, token string) (*session.Session, error)
+
+	// Stateless returns true/false if tokens are verified without retrieving their
+	// session, hence without retrieving the user of the session.
+	Stateless() bool
+}
+
+// OpaqueTokens implements TokenStrategy with the base64 encoded UserID:SessionID:Token of
+// sessions, which are verified against the hash of the token stored for the session.
+type OpaqueTokens struct{}
+
+// Issue returns the SessionToken of the session.
+func (OpaqueTokens) Issue(current *session.Session) (string, error) {
+	if current.Token == "" {
+		return "", errors.New("Session holds no token: only new or rotated sessions are issued tokens")
+	}
+
+	return current.SessionToken(), nil
+}
+
+// VerifyCtx returns the unexpired session of the token, recording it's use.
+func (OpaqueTokens) VerifyCtx(ctx context.Context, s Sessions, token string) (*session.Session, error) {
+	return s.verifyOpaque(ctx, token)
+}
+
+// Stateless returns false as opaque tokens are verified against their session.
+func (OpaqueTokens) Stateless() bool {
+	return false
+}
+
+// JWTTokens implements TokenStrategy with JWTs signed by Keys, which carry the user id,
+// session id, expiry and Scopes of their session along with the Roles of it's user, and
+// are verified without retrieving either. Changes to the roles of a user apply to it's
+// tokens once they are refreshed.
+// As such, a revoked session's tokens remain valid until they expire unless CheckRevoked
+// is set, where tokens are only valid while their session is within the sessions table.
+// The last seen time and sliding expiry of sessions are not recorded with JWTs.
+type JWTTokens struct {
+	Keys         *jwt.Keys
+	Scopes       []string
+	CheckRevoked bool
+}
+
+// Issue returns a new JWT of the session which expires along with the session.
+func (t JWTTokens) Issue(current *session.Session) (string, error) {
+	return t.Keys.Sign(jwt.Claims{
+		Subject:  current.UserID,
+		Session:  current.PublicID,
+		Roles:    current.Roles,
+		Scopes:   t.Scopes,
+		IssuedAt: time.Now().Unix(),
+		Expires:  current.Expires.Unix(),
+	})
+}
+
+// VerifyCtx returns the session of the claims of the JWT, which is only retrieved to check
+// that it was not revoked if CheckRevoked is set.
+func (t JWTTokens) VerifyCtx(ctx context.Context, s Sessions, token string) (*session.Session, error) {
+	claims, err := t.Keys.Verify(token)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err))
+		return nil, fmt.Errorf("%s: %w", err, ErrInvalidCredentials)
+	}
+
+	if t.CheckRevoked {
+		current, err := s.GetCtx(ctx, claims.Session)
+		if err != nil {
+			return nil, credentialsErr(err)
+		}
+
+		if current.UserID != claims.Subject {
+			err := fmt.Errorf("Invalid user session's token: %w", ErrInvalidCredentials)
+			s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"session_id": claims.Session}))
+			return nil, err
+		}
+	}
+
+	return &session.Session{
+		UserID:   claims.Subject,
+		PublicID: claims.Session,
+		Expires:  claims.ExpiresAt(),
+		Scopes:   claims.Scopes,
+		Roles:    claims.Roles,
+	}, nil
+}
+
+// Stateless returns true as JWTs are verified without retrieving their session.
+func (JWTTokens) Stateless() bool {
+	return true
+}
+
+// tokens returns the TokenStrategy of the Sessions.
+func (s Sessions) tokens() TokenStrategy {
+	if s.Tokens == nil {
+		return OpaqueTokens{}
+	}
+
+	return s.Tokens
+}
+
+// Stateless returns true/false if the tokens of the Sessions are verified without
+// retrieving their session.
+func (s Sessions) Stateless() bool {
+	return s.tokens().Stateless()
+}
+
+// TokenFields returns the SessionFields of the giving new or rotated session, holding the
+// token issued for it by the TokenStrategy of the Sessions.
+func (s Sessions) TokenFields(current *session.Session) (map[string]interface{}, error) {
+	token, err := s.tokens().Issue(current)
+	if err != nil {
+		s.Log.Emit(sinks.Error("Failed to issue session token: %+q", err).WithFields(sink.Fields{"session_id": current.PublicID}))
+		return nil, err
+	}
+
+	fields := current.SessionFields()
+	fields["token"] = token
+
+	return fields, nil
+}
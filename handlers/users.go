@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/influx6/backoffice/db"
 	"github.com/influx6/backoffice/models/user"
@@ -51,25 +54,76 @@ type Users struct {
 	Log           sink.Sink
 	Profiles      *Profiles
 	TableIdentity db.TableIdentity
+
+	// Resets enables RequestPasswordReset and ResetPassword if set.
+	Resets *PasswordResets
+
+	// PasswordPolicy is enforced on new passwords, which defaults to the
+	// user.DefaultPasswordPolicy if it is the zero value.
+	PasswordPolicy user.PasswordPolicy
 }
 
-// Delete handles receiving requests to delete a user from the database.
+// passwordPolicy returns the PasswordPolicy of the Users, or the user.DefaultPasswordPolicy
+// if it is the zero value.
+func (u Users) passwordPolicy() user.PasswordPolicy {
+	if u.PasswordPolicy == (user.PasswordPolicy{}) {
+		return user.DefaultPasswordPolicy
+	}
+
+	return u.PasswordPolicy
+}
+
+// withDB returns a copy of the Users and it's Profiles which use the provided db.DB.
+func (u Users) withDB(d db.DB) Users {
+	u.DB = d
+
+	if u.Profiles != nil {
+		profiles := *u.Profiles
+		profiles.DB = d
+		u.Profiles = &profiles
+	}
+
+	return u
+}
+
+// Delete handles receiving requests to delete a user from the database. If the context
+// carries a Principal, it must be granted users:delete over the user.
 func (u Users) Delete(id string) error {
+	return u.DeleteCtx(context.Background(), id)
+}
+
+// DeleteCtx is the same as Delete but uses the provided context for all db operations.
+func (u Users) DeleteCtx(ctx context.Context, id string) error {
 	defer u.Log.Emit(sinks.Info("Get Existing User").With("user_id", id).Trace("handlers.Users.Create").End())
 
-	if err := u.DB.Delete(u.TableIdentity, "public_id", id); err != nil {
+	if err := authorize(ctx, "users:delete:self", id); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"public_id": id}))
 		return err
 	}
 
-	var err error
+	// Delete user and user profile together, so neither is left behind on failure.
+	err := db.WithContext(u.DB).WithTxCtx(ctx, func(tx db.DB) error {
+		txu := u.withDB(tx)
 
-	// Delete user profile.
-	if u.Profiles != nil {
-		if err = u.Profiles.DeleteByUser(id); err != nil {
-			u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"public_id": id}))
+		if err := db.WithContext(tx).DeleteCtx(ctx, txu.TableIdentity, "public_id", id); err != nil {
+			return err
+		}
+
+		if txu.Profiles == nil {
+			return nil
+		}
+
+		// A user may have had it's profile removed already.
+		if err := txu.Profiles.DeleteByUserCtx(ctx, id); err != nil && !errors.Is(err, db.ErrNotFound) {
 			return err
 		}
+
+		return nil
+	})
+
+	if err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"public_id": id}))
+		return err
 	}
 
 	return nil
@@ -77,11 +131,16 @@ func (u Users) Delete(id string) error {
 
 // Get handles receiving requests to retrieve a user from the database.
 func (u Users) Get(id string) (*user.User, error) {
+	return u.GetCtx(context.Background(), id)
+}
+
+// GetCtx is the same as Get but uses the provided context for all db operations.
+func (u Users) GetCtx(ctx context.Context, id string) (*user.User, error) {
 	defer u.Log.Emit(sinks.Info("Get Existing User").With("user_id", id).Trace("handlers.Users.Create").End())
 
 	var nu user.User
 
-	if err := u.DB.Get(u.TableIdentity, &nu, "public_id", id); err != nil {
+	if err := db.WithContext(u.DB).GetCtx(ctx, u.TableIdentity, &nu, "public_id", id); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"public_id": id}))
 		return nil, err
 	}
@@ -90,7 +149,7 @@ func (u Users) Get(id string) (*user.User, error) {
 	if u.Profiles != nil {
 		var err error
 
-		nu.Profile, err = u.Profiles.GetByUser(nu.PublicID)
+		nu.Profile, err = u.Profiles.GetByUserCtx(ctx, nu.PublicID)
 		if err != nil {
 			u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"public_id": id}))
 			return nil, err
@@ -100,22 +159,34 @@ func (u Users) Get(id string) (*user.User, error) {
 	return &nu, nil
 }
 
-// GetByEmail handles receiving requests to retrieve a user with user's email from the database.
+// GetByEmail handles receiving requests to retrieve a user with user's email from the database,
+// where the email is normalized with user.NormalizeEmail so it matches regardless of case.
 func (u Users) GetByEmail(email string) (*user.User, error) {
+	return u.GetByEmailCtx(context.Background(), email)
+}
+
+// GetByEmailCtx is the same as GetByEmail but uses the provided context for all db operations.
+func (u Users) GetByEmailCtx(ctx context.Context, email string) (*user.User, error) {
 	defer u.Log.Emit(sinks.Info("Get Existing User").With("user_email", email).Trace("handlers.Users.Create").End())
 
+	normalized, err := user.NormalizeEmail(email)
+	if err != nil {
+		// No user is stored with an invalid email.
+		err = fmt.Errorf("%s: %w", err, db.ErrNotFound)
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": email}))
+		return nil, err
+	}
+
 	var nu user.User
 
-	if err := u.DB.Get(u.TableIdentity, &nu, "email", email); err != nil {
+	if err := db.WithContext(u.DB).GetCtx(ctx, u.TableIdentity, &nu, "email", normalized); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": email}))
 		return nil, err
 	}
 
-	var err error
-
 	// Get user profile.
 	if u.Profiles != nil {
-		nu.Profile, err = u.Profiles.GetByUser(nu.PublicID)
+		nu.Profile, err = u.Profiles.GetByUserCtx(ctx, nu.PublicID)
 		if err != nil {
 			u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": email}))
 			return nil, err
@@ -125,23 +196,67 @@ func (u Users) GetByEmail(email string) (*user.User, error) {
 	return &nu, nil
 }
 
+// Authenticate returns the user with the giving email if the password matches, else
+// returning an error matching ErrInvalidCredentials. The hash of the user is replaced if
+// it was not produced by user.Hasher with it's current parameters.
+func (u Users) Authenticate(email string, password string) (*user.User, error) {
+	return u.AuthenticateCtx(context.Background(), email, password)
+}
+
+// AuthenticateCtx is the same as Authenticate but uses the provided context for all db operations.
+func (u Users) AuthenticateCtx(ctx context.Context, email string, password string) (*user.User, error) {
+	defer u.Log.Emit(sinks.Info("Authenticate User").With("user_email", email).Trace("handlers.Users.Authenticate").End())
+
+	nu, err := u.GetByEmailCtx(ctx, email)
+	if err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": email}))
+		return nil, credentialsErr(err)
+	}
+
+	if err := nu.Authenticate(password); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": email}))
+		return nil, fmt.Errorf("%s: %w", err, ErrInvalidCredentials)
+	}
+
+	// Hashes of an outdated hasher or cost are replaced while the password is at hand,
+	// where failing to do so does not fail the login.
+	rehashed, err := nu.Rehash(password)
+	if err == nil && rehashed {
+		err = db.WithContext(u.DB).UpdateCtx(ctx, u.TableIdentity, user.UpdateUserHash{PublicID: nu.PublicID, Hash: nu.Hash}, "public_id")
+	}
+
+	if err != nil {
+		u.Log.Emit(sinks.Error("Failed to rehash user password: %+q", err).WithFields(sink.Fields{"user_id": nu.PublicID}))
+	}
+
+	return nu, nil
+}
+
 // UserRecords defines a struct which returns the total fields and page details
-// used in retrieving the records.
+// used in retrieving the records. Next and Prev are the cursors of the following and
+// preceding pages of records retrieved with GetPage.
 type UserRecords struct {
 	Total           int         `json:"total"`
 	Page            int         `json:"page"`
 	ResponsePerPage int         `json:"responsePerPage"`
 	Records         []user.User `json:"records"`
+	Next            string      `json:"next,omitempty"`
+	Prev            string      `json:"prev,omitempty"`
 }
 
 // GetAll handles receiving requests to retrieve all user from the database.
 func (u Users) GetAll(page, responsePerPage int) (UserRecords, error) {
+	return u.GetAllCtx(context.Background(), page, responsePerPage)
+}
+
+// GetAllCtx is the same as GetAll but uses the provided context for all db operations.
+func (u Users) GetAllCtx(ctx context.Context, page, responsePerPage int) (UserRecords, error) {
 	defer u.Log.Emit(sinks.Info("Get Existing User").WithFields(sink.Fields{
 		"page":            page,
 		"responsePerPage": responsePerPage,
 	}).Trace("handlers.Users.Create").End())
 
-	records, realTotalRecords, err := u.DB.GetAllPerPage(u.TableIdentity, "asc", "public_id", page, responsePerPage)
+	records, realTotalRecords, err := db.WithContext(u.DB).GetAllPerPageCtx(ctx, u.TableIdentity, "asc", "public_id", page, responsePerPage)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"page":            page,
@@ -175,39 +290,208 @@ func (u Users) GetAll(page, responsePerPage int) (UserRecords, error) {
 	}, nil
 }
 
-// Create handles receiving requests to create a user from the server.
+// Find handles receiving requests to retrieve the users matching the giving query, where
+// Total is the number of users matching the query regardless of it's limit and offset.
+// Users are ordered by public_id if the query has no order and all fields are retrieved.
+func (u Users) Find(query db.Query) (UserRecords, error) {
+	return u.FindCtx(context.Background(), query)
+}
+
+// FindCtx is the same as Find but uses the provided context for all db operations.
+func (u Users) FindCtx(ctx context.Context, query db.Query) (UserRecords, error) {
+	defer u.Log.Emit(sinks.Info("Find Users").With("query", query).Trace("handlers.Users.Find").End())
+
+	if len(query.Order) == 0 {
+		query.Order = []db.Order{{Field: "public_id"}}
+	}
+
+	// Users require all their fields to be retrieved.
+	query.Fields = nil
+
+	records, err := db.WithContext(u.DB).FindCtx(ctx, u.TableIdentity, query)
+	if err != nil {
+		u.Log.Emit(sinks.Error(err).With("query", query))
+		return UserRecords{}, err
+	}
+
+	total, err := u.matching(ctx, query.Where)
+	if err != nil {
+		u.Log.Emit(sinks.Error(err).With("query", query))
+		return UserRecords{}, err
+	}
+
+	userRecords := make([]user.User, 0, len(records))
+
+	for _, record := range records {
+		var nw user.User
+
+		if err := nw.WithFields(record); err != nil {
+			u.Log.Emit(sinks.Error(err).With("query", query))
+			return UserRecords{}, err
+		}
+
+		userRecords = append(userRecords, nw)
+	}
+
+	page := 1
+	if query.Limit > 0 {
+		page = query.Offset/query.Limit + 1
+	}
+
+	return UserRecords{
+		Page:            page,
+		Total:           total,
+		ResponsePerPage: query.Limit,
+		Records:         userRecords,
+	}, nil
+}
+
+// GetPage handles receiving requests to retrieve a page of at most limit users matching
+// the predicates, which are ordered by their creation. The page starts after, or before,
+// the position of the cursor, which is one of the Next and Prev cursors of a previous
+// page, or from the first user if empty.
+func (u Users) GetPage(cursor string, limit int, where ...db.Predicate) (UserRecords, error) {
+	return u.GetPageCtx(context.Background(), cursor, limit, where...)
+}
+
+// GetPageCtx is the same as GetPage but uses the provided context for all db operations.
+func (u Users) GetPageCtx(ctx context.Context, cursor string, limit int, where ...db.Predicate) (UserRecords, error) {
+	defer u.Log.Emit(sinks.Info("Get Page of Users").WithFields(sink.Fields{
+		"cursor": cursor,
+		"limit":  limit,
+	}).Trace("handlers.Users.GetPage").End())
+
+	page, err := db.FindPage(ctx, u.DB, u.TableIdentity, where, cursor, limit)
+	if err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+		return UserRecords{}, err
+	}
+
+	total, err := u.matching(ctx, where)
+	if err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+		return UserRecords{}, err
+	}
+
+	userRecords := make([]user.User, 0, len(page.Records))
+
+	for _, record := range page.Records {
+		var nw user.User
+
+		if err := nw.WithFields(record); err != nil {
+			u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"cursor": cursor, "limit": limit}))
+			return UserRecords{}, err
+		}
+
+		userRecords = append(userRecords, nw)
+	}
+
+	return UserRecords{
+		Total:           total,
+		ResponsePerPage: limit,
+		Records:         userRecords,
+		Next:            page.Next,
+		Prev:            page.Prev,
+	}, nil
+}
+
+// matching returns the number of users matching the predicates.
+func (u Users) matching(ctx context.Context, where []db.Predicate) (int, error) {
+	if len(where) == 0 {
+		return db.WithContext(u.DB).CountCtx(ctx, u.TableIdentity)
+	}
+
+	return db.WithContext(u.DB).CountWhereCtx(ctx, u.TableIdentity, where)
+}
+
+// Create handles receiving requests to create a user from the server. It returns an error
+// matching db.ErrConflict if a user with the same normalized email exists, and one matching
+// ErrValidation listing the violations if the password does not meet the PasswordPolicy.
 func (u Users) Create(nw user.NewUser) (*user.User, error) {
+	return u.CreateCtx(context.Background(), nw)
+}
+
+// CreateCtx is the same as Create but uses the provided context for all db operations.
+func (u Users) CreateCtx(ctx context.Context, nw user.NewUser) (*user.User, error) {
 	defer u.Log.Emit(sinks.Info("Create New User").Trace("handlers.Users.Create").End())
 
-	newUser, err := user.New(nw)
-	if err != nil {
+	if nw.Email == "" || nw.Password == "" {
+		err := ValidationError{Fields: make(map[string]string)}
+
+		if nw.Email == "" {
+			err.Fields["email"] = "is required"
+		}
+
+		if nw.Password == "" {
+			err.Fields["password"] = "is required"
+		}
+
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"email": nw.Email}))
 		return nil, err
 	}
 
-	if err := u.DB.Save(u.TableIdentity, newUser); err != nil {
+	newUser, err := user.NewWithPolicy(nw, u.passwordPolicy())
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidEmail) {
+			err = Invalid("email", "must be a valid email address")
+		}
+
+		err = passwordErr(err)
+
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"email": nw.Email}))
 		return nil, err
 	}
 
-	// Add user profile.
-	if u.Profiles != nil {
-		newUser.Profile, err = u.Profiles.Create(newUser, nil)
+	// Add user and user profile together, so neither is saved without the other.
+	err = db.WithContext(u.DB).WithTxCtx(ctx, func(tx db.DB) error {
+		txu := u.withDB(tx)
+
+		// The unique index of the email also guards against concurrent creation, where
+		// the check reports the conflict for stores without one.
+		if err := txu.emailTaken(ctx, newUser.Email, ""); err != nil {
+			return err
+		}
+
+		if err := db.WithContext(tx).SaveCtx(ctx, txu.TableIdentity, newUser); err != nil {
+			return err
+		}
+
+		if txu.Profiles == nil {
+			return nil
+		}
+
+		userProfile, err := txu.Profiles.CreateCtx(ctx, newUser, nil)
 		if err != nil {
-			u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"email": nw.Email}))
-			return nil, err
+			return err
 		}
+
+		newUser.Profile = userProfile
+
+		return nil
+	})
+
+	if err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"email": nw.Email}))
+		return nil, err
 	}
 
 	return newUser, nil
 }
 
 // UpdatePassword handles receiving requests to update a user identified by it's public_id.
+// If the context carries a Principal, it must be granted users:update over the user, where
+// a user changing it's own password must provide it's current password. The new password
+// must meet the PasswordPolicy.
 func (u Users) UpdatePassword(nw user.UpdateUserPassword) error {
+	return u.UpdatePasswordCtx(context.Background(), nw)
+}
+
+// UpdatePasswordCtx is the same as UpdatePassword but uses the provided context for all db operations.
+func (u Users) UpdatePasswordCtx(ctx context.Context, nw user.UpdateUserPassword) error {
 	defer u.Log.Emit(sinks.Info("Update User Password").With("user", nw.PublicID).Trace("handlers.Users.UpdatePassword").End())
 
 	if nw.PublicID == "" {
-		err := errors.New("JSON UpdateUserPassword.PublicID is empty")
+		err := Invalid("public_id", "is required")
 
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"user_id": nw.PublicID,
@@ -216,9 +500,9 @@ func (u Users) UpdatePassword(nw user.UpdateUserPassword) error {
 		return err
 	}
 
-	// TODO(influx6): Should we do some password validty checks.
+	// The password is checked against the PasswordPolicy when changed.
 	if nw.Password == "" {
-		err := errors.New("JSON UpdateUserPassword.Password is empty")
+		err := Invalid("password", "is required")
 
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"user_id": nw.PublicID,
@@ -227,18 +511,17 @@ func (u Users) UpdatePassword(nw user.UpdateUserPassword) error {
 		return err
 	}
 
-	// TODO(influx6): Do we need to do this here.
-	// if nw.Password != nw.PasswordConfirm {
-	// 	err := errors.New("Invalid Confirmation Password")
-	// 	u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-	//		"user_id":   nw.PublicID,
-	// 	}))
-	// 	return
-	// }
+	if err := authorize(ctx, "users:update:self", nw.PublicID); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id": nw.PublicID,
+		}))
+
+		return err
+	}
 
 	var dbUser user.User
 
-	if err := u.DB.Get(u.TableIdentity, &dbUser, "public_id", nw.PublicID); err != nil {
+	if err := db.WithContext(u.DB).GetCtx(ctx, u.TableIdentity, &dbUser, "public_id", nw.PublicID); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"user_id": nw.PublicID,
 		}))
@@ -246,7 +529,29 @@ func (u Users) UpdatePassword(nw user.UpdateUserPassword) error {
 		return err
 	}
 
-	if err := dbUser.ChangePassword(nw.Password); err != nil {
+	// Users changing their own password must prove they know it, so a stolen session can
+	// not be used to take over the user.
+	if principal, ok := PrincipalFrom(ctx); ok && principal.User.PublicID == nw.PublicID {
+		var err error
+
+		switch {
+		case nw.CurrentPassword == "":
+			err = Invalid("current_password", "is required")
+		case dbUser.Authenticate(nw.CurrentPassword) != nil:
+			err = Invalid("current_password", "does not match")
+		}
+
+		if err != nil {
+			u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+				"user_id": nw.PublicID,
+			}))
+
+			return err
+		}
+	}
+
+	if err := dbUser.ChangePasswordWithPolicy(nw.Password, u.passwordPolicy()); err != nil {
+		err = passwordErr(err)
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"user_id": nw.PublicID,
 		}))
@@ -254,7 +559,9 @@ func (u Users) UpdatePassword(nw user.UpdateUserPassword) error {
 		return err
 	}
 
-	if err := u.DB.Update(u.TableIdentity, &dbUser, "public_id"); err != nil {
+	// Only the hash is written, so updates of the user's other fields made since it was
+	// retrieved are kept.
+	if err := db.WithContext(u.DB).UpdateCtx(ctx, u.TableIdentity, user.UpdateUserHash{PublicID: dbUser.PublicID, Hash: dbUser.Hash}, "public_id"); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"user_id": nw.PublicID,
 		}))
@@ -265,12 +572,20 @@ func (u Users) UpdatePassword(nw user.UpdateUserPassword) error {
 	return nil
 }
 
-// Update handles receiving requests to update a user identified by it's public_id.
+// Update handles receiving requests to update a user identified by it's public_id. If the
+// context carries a Principal, it must be granted users:update over the user. The email is
+// normalized with user.NormalizeEmail, returning an error matching db.ErrConflict if
+// another user holds it. A user whoes email changes is no longer verified.
 func (u Users) Update(nw user.UpdateUser) error {
+	return u.UpdateCtx(context.Background(), nw)
+}
+
+// UpdateCtx is the same as Update but uses the provided context for all db operations.
+func (u Users) UpdateCtx(ctx context.Context, nw user.UpdateUser) error {
 	defer u.Log.Emit(sinks.Info("Update User").With("user", nw.PublicID).Trace("handlers.Users.Update").End())
 
 	if nw.PublicID == "" {
-		err := errors.New("JSON User.PublicID is empty")
+		err := Invalid("public_id", "is required")
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"user_id": nw.PublicID,
 			"email":   nw.Email,
@@ -279,7 +594,53 @@ func (u Users) Update(nw user.UpdateUser) error {
 		return err
 	}
 
-	if err := u.DB.Update(u.TableIdentity, nw, "public_id"); err != nil {
+	if err := authorize(ctx, "users:update:self", nw.PublicID); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id": nw.PublicID,
+			"email":   nw.Email,
+		}))
+
+		return err
+	}
+
+	email, err := user.NormalizeEmail(nw.Email)
+	if err != nil {
+		err := Invalid("email", "must be a valid email address")
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id": nw.PublicID,
+			"email":   nw.Email,
+		}))
+
+		return err
+	}
+
+	nw.Email = email
+
+	err = db.WithContext(u.DB).WithTxCtx(ctx, func(tx db.DB) error {
+		txdb := db.WithContext(tx)
+
+		var existing user.User
+
+		if err := txdb.GetCtx(ctx, u.TableIdentity, &existing, "public_id", nw.PublicID); err != nil {
+			return err
+		}
+
+		if existing.Email == nw.Email {
+			return nil
+		}
+
+		if err := u.withDB(tx).emailTaken(ctx, nw.Email, nw.PublicID); err != nil {
+			return err
+		}
+
+		if err := txdb.UpdateCtx(ctx, u.TableIdentity, nw, "public_id"); err != nil {
+			return err
+		}
+
+		return txdb.UpdateCtx(ctx, u.TableIdentity, user.UpdateUserVerified{PublicID: nw.PublicID}, "public_id")
+	})
+
+	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"user_id": nw.PublicID,
 			"email":   nw.Email,
@@ -290,3 +651,115 @@ func (u Users) Update(nw user.UpdateUser) error {
 
 	return nil
 }
+
+// SetRoles handles receiving requests to replace the roles of a user identified by it's
+// public_id, which grant it permissions through the Policy of a BearerAuth. If the context
+// carries a Principal, it must be granted users:roles:any, as users may not grant
+// themselves roles.
+func (u Users) SetRoles(nw user.UpdateUserRoles) error {
+	return u.SetRolesCtx(context.Background(), nw)
+}
+
+// SetRolesCtx is the same as SetRoles but uses the provided context for all db operations.
+func (u Users) SetRolesCtx(ctx context.Context, nw user.UpdateUserRoles) error {
+	defer u.Log.Emit(sinks.Info("Set User Roles").With("user", nw.PublicID).Trace("handlers.Users.SetRoles").End())
+
+	if nw.PublicID == "" {
+		err := Invalid("public_id", "is required")
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id": nw.PublicID,
+		}))
+
+		return err
+	}
+
+	if err := authorize(ctx, "users:roles:any", ""); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id": nw.PublicID,
+		}))
+
+		return err
+	}
+
+	for _, role := range nw.Roles {
+		if role == "" || strings.ContainsAny(role, ", ") {
+			err := Invalid("roles", "must be names without commas or spaces")
+			u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+				"user_id": nw.PublicID,
+				"roles":   nw.Roles,
+			}))
+
+			return err
+		}
+	}
+
+	if err := db.WithContext(u.DB).UpdateCtx(ctx, u.TableIdentity, nw, "public_id"); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id": nw.PublicID,
+			"roles":   nw.Roles,
+		}))
+
+		return err
+	}
+
+	return nil
+}
+
+// SetVerified handles receiving requests to mark the email of a user identified by it's
+// public_id as verified or not, without a ticket mailed by Verifications, such as for
+// users created before emails were verified. If the context carries a Principal, it must
+// be granted users:verify:any.
+func (u Users) SetVerified(nw user.UpdateUserVerified) error {
+	return u.SetVerifiedCtx(context.Background(), nw)
+}
+
+// SetVerifiedCtx is the same as SetVerified but uses the provided context for all db operations.
+func (u Users) SetVerifiedCtx(ctx context.Context, nw user.UpdateUserVerified) error {
+	defer u.Log.Emit(sinks.Info("Set User Verified").With("user", nw.PublicID).Trace("handlers.Users.SetVerified").End())
+
+	if nw.PublicID == "" {
+		err := Invalid("public_id", "is required")
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id": nw.PublicID,
+		}))
+
+		return err
+	}
+
+	if err := authorize(ctx, "users:verify:any", ""); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id": nw.PublicID,
+		}))
+
+		return err
+	}
+
+	if err := db.WithContext(u.DB).UpdateCtx(ctx, u.TableIdentity, nw, "public_id"); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"user_id":  nw.PublicID,
+			"verified": nw.Verified,
+		}))
+
+		return err
+	}
+
+	return nil
+}
+
+// emailTaken returns an error matching db.ErrConflict if a user other than the one with
+// the giving public id holds the normalized email.
+func (u Users) emailTaken(ctx context.Context, email string, publicID string) error {
+	var existing user.User
+
+	err := db.WithContext(u.DB).GetCtx(ctx, u.TableIdentity, &existing, "email", email)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case existing.PublicID == publicID:
+		return nil
+	}
+
+	return fmt.Errorf("User with email %q already exists: %w", email, db.ErrConflict)
+}
@@ -0,0 +1,292 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/memory"
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+	"github.com/influx6/faux/tests"
+)
+
+var (
+	log = sink.New(sinks.Stdout{})
+
+	usersTable    = db.TableName{Name: "users"}
+	profilesTable = db.TableName{Name: "profiles"}
+)
+
+// TestUsers validates the operations of the Users handler against a memory store.
+func TestUsers(t *testing.T) {
+	users := handlers.UsersFactory(log, memory.New(), usersTable, profilesTable)
+
+	nu, err := users.Create(user.NewUser{
+		Email:    "bob@guma.com",
+		Password: "Glow-Worm-Lantern-42",
+	})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	if nu.Profile == nil {
+		tests.Failed("Should have successfully created profile for new user.")
+	}
+	tests.Passed("Should have successfully created profile for new user.")
+
+	existing, err := users.GetByEmail("bob@guma.com")
+	if err != nil {
+		tests.Failed("Should have successfully retrieved user by email: %+q.", err)
+	}
+	tests.Passed("Should have successfully retrieved user by email.")
+
+	if existing.PublicID != nu.PublicID {
+		tests.Failed("Should have retrieved the same user by email.")
+	}
+	tests.Passed("Should have retrieved the same user by email.")
+
+	if err := users.UpdatePassword(user.UpdateUserPassword{PublicID: nu.PublicID, Password: "Grow-Fern-Garden-58"}); err != nil {
+		tests.Failed("Should have successfully updated user password: %+q.", err)
+	}
+	tests.Passed("Should have successfully updated user password.")
+
+	updated, err := users.Get(nu.PublicID)
+	if err != nil {
+		tests.Failed("Should have successfully retrieved user: %+q.", err)
+	}
+	tests.Passed("Should have successfully retrieved user.")
+
+	if err := updated.Authenticate("Grow-Fern-Garden-58"); err != nil {
+		tests.Failed("Should have successfully authenticated with new password: %+q.", err)
+	}
+	tests.Passed("Should have successfully authenticated with new password.")
+
+	records, err := users.GetAll(0, 0)
+	if err != nil {
+		tests.Failed("Should have successfully retrieved all users: %+q.", err)
+	}
+	tests.Passed("Should have successfully retrieved all users.")
+
+	if records.Total != 1 {
+		tests.Failed("Should have retrieved a total of 1 user.")
+	}
+	tests.Passed("Should have retrieved a total of 1 user.")
+
+	if err := users.Delete(nu.PublicID); err != nil {
+		tests.Failed("Should have successfully deleted user: %+q.", err)
+	}
+	tests.Passed("Should have successfully deleted user.")
+
+	if _, err := users.Get(nu.PublicID); err == nil {
+		tests.Failed("Should have failed to retrieve deleted user.")
+	}
+	tests.Passed("Should have failed to retrieve deleted user.")
+}
+
+// TestUsersCancelledContext validates that the Users handler stops on a cancelled context.
+func TestUsersCancelledContext(t *testing.T) {
+	users := handlers.UsersFactory(log, memory.New(), usersTable, profilesTable)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := users.CreateCtx(ctx, user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"}); err != context.Canceled {
+		tests.Failed("Should have failed to create user with cancelled context: %+q.", err)
+	}
+	tests.Passed("Should have failed to create user with cancelled context.")
+
+	records, err := users.GetAll(0, 0)
+	if err != nil {
+		tests.Failed("Should have successfully retrieved all users: %+q.", err)
+	}
+	tests.Passed("Should have successfully retrieved all users.")
+
+	if records.Total != 0 {
+		tests.Failed("Should have not saved any user with cancelled context.")
+	}
+	tests.Passed("Should have not saved any user with cancelled context.")
+}
+
+// TestUsersErrors validates the typed errors returned by the Users handler.
+func TestUsersErrors(t *testing.T) {
+	users := handlers.UsersFactory(log, memory.New(), usersTable, profilesTable)
+
+	if _, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"}); err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	t.Logf("Given the need to check the errors returned by the Users handler")
+	{
+		t.Log("\tWhen creating a user without a password")
+		{
+			_, err := users.Create(user.NewUser{Email: "bob@guma.com"})
+			if !errors.Is(err, handlers.ErrValidation) {
+				tests.Failed("Should have failed with a validation error: %+q.", err)
+			}
+			tests.Passed("Should have failed with a validation error.")
+
+			var verr handlers.ValidationError
+			if !errors.As(err, &verr) || verr.Fields["password"] == "" {
+				tests.Failed("Should have reported the password field as invalid.")
+			}
+			tests.Passed("Should have reported the password field as invalid.")
+		}
+
+		t.Log("\tWhen creating a user with a weak password")
+		{
+			var verr handlers.ValidationError
+			if _, err := users.Create(user.NewUser{Email: "carl@guma.com", Password: "password1"}); !errors.As(err, &verr) || !strings.Contains(verr.Fields["password"], "is too common") {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have reported the password violations.")
+			}
+			tests.Passed("Should have reported the password violations.")
+		}
+
+		t.Log("\tWhen creating a user with a password meeting only a stricter policy of the Users")
+		{
+			strict := users
+			strict.PasswordPolicy = user.DefaultPasswordPolicy
+			strict.PasswordPolicy.MinLength = 24
+
+			var verr handlers.ValidationError
+			if _, err := strict.Create(user.NewUser{Email: "carl@guma.com", Password: "Glow-Worm-Lantern-42"}); !errors.As(err, &verr) || !strings.Contains(verr.Fields["password"], "at least 24") {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have reported the password violations of the Users policy.")
+			}
+			tests.Passed("Should have reported the password violations of the Users policy.")
+		}
+
+		t.Log("\tWhen creating a user with an existing email")
+		{
+			if _, err := users.Create(user.NewUser{Email: " BOB@Guma.com", Password: "Glow-Worm-Lantern-42"}); !errors.Is(err, db.ErrConflict) {
+				tests.Failed("Should have failed with db.ErrConflict: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrConflict.")
+		}
+
+		t.Log("\tWhen creating a user with an invalid email")
+		{
+			var verr handlers.ValidationError
+			if _, err := users.Create(user.NewUser{Email: "bob", Password: "Glow-Worm-Lantern-42"}); !errors.As(err, &verr) || verr.Fields["email"] == "" {
+				tests.Failed("Should have reported the email field as invalid: %+q.", err)
+			}
+			tests.Passed("Should have reported the email field as invalid.")
+		}
+
+		t.Log("\tWhen updating a user to the email of another")
+		{
+			alice, err := users.Create(user.NewUser{Email: "Alice@Guma.com", Password: "Glow-Worm-Lantern-42"})
+			if err != nil {
+				tests.Failed("Should have successfully created new user: %+q.", err)
+			}
+			tests.Passed("Should have successfully created new user.")
+
+			if err := users.Update(user.UpdateUser{PublicID: alice.PublicID, Email: "Bob@guma.com"}); !errors.Is(err, db.ErrConflict) {
+				tests.Failed("Should have failed with db.ErrConflict: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrConflict.")
+
+			if err := users.Update(user.UpdateUser{PublicID: alice.PublicID, Email: "ALICE@guma.com"}); err != nil {
+				tests.Failed("Should have successfully updated user to it's own email: %+q.", err)
+			}
+			tests.Passed("Should have successfully updated user to it's own email.")
+
+			if err := users.Delete(alice.PublicID); err != nil {
+				tests.Failed("Should have successfully deleted user: %+q.", err)
+			}
+			tests.Passed("Should have successfully deleted user.")
+		}
+
+		t.Log("\tWhen retrieving a user by email of another case")
+		{
+			nu, err := users.GetByEmail("  Bob@GUMA.com ")
+			if err != nil {
+				tests.Failed("Should have successfully retrieved user: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved user.")
+
+			if nu.Email != "bob@guma.com" {
+				tests.Failed("Should have retrieved user with normalized email.")
+			}
+			tests.Passed("Should have retrieved user with normalized email.")
+		}
+
+		t.Log("\tWhen retrieving an unknown user")
+		{
+			if _, err := users.Get("unknown"); !errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have failed with db.ErrNotFound: %+q.", err)
+			}
+			tests.Passed("Should have failed with db.ErrNotFound.")
+		}
+
+		t.Log("\tWhen authenticating with a wrong password")
+		{
+			if _, err := users.Authenticate("bob@guma.com", "Glowing-Ember-Coal-36"); !errors.Is(err, handlers.ErrInvalidCredentials) {
+				tests.Failed("Should have failed with ErrInvalidCredentials: %+q.", err)
+			}
+			tests.Passed("Should have failed with ErrInvalidCredentials.")
+		}
+
+		t.Log("\tWhen authenticating an unknown email")
+		{
+			_, err := users.Authenticate("alice@guma.com", "Glow-Worm-Lantern-42")
+			if !errors.Is(err, handlers.ErrInvalidCredentials) || errors.Is(err, db.ErrNotFound) {
+				tests.Failed("Should have failed with only ErrInvalidCredentials: %+q.", err)
+			}
+			tests.Passed("Should have failed with only ErrInvalidCredentials.")
+		}
+	}
+}
+
+// TestUsersRehash validates that logging in replaces hashes of an outdated hasher.
+func TestUsersRehash(t *testing.T) {
+	users := handlers.UsersFactory(log, memory.New(), usersTable, profilesTable)
+
+	current := user.Hasher
+	defer func() { user.Hasher = current }()
+
+	user.Hasher = user.Bcrypt{Cost: 4}
+
+	nu, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	user.Hasher = user.Argon2id{Time: 1, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32}
+
+	t.Logf("Given the need to raise the cost of password hashes without resetting passwords")
+	{
+		t.Log("\tWhen logging in with a hash of an outdated hasher")
+		{
+			if _, err := users.Authenticate("bob@guma.com", "Glow-Worm-Lantern-42"); err != nil {
+				tests.Failed("Should have successfully authenticated user: %+q.", err)
+			}
+			tests.Passed("Should have successfully authenticated user.")
+
+			stored, err := users.Get(nu.PublicID)
+			if err != nil {
+				tests.Failed("Should have successfully retrieved user: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved user.")
+
+			if !strings.HasPrefix(stored.Hash, "$argon2id$") {
+				tests.Info("Hash: %s", stored.Hash)
+				tests.Failed("Should have stored hash of current hasher.")
+			}
+			tests.Passed("Should have stored hash of current hasher.")
+
+			if _, err := users.Authenticate("bob@guma.com", "Glow-Worm-Lantern-42"); err != nil {
+				tests.Failed("Should have authenticated with new hash: %+q.", err)
+			}
+			tests.Passed("Should have authenticated with new hash.")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/mail"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+)
+
+// VerificationsFactory returns a new Verifications which mails tickets expiring after the
+// expiration through the mailer, stored within the verifications table. The tokens of
+// tickets are signed and hashed with the secret.
+func VerificationsFactory(log sink.Sink, dbr db.DB, users Users, verificationsT db.TableIdentity, secret []byte, expiration time.Duration, mailer mail.Mailer) Verifications {
+	return Verifications{
+		DB:            dbr,
+		Log:           log,
+		Users:         users,
+		Mailer:        mailer,
+		Secret:        secret,
+		Expiration:    expiration,
+		TableIdentity: verificationsT,
+	}
+}
+
+// Verifications exposes a central handle for verifying the emails of users, which are
+// mailed a ticket.Ticket whoes signed token is returned with Verify to verify the email
+// it was mailed to. Secret keys the signatures and hashes of the tokens, where no tickets
+// are mailed or verified while it is empty.
+type Verifications struct {
+	DB            db.DB
+	Log           sink.Sink
+	Users         Users
+	Mailer        mail.Mailer
+	Secret        []byte
+	Expiration    time.Duration
+	TableIdentity db.TableIdentity
+
+	// Link is the link mailed to users holding "%s" in place of the token, such as
+	// "https://example.com/verify?token=%s", where the token is mailed as is if empty.
+	Link string
+
+	// Message returns the message mailed to the user with the link, defaulting to a
+	// plain message holding the link.
+	Message func(nu *user.User, link string) mail.Message
+}
+
+// Send mails a new ticket to the user to verify it's email, replacing any ticket mailed
+// before. Users who verified their email are not mailed.
+func (v Verifications) Send(nu *user.User) error {
+	return v.SendCtx(context.Background(), nu)
+}
+
+// SendCtx is the same as Send but uses the provided context for all db operations.
+func (v Verifications) SendCtx(ctx context.Context, nu *user.User) error {
+	defer v.Log.Emit(sinks.Info("Send User Verification").WithFields(sink.Fields{
+		"user_id": nu.PublicID,
+	}).Trace("Verifications.Send").End())
+
+	if nu.Verified {
+		return nil
+	}
+
+	return v.tickets().issueCtx(ctx, nu)
+}
+
+// Resend mails a new ticket to the user with the giving email, as Send does. No error is
+// returned if no user has the email, so callers can not learn which emails have users.
+func (v Verifications) Resend(email string) error {
+	return v.ResendCtx(context.Background(), email)
+}
+
+// ResendCtx is the same as Resend but uses the provided context for all db operations.
+func (v Verifications) ResendCtx(ctx context.Context, email string) error {
+	defer v.Log.Emit(sinks.Info("Resend User Verification").WithFields(sink.Fields{
+		"user_email": email,
+	}).Trace("Verifications.Resend").End())
+
+	users := v.Users
+	users.Profiles = nil
+
+	nu, err := users.GetByEmailCtx(ctx, email)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil
+		}
+
+		v.Log.Emit(sinks.Error(err).WithFields(sink.Fields{"user_email": email}))
+		return err
+	}
+
+	return v.SendCtx(ctx, nu)
+}
+
+// Verify verifies the email of the user of the signed token mailed by Send, returning the
+// verified user. The token must not have expired, and is valid only once and only while
+// the user's email is the one it was mailed to, else an error matching ErrValidation is
+// returned.
+func (v Verifications) Verify(token string) (*user.User, error) {
+	return v.VerifyCtx(context.Background(), token)
+}
+
+// VerifyCtx is the same as Verify but uses the provided context for all db operations.
+func (v Verifications) VerifyCtx(ctx context.Context, token string) (*user.User, error) {
+	defer v.Log.Emit(sinks.Info("Verify User Email").Trace("Verifications.Verify").End())
+
+	return v.tickets().redeemCtx(ctx, v.Users, token, func(tx db.DB, nu *user.User) error {
+		nu.Verified = true
+
+		return db.WithContext(tx).UpdateCtx(ctx, v.Users.TableIdentity, user.UpdateUserVerified{PublicID: nu.PublicID, Verified: true}, "public_id")
+	})
+}
+
+// tickets returns the tickets issuing and redeeming the verifications.
+func (v Verifications) tickets() tickets {
+	return tickets{
+		DB:            v.DB,
+		Log:           v.Log,
+		Mailer:        v.Mailer,
+		Secret:        v.Secret,
+		Expiration:    v.Expiration,
+		TableIdentity: v.TableIdentity,
+		Link:          v.Link,
+		Message:       v.Message,
+		Kind:          "verification",
+		Subject:       "Verify your email",
+		Body:          "Verify your email with the link below, which expires in %s:\n\n%s\n",
+	}
+}
@@ -0,0 +1,225 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/memory"
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/mail"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/faux/tests"
+)
+
+var verificationsTable = db.TableName{Name: "verifications"}
+
+// mailbox implements mail.Mailer by holding sent messages.
+type mailbox struct {
+	messages []mail.Message
+}
+
+func (m *mailbox) Send(ctx context.Context, msg mail.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// last returns the token within the last message sent to the email.
+func (m *mailbox) last(email string) string {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == email {
+			body := strings.TrimSpace(m.messages[i].Body)
+			return body[strings.LastIndex(body, "=")+1:]
+		}
+	}
+
+	return ""
+}
+
+// TestVerifications validates the email verification of the Verifications handler against a memory store.
+func TestVerifications(t *testing.T) {
+	store := memory.New()
+	box := new(mailbox)
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	verifications := handlers.VerificationsFactory(log, store, users, verificationsTable, []byte("verification-secret"), time.Hour, box)
+	verifications.Link = "https://guma.com/verify?token=%s"
+
+	nu, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	if nu.Verified {
+		tests.Failed("Should have created user with unverified email.")
+	}
+	tests.Passed("Should have created user with unverified email.")
+
+	t.Logf("Given the need to verify the email of a user")
+	{
+		t.Log("\tWhen mailing a verification to the user")
+		{
+			if err := verifications.Send(nu); err != nil {
+				tests.Failed("Should have successfully sent verification: %+q.", err)
+			}
+			tests.Passed("Should have successfully sent verification.")
+
+			if len(box.messages) != 1 || !strings.Contains(box.messages[0].Body, "https://guma.com/verify?token=") {
+				tests.Info("Messages: %+v", box.messages)
+				tests.Failed("Should have mailed link to user.")
+			}
+			tests.Passed("Should have mailed link to user.")
+		}
+
+		t.Log("\tWhen resending the verification")
+		{
+			first := box.last("bob@guma.com")
+
+			if err := verifications.Resend("BOB@guma.com"); err != nil {
+				tests.Failed("Should have successfully resent verification: %+q.", err)
+			}
+			tests.Passed("Should have successfully resent verification.")
+
+			if _, err := verifications.Verify(first); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have invalidated earlier verification.")
+			}
+			tests.Passed("Should have invalidated earlier verification.")
+		}
+
+		t.Log("\tWhen verifying with the mailed token")
+		{
+			token := box.last("bob@guma.com")
+
+			verified, err := verifications.Verify(token)
+			if err != nil {
+				tests.Failed("Should have successfully verified email: %+q.", err)
+			}
+			tests.Passed("Should have successfully verified email.")
+
+			if !verified.Verified || verified.PublicID != nu.PublicID {
+				tests.Info("User: %+v", verified)
+				tests.Failed("Should have returned verified user.")
+			}
+			tests.Passed("Should have returned verified user.")
+
+			stored, err := users.Get(nu.PublicID)
+			if err != nil {
+				tests.Failed("Should have successfully retrieved user: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved user.")
+
+			if !stored.Verified {
+				tests.Failed("Should have stored user as verified.")
+			}
+			tests.Passed("Should have stored user as verified.")
+
+			if _, err := verifications.Verify(token); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused to reuse token.")
+			}
+			tests.Passed("Should have refused to reuse token.")
+
+			sent := len(box.messages)
+			if err := verifications.Send(stored); err != nil || len(box.messages) != sent {
+				tests.Failed("Should not have mailed verified user: %+q.", err)
+			}
+			tests.Passed("Should not have mailed verified user.")
+		}
+
+		t.Log("\tWhen the user changes it's email")
+		{
+			if err := verifications.Send(&user.User{PublicID: nu.PublicID, Email: "bob@guma.com"}); err != nil {
+				tests.Failed("Should have successfully sent verification: %+q.", err)
+			}
+			tests.Passed("Should have successfully sent verification.")
+
+			token := box.last("bob@guma.com")
+
+			if err := users.Update(user.UpdateUser{PublicID: nu.PublicID, Email: "bobby@guma.com"}); err != nil {
+				tests.Failed("Should have successfully updated user email: %+q.", err)
+			}
+			tests.Passed("Should have successfully updated user email.")
+
+			stored, err := users.Get(nu.PublicID)
+			if err != nil {
+				tests.Failed("Should have successfully retrieved user: %+q.", err)
+			}
+			tests.Passed("Should have successfully retrieved user.")
+
+			if stored.Verified {
+				tests.Failed("Should have unverified changed email.")
+			}
+			tests.Passed("Should have unverified changed email.")
+
+			if _, err := verifications.Verify(token); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused token mailed to old email.")
+			}
+			tests.Passed("Should have refused token mailed to old email.")
+		}
+
+		t.Log("\tWhen verifying with forged or expired tokens")
+		{
+			forger := verifications
+			forger.Secret = []byte("forged-secret")
+
+			if err := forger.Resend("bobby@guma.com"); err != nil {
+				tests.Failed("Should have successfully sent verification: %+q.", err)
+			}
+			tests.Passed("Should have successfully sent verification.")
+
+			if _, err := verifications.Verify(box.last("bobby@guma.com")); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused token signed by another secret.")
+			}
+			tests.Passed("Should have refused token signed by another secret.")
+
+			expired := verifications
+			expired.Expiration = -time.Minute
+
+			if err := expired.Resend("bobby@guma.com"); err != nil {
+				tests.Failed("Should have successfully sent verification: %+q.", err)
+			}
+			tests.Passed("Should have successfully sent verification.")
+
+			if _, err := verifications.Verify(box.last("bobby@guma.com")); !errors.Is(err, handlers.ErrValidation) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused expired token.")
+			}
+			tests.Passed("Should have refused expired token.")
+		}
+
+		t.Log("\tWhen resending to an unknown email")
+		{
+			sent := len(box.messages)
+
+			if err := verifications.Resend("alice@guma.com"); err != nil || len(box.messages) != sent {
+				tests.Failed("Should have silently ignored unknown email: %+q.", err)
+			}
+			tests.Passed("Should have silently ignored unknown email.")
+		}
+
+		t.Log("\tWhen the verifications have no secret")
+		{
+			unkeyed := verifications
+			unkeyed.Secret = nil
+
+			if err := unkeyed.Resend("bobby@guma.com"); !errors.Is(err, handlers.ErrNoSecret) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused to mail verification.")
+			}
+			tests.Passed("Should have refused to mail verification.")
+
+			if _, err := unkeyed.Verify(box.last("bobby@guma.com")); !errors.Is(err, handlers.ErrNoSecret) {
+				tests.Info("Error: %+q", err)
+				tests.Failed("Should have refused to verify token.")
+			}
+			tests.Passed("Should have refused to verify token.")
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package mail defines the Mailer through which backoffice mails users, such as to verify
+// their email, along with an SMTP sender and file and log senders for local development.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+)
+
+// Message defines a plain text mail sent to a single recipient.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Bytes returns the message in the RFC 5322 format sent to SMTP servers, where From is
+// used if the message has no sender of it's own. It returns an error if a header holds
+// a line break, as it would allow headers to be injected.
+func (m Message) Bytes(from string) ([]byte, error) {
+	if m.From != "" {
+		from = m.From
+	}
+
+	if from == "" || m.To == "" {
+		return nil, errors.New("Message requires a sender and recipient")
+	}
+
+	for _, header := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errors.New("Message headers must not hold line breaks")
+		}
+	}
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&b, "\r\n")
+
+	body := strings.ReplaceAll(m.Body, "\r\n", "\n")
+	fmt.Fprint(&b, strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return b.Bytes(), nil
+}
+
+// Mailer defines an interface which sends messages to their recipient.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTP implements Mailer by sending messages through the SMTP server at Addr, in the form
+// host:port, as From unless a message has a sender of it's own. Auth is optional, where
+// net/smtp only sends it over TLS or to localhost.
+type SMTP struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+// Send sends the message through the SMTP server.
+func (s SMTP) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := msg.Bytes(s.From)
+	if err != nil {
+		return err
+	}
+
+	from := msg.From
+	if from == "" {
+		from = s.From
+	}
+
+	return smtp.SendMail(s.Addr, s.Auth, from, []string{msg.To}, data)
+}
+
+// File implements Mailer by writing each message as a .eml file within Dir, which is
+// created if missing. It is suited for local development, where messages can be opened
+// with any mail client.
+type File struct {
+	Dir  string
+	From string
+}
+
+// unsafeName matches the characters replaced within the file names of messages.
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9@._-]+`)
+
+// Send writes the message to a new file named by the time and recipient of the message.
+func (f File) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := msg.Bytes(f.From)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(f.Dir, 0700); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), unsafeName.ReplaceAllString(msg.To, "_"))
+
+	return ioutil.WriteFile(filepath.Join(f.Dir, name), data, 0600)
+}
+
+// Log implements Mailer by emitting each message to the sink, including it's body. It
+// is suited for local development only, as the body holds the tokens mailed to users.
+type Log struct {
+	Log sink.Sink
+}
+
+// Send emits the message to the sink.
+func (l Log) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return l.Log.Emit(sinks.Info("Mail Message").WithFields(sink.Fields{
+		"from":    msg.From,
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	}))
+}
@@ -0,0 +1,33 @@
+package mail_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/influx6/backoffice/mail"
+	"github.com/influx6/faux/tests"
+)
+
+// TestMessageBytes validates the format of messages sent to SMTP servers.
+func TestMessageBytes(t *testing.T) {
+	msg := mail.Message{To: "bob@guma.com", Subject: "Verify your email", Body: "Hello\nBob"}
+
+	data, err := msg.Bytes("noreply@guma.com")
+	if err != nil {
+		tests.Failed("Should have successfully formatted message: %+q.", err)
+	}
+	tests.Passed("Should have successfully formatted message.")
+
+	if !bytes.Contains(data, []byte("From: noreply@guma.com\r\n")) || !bytes.HasSuffix(data, []byte("\r\n\r\nHello\r\nBob")) {
+		tests.Info("Message: %q", data)
+		tests.Failed("Should have formatted headers and body with CRLF line breaks.")
+	}
+	tests.Passed("Should have formatted headers and body with CRLF line breaks.")
+
+	msg.Subject = "Hello\r\nBcc: alice@guma.com"
+
+	if _, err := msg.Bytes("noreply@guma.com"); err == nil {
+		tests.Failed("Should have refused headers holding line breaks.")
+	}
+	tests.Passed("Should have refused headers holding line breaks.")
+}
@@ -1,12 +1,281 @@
 package sqltables
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/sql/dialects"
 	"github.com/influx6/backoffice/db/sql/tables"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/jmoiron/sqlx"
 )
 
-// BasicTables defines the migration table for creating the profiles's table.
+// BasicTables defines the migration tables for creating the profiles, sessions, users,
+// refresh_tokens, verifications and password_resets tables with all fields, indexes and
+// constraints added by Migrations.
 func BasicTables(names db.Namer) []tables.TableMigration {
+	ts := initialTables(names)
+
+	for index := range ts {
+		ts[index].Indexes = append(ts[index].Indexes, cursorIndex())
+
+		switch ts[index].TableName {
+		case names.New("sessions"):
+			ts[index].Fields = append(ts[index].Fields, sessionDeviceFields()...)
+			ts[index].Fields = append(ts[index].Fields, tokenHashField())
+		case names.New("users"):
+			ts[index].Fields = append(ts[index].Fields, userRolesField(), userVerifiedField())
+
+			for field := range ts[index].Fields {
+				if ts[index].Fields[field].FieldName == "email" {
+					ts[index].Fields[field].Unique = true
+				}
+			}
+		}
+	}
+
+	refreshes := refreshTokensTable(names)
+	refreshes.Fields = append(refreshes.Fields, tokenHashField())
+
+	return append(ts, refreshes, ticketsTable(names.New("verifications")), ticketsTable(names.New("password_resets")))
+}
+
+// cursorIndex defines the index of the fields by which pages of records are retrieved
+// with cursors, which is added to the tables created by version 1 of Migrations by
+// version 2.
+func cursorIndex() tables.IndexMigration {
+	return tables.IndexMigration{IndexName: "created_at", Field: "created_at, public_id"}
+}
+
+// userVerifiedField defines the field of the users table recording if each user verified
+// it's email, which is added by version 8 of Migrations. It is nullable as existing users
+// are unverified.
+func userVerifiedField() tables.FieldMigration {
+	return tables.FieldMigration{
+		FieldName: "verified",
+		FieldType: "BOOLEAN",
+	}
+}
+
+// ticketsTable defines a table of the tickets mailed to users, such as the verifications
+// and password_resets tables created by versions 8 and 9 of Migrations.
+func ticketsTable(name string) tables.TableMigration {
+	return tables.TableMigration{
+		TableName:   name,
+		Timestamped: true,
+		Indexes: []tables.IndexMigration{
+			{
+				IndexName: "user_id",
+				Field:     "user_id",
+			},
+		},
+		Fields: []tables.FieldMigration{
+			{
+				FieldName:  "public_id",
+				FieldType:  "VARCHAR(255)",
+				PrimaryKey: true,
+				NotNull:    true,
+			},
+			{
+				FieldName: "user_id",
+				FieldType: "VARCHAR(255)",
+				NotNull:   true,
+			},
+			{
+				FieldName: "email",
+				FieldType: "VARCHAR(255)",
+				NotNull:   true,
+			},
+			{
+				FieldName: "token_hash",
+				FieldType: "VARCHAR(64)",
+				NotNull:   true,
+			},
+			{
+				FieldName: "expires",
+				FieldType: "timestamp",
+				NotNull:   true,
+			},
+		},
+	}
+}
+
+// uniqueEmails defines the steps of version 7 of Migrations, which normalize the emails of
+// existing users to the form stored by handlers.Users and index them as unique. Users
+// sharing an email once normalized fail the migration, listing their public ids, as only
+// an operator can tell which of them to keep.
+func uniqueEmails(names db.Namer) []tables.Step {
+	return []tables.Step{
+		normalizeEmails(names.New("users")),
+		tables.AddIndex{
+			TableName: names.New("users"),
+			Index:     tables.IndexMigration{IndexName: "email", Field: "email"},
+			Unique:    true,
+		},
+	}
+}
+
+// normalizeEmails returns a Step normalizing the emails of the users of the table with
+// user.NormalizeEmail, which no sql statement can, as it converts internationalized
+// domains to punycode. Emails which NormalizeEmail refuses are only trimmed and
+// lowercased, leaving them to be corrected by their users. No email is updated if any
+// users share an email once normalized.
+func normalizeEmails(table string) tables.Func {
+	return func(ctx context.Context, tx *sqlx.Tx, d dialects.Dialect) error {
+		var users []struct {
+			PublicID string `db:"public_id"`
+			Email    string `db:"email"`
+		}
+
+		if err := tx.SelectContext(ctx, &users, fmt.Sprintf("SELECT public_id, email FROM %s ORDER BY public_id", table)); err != nil {
+			return err
+		}
+
+		normalized := make([]string, len(users))
+		owners := make(map[string][]string, len(users))
+
+		var shared []string
+
+		for index, nu := range users {
+			email, err := user.NormalizeEmail(nu.Email)
+			if err != nil {
+				email = strings.ToLower(strings.TrimSpace(nu.Email))
+			}
+
+			normalized[index] = email
+			owners[email] = append(owners[email], nu.PublicID)
+
+			if len(owners[email]) == 2 {
+				shared = append(shared, email)
+			}
+		}
+
+		if len(shared) != 0 {
+			conflicts := make([]string, 0, len(shared))
+			for _, email := range shared {
+				conflicts = append(conflicts, fmt.Sprintf("%q by %s", email, strings.Join(owners[email], ", ")))
+			}
+
+			return fmt.Errorf("Users of %q share emails once normalized, where all but one user of each must be removed or given another email before migrating: %s", table, strings.Join(conflicts, "; "))
+		}
+
+		update := fmt.Sprintf("UPDATE %s SET email = %s WHERE public_id = %s", table, d.Placeholder(1), d.Placeholder(2))
+
+		for index, nu := range users {
+			if normalized[index] == nu.Email {
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, update, normalized[index], nu.PublicID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// userRolesField defines the field of the users table holding the comma separated roles
+// of each user, which is added by version 6 of Migrations. It is nullable as existing
+// users hold no roles.
+func userRolesField() tables.FieldMigration {
+	return tables.FieldMigration{
+		FieldName: "roles",
+		FieldType: "VARCHAR(255)",
+	}
+}
+
+// tokenHashField defines the field holding the hash of the token of sessions and refresh
+// tokens, which is added to both tables by version 5 of Migrations. It is nullable as it
+// is missing from existing records, which hold their token as is.
+func tokenHashField() tables.FieldMigration {
+	return tables.FieldMigration{
+		FieldName: "token_hash",
+		FieldType: "VARCHAR(64)",
+	}
+}
+
+// refreshTokensTable defines the table of the refresh tokens of sessions, which is
+// created by version 4 of Migrations.
+func refreshTokensTable(names db.Namer) tables.TableMigration {
+	return tables.TableMigration{
+		TableName:   names.New("refresh_tokens"),
+		Timestamped: true,
+		Indexes: []tables.IndexMigration{
+			{
+				IndexName: "session_id",
+				Field:     "session_id",
+			},
+			{
+				IndexName: "user_id",
+				Field:     "user_id",
+			},
+		},
+		Fields: []tables.FieldMigration{
+			{
+				FieldName:  "public_id",
+				FieldType:  "VARCHAR(255)",
+				PrimaryKey: true,
+				NotNull:    true,
+			},
+			{
+				FieldName: "session_id",
+				FieldType: "VARCHAR(255)",
+				NotNull:   true,
+			},
+			{
+				FieldName: "user_id",
+				FieldType: "VARCHAR(255)",
+				NotNull:   true,
+			},
+			{
+				FieldName: "token",
+				FieldType: "VARCHAR(255)",
+				NotNull:   true,
+			},
+			{
+				FieldName: "expires",
+				FieldType: "timestamp",
+				NotNull:   true,
+			},
+			{
+				FieldName: "replaced_by",
+				FieldType: "VARCHAR(255)",
+				NotNull:   true,
+			},
+		},
+	}
+}
+
+// sessionDeviceFields defines the fields of the sessions table recording the device of
+// each session, which are added by version 3 of Migrations. They are nullable as they
+// are missing from existing sessions.
+func sessionDeviceFields() []tables.FieldMigration {
+	return []tables.FieldMigration{
+		{
+			FieldName: "user_agent",
+			FieldType: "VARCHAR(255)",
+		},
+		{
+			FieldName: "ip",
+			FieldType: "VARCHAR(64)",
+		},
+		{
+			FieldName: "label",
+			FieldType: "VARCHAR(255)",
+		},
+		{
+			FieldName: "last_seen",
+			FieldType: "timestamp",
+		},
+	}
+}
+
+// initialTables defines the tables as created by version 1 of Migrations, which must
+// not change as later versions build upon it.
+func initialTables(names db.Namer) []tables.TableMigration {
 	var ts []tables.TableMigration
 
 	ts = append(ts, tables.TableMigration{
@@ -113,3 +382,111 @@ func BasicTables(names db.Namer) []tables.TableMigration {
 
 	return ts
 }
+
+// Migrations returns the versioned migrations of the backoffice tables, to be applied
+// with a sql.Migrator. Version 1 creates the tables, version 2 indexes their created_at
+// and public_id fields for cursor pagination, version 3 adds the device fields of
+// sessions, version 4 creates the refresh_tokens table, version 5 adds the token_hash
+// field to sessions and refresh_tokens, version 6 adds the roles field to users and
+// version 7 normalizes the emails of users and indexes them as unique, version 8 adds
+// the verified field to users and creates the verifications table and version 9 creates
+// the password_resets table.
+func Migrations(names db.Namer) []tables.Migration {
+	basic := initialTables(names)
+
+	var up, down []tables.Step
+
+	for index := range basic {
+		up = append(up, basic[index])
+		down = append(down, tables.DropTable{TableName: basic[len(basic)-1-index].TableName})
+	}
+
+	var indexUp, indexDown []tables.Step
+
+	// Index the fields by which pages of records are retrieved with cursors.
+	for _, table := range basic {
+		indexUp = append(indexUp, tables.AddIndex{TableName: table.TableName, Index: cursorIndex()})
+		indexDown = append(indexDown, tables.DropIndex{TableName: table.TableName, IndexName: "created_at"})
+	}
+
+	var deviceUp, deviceDown []tables.Step
+
+	for _, field := range sessionDeviceFields() {
+		deviceUp = append(deviceUp, tables.AddColumn{TableName: names.New("sessions"), Field: field})
+		deviceDown = append(deviceDown, tables.DropColumn{TableName: names.New("sessions"), FieldName: field.FieldName})
+	}
+
+	var hashUp, hashDown []tables.Step
+
+	for _, table := range []string{names.New("sessions"), names.New("refresh_tokens")} {
+		hashUp = append(hashUp, tables.AddColumn{TableName: table, Field: tokenHashField()})
+		hashDown = append(hashDown, tables.DropColumn{TableName: table, FieldName: "token_hash"})
+	}
+
+	return []tables.Migration{
+		{
+			Version: 1,
+			Name:    "basic_tables",
+			Up:      up,
+			Down:    down,
+		},
+		{
+			Version: 2,
+			Name:    "cursor_indexes",
+			Up:      indexUp,
+			Down:    indexDown,
+		},
+		{
+			Version: 3,
+			Name:    "session_devices",
+			Up:      deviceUp,
+			Down:    deviceDown,
+		},
+		{
+			Version: 4,
+			Name:    "refresh_tokens",
+			Up:      []tables.Step{refreshTokensTable(names)},
+			Down:    []tables.Step{tables.DropTable{TableName: names.New("refresh_tokens")}},
+		},
+		{
+			Version: 5,
+			Name:    "token_hashes",
+			Up:      hashUp,
+			Down:    hashDown,
+		},
+		{
+			Version: 6,
+			Name:    "user_roles",
+			Up:      []tables.Step{tables.AddColumn{TableName: names.New("users"), Field: userRolesField()}},
+			Down:    []tables.Step{tables.DropColumn{TableName: names.New("users"), FieldName: "roles"}},
+		},
+		{
+			Version: 7,
+			Name:    "unique_emails",
+			Up:      uniqueEmails(names),
+			Down:    []tables.Step{tables.DropIndex{TableName: names.New("users"), IndexName: "email"}},
+		},
+		{
+			Version: 8,
+			Name:    "email_verifications",
+			Up: []tables.Step{
+				tables.AddColumn{TableName: names.New("users"), Field: userVerifiedField()},
+				ticketsTable(names.New("verifications")),
+			},
+			Down: []tables.Step{
+				tables.DropTable{TableName: names.New("verifications")},
+				tables.DropColumn{TableName: names.New("users"), FieldName: "verified"},
+			},
+		},
+		{
+			Version: 9,
+			Name:    "password_resets",
+			Up: []tables.Step{
+				ticketsTable(names.New("password_resets")),
+			},
+			Down: []tables.Step{
+				tables.DropTable{TableName: names.New("password_resets")},
+			},
+		},
+	}
+}
@@ -0,0 +1,204 @@
+package session
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	refreshTableName = "refresh_tokens"
+
+	// FamilyIndex defines the index name of the session a refresh token belongs to.
+	FamilyIndex = "session_id"
+)
+
+// Refresh defines the set of data received to exchange a refresh token for a new
+// access token.
+type Refresh struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken defines a long-lived token which is exchanged for a new access token of
+// the session it belongs to. Each exchange replaces the refresh token with a new one,
+// where the tokens of a session form a family which is revoked along with the session
+// if a replaced token is used again.
+//
+// As with a Session, Token is only set on a new refresh token as only it's TokenHash
+// is stored.
+type RefreshToken struct {
+	PublicID   string    `json:"public_id"`
+	SessionID  string    `json:"session_id"`
+	UserID     string    `json:"user_id"`
+	Token      string    `json:"-"`
+	TokenHash  string    `json:"-"`
+	Expires    time.Time `json:"expires"`
+	ReplacedBy string    `json:"replaced_by"`
+
+	plainToken string
+}
+
+// NewRefreshToken returns a new instance of a refresh token for the session, whoes token
+// is hashed with the secret.
+func NewRefreshToken(s Session, expiration time.Time, secret []byte) *RefreshToken {
+	token := NewToken()
+
+	return &RefreshToken{
+		PublicID:  uuid.NewV4().String(),
+		SessionID: s.PublicID,
+		UserID:    s.UserID,
+		Token:     token,
+		TokenHash: HashToken(secret, token),
+		Expires:   expiration,
+	}
+}
+
+// ValidateToken validates that the provided token, as returned by ParseRefreshToken,
+// matches the token of the refresh token hashed with the secret. The comparison takes
+// constant time.
+func (u RefreshToken) ValidateToken(secret []byte, token string) bool {
+	return matchToken(secret, u.TokenHash, u.plainToken, token)
+}
+
+// Legacy returns true/false if the refresh token holds it's token as is, having been
+// created before tokens were hashed.
+func (u RefreshToken) Legacy() bool {
+	return u.TokenHash == "" && u.plainToken != ""
+}
+
+// Upgrade replaces the token a legacy refresh token holds as is with it's hash.
+func (u *RefreshToken) Upgrade(secret []byte) {
+	if !u.Legacy() {
+		return
+	}
+
+	u.TokenHash = HashToken(secret, u.plainToken)
+	u.plainToken = ""
+}
+
+// EncodedToken returns the RefreshToken.Token has a base64 encoded string.
+// It returns a base64 encoded version where it contains the SessionID:RefreshID:RefreshToken.
+func (u RefreshToken) EncodedToken() string {
+	refreshToken := fmt.Sprintf("%s:%s:%s", u.SessionID, u.PublicID, u.Token)
+	return base64.StdEncoding.EncodeToString([]byte(refreshToken))
+}
+
+// Replaced returns true/false if the refresh token was already exchanged.
+func (u RefreshToken) Replaced() bool {
+	return u.ReplacedBy != ""
+}
+
+// Expired returns true/false if the the given refresh token is expired.
+func (u RefreshToken) Expired() bool {
+	return time.Now().After(u.Expires)
+}
+
+// Table returns the given table which the given struct corresponds to.
+func (u RefreshToken) Table() string {
+	return refreshTableName
+}
+
+// Fields returns a map representing the data of the refresh token.
+func (u RefreshToken) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"public_id":   u.PublicID,
+		"session_id":  u.SessionID,
+		"user_id":     u.UserID,
+		"token":       u.plainToken,
+		"token_hash":  u.TokenHash,
+		"expires":     u.Expires.Format(time.RFC3339),
+		"replaced_by": u.ReplacedBy,
+	}
+}
+
+// WithFields attempts to syncing the giving data within the provided
+// map into it's own fields.
+func (u *RefreshToken) WithFields(fields map[string]interface{}) error {
+	for key, target := range map[string]*string{
+		"public_id":  &u.PublicID,
+		"session_id": &u.SessionID,
+		"user_id":    &u.UserID,
+	} {
+		value, ok := fields[key].(string)
+		if !ok {
+			return fmt.Errorf("Expected '%s' key", key)
+		}
+
+		*target = value
+	}
+
+	// Refresh tokens created before tokens were hashed hold their token as is.
+	u.TokenHash, _ = fields["token_hash"].(string)
+	u.plainToken, _ = fields["token"].(string)
+
+	if u.TokenHash == "" && u.plainToken == "" {
+		return errors.New("Expected 'token_hash' key")
+	}
+
+	if replaced, ok := fields["replaced_by"].(string); ok {
+		u.ReplacedBy = replaced
+	}
+
+	expires, err := db.TimeOf(fields["expires"])
+	if err != nil {
+		return fmt.Errorf("Invalid %q: %s", "expires", err)
+	}
+
+	u.Expires = expires
+
+	return nil
+}
+
+//====================================================================================================
+
+// UpdateRefreshReplaced defines the set of data sent when exchanging a refresh token, which
+// marks it as replaced by the next refresh token. The token of a legacy refresh token is
+// replaced with it's TokenHash along the way.
+type UpdateRefreshReplaced struct {
+	ReplacedBy string `json:"replaced_by"`
+	TokenHash  string `json:"-"`
+}
+
+// Fields returns a map representing the data of the refresh token's replacement.
+func (u UpdateRefreshReplaced) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"replaced_by": u.ReplacedBy,
+		"token":       "",
+		"token_hash":  u.TokenHash,
+	}
+}
+
+// Table returns the given table which the given struct corresponds to.
+func (u UpdateRefreshReplaced) Table() string {
+	return refreshTableName
+}
+
+//====================================================================================================
+
+// ParseRefreshToken parses the base64 encoded refresh token, which it returns the
+// associated sessionID, refreshID and refresh token.
+func ParseRefreshToken(val string) (sessionID string, refreshID string, token string, err error) {
+	var decoded []byte
+
+	decoded, err = base64.StdEncoding.DecodeString(val)
+	if err != nil {
+		return
+	}
+
+	refreshToken := strings.Split(string(decoded), ":")
+	if len(refreshToken) != 3 {
+		err = errors.New("Invalid RefreshToken: Token must be SessionID:RefreshID:Token format")
+		return
+	}
+
+	sessionID = refreshToken[0]
+	refreshID = refreshToken[1]
+	token = refreshToken[2]
+
+	return
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/influx6/backoffice/db"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -20,10 +21,12 @@ const (
 	UniqueIndexField = "user_public_id"
 )
 
-// NewSession defines the set of data received to create a new user's session.
+// NewSession defines the set of data received to create a new user's session, where
+// Label optionally names the device the session is created for.
 type NewSession struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Label    string `json:"label"`
 }
 
 // EndSession defines the set of data received to end a user's session.
@@ -32,49 +35,82 @@ type EndSession struct {
 	Token  string `json:"token"`
 }
 
-// Session defines a struct which holds the the details of a giving user session.
+// Device defines the details of the client a session is created for.
+type Device struct {
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+	Label     string `json:"label"`
+}
+
+// Session defines a struct which holds the the details of a giving user session. A user
+// holds a session for each device it logs in from, each identified by it's PublicID.
+//
+// Token is only set on a new or rotated session, as only it's TokenHash is stored.
+// Sessions created before tokens were hashed hold their token as is, until upgraded
+// with Upgrade. Scopes are only set on sessions verified from signed tokens carrying
+// them, and Roles on new sessions and those verified from signed tokens, where both
+// are not stored.
 type Session struct {
-	UserID   string    `json:"user_id"`
-	PublicID string    `json:"public_id"`
-	Token    string    `json:"token"`
-	Expires  time.Time `json:"expires"`
+	UserID    string    `json:"user_id"`
+	PublicID  string    `json:"public_id"`
+	Token     string    `json:"-"`
+	TokenHash string    `json:"-"`
+	Expires   time.Time `json:"expires"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	Label     string    `json:"label"`
+	LastSeen  time.Time `json:"last_seen"`
+	CreatedAt time.Time `json:"created_at"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	Roles     []string  `json:"roles,omitempty"`
+
+	plainToken string
 }
 
-// New returns a new instance of a session.
-func New(userID string, expiration time.Time) *Session {
+// New returns a new instance of a session for the giving device, whoes token is hashed
+// with the secret.
+func New(userID string, expiration time.Time, device Device, secret []byte) *Session {
+	token := NewToken()
+
 	return &Session{
-		UserID:   userID,
-		PublicID: uuid.NewV4().String(),
-		Token:    uuid.NewV4().String(),
-		Expires:  expiration,
+		UserID:    userID,
+		PublicID:  uuid.NewV4().String(),
+		Token:     token,
+		TokenHash: HashToken(secret, token),
+		Expires:   expiration,
+		UserAgent: device.UserAgent,
+		IP:        device.IP,
+		Label:     device.Label,
+		LastSeen:  time.Now().UTC(),
 	}
 }
 
-// ValidateToken validates the provide base64 encoded token, that it matches the
-// expected token value with that of the session.
-func (u Session) ValidateToken(token string) bool {
-	decoded, err := base64.StdEncoding.DecodeString(token)
-	if err != nil {
-		return false
-	}
+// ValidateToken validates that the provided token, as returned by ParseToken, matches
+// the token of the session hashed with the secret. The comparison takes constant time.
+func (u Session) ValidateToken(secret []byte, token string) bool {
+	return matchToken(secret, u.TokenHash, u.plainToken, token)
+}
 
-	// Attempt to get the session token split which has the userid:session_token.
-	sessionToken := strings.Split(string(decoded), ":")
-	if len(sessionToken) != 2 {
-		return false
-	}
+// Legacy returns true/false if the session holds it's token as is, having been created
+// before tokens were hashed.
+func (u Session) Legacy() bool {
+	return u.TokenHash == "" && u.plainToken != ""
+}
 
-	if string(sessionToken[1]) != u.Token {
-		return false
+// Upgrade replaces the token a legacy session holds as is with it's hash.
+func (u *Session) Upgrade(secret []byte) {
+	if !u.Legacy() {
+		return
 	}
 
-	return true
+	u.TokenHash = HashToken(secret, u.plainToken)
+	u.plainToken = ""
 }
 
 // SessionToken returns the Session.Token has a base64 encoded string.
-// It returns a base64 encoded version where it contains the UserID:SessionToken.
+// It returns a base64 encoded version where it contains the UserID:SessionID:SessionToken.
 func (u Session) SessionToken() string {
-	sessionToken := fmt.Sprintf("%s:%s", u.UserID, u.Token)
+	sessionToken := fmt.Sprintf("%s:%s:%s", u.UserID, u.PublicID, u.Token)
 	return base64.StdEncoding.EncodeToString([]byte(sessionToken))
 }
 
@@ -86,22 +122,53 @@ func (u Session) Table() string {
 // SessionFields returns a map representing the user session.
 func (u Session) SessionFields() map[string]interface{} {
 	return map[string]interface{}{
-		"type":    "Bearer",
-		"token":   u.SessionToken(),
-		"expires": u.Expires.Format(time.RFC3339),
+		"type":       "Bearer",
+		"token":      u.SessionToken(),
+		"session_id": u.PublicID,
+		"expires":    u.Expires.Format(time.RFC3339),
 	}
 }
 
 // Fields returns a map representing the data of the session.
 func (u Session) Fields() map[string]interface{} {
 	return map[string]interface{}{
-		"user_id":   u.UserID,
-		"token":     u.Token,
-		"public_id": u.PublicID,
-		"expires":   u.Expires.Format(time.RFC3339),
+		"user_id":    u.UserID,
+		"token":      u.plainToken,
+		"token_hash": u.TokenHash,
+		"public_id":  u.PublicID,
+		"expires":    u.Expires.Format(time.RFC3339),
+		"user_agent": u.UserAgent,
+		"ip":         u.IP,
+		"label":      u.Label,
+		"last_seen":  u.LastSeen.Format(time.RFC3339),
 	}
 }
 
+// SafeFields returns a map representing the data of the session without it's token,
+// which is safe to return to the user of the session.
+func (u Session) SafeFields() map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":    u.UserID,
+		"public_id":  u.PublicID,
+		"expires":    u.Expires.Format(time.RFC3339),
+		"user_agent": u.UserAgent,
+		"ip":         u.IP,
+		"label":      u.Label,
+		"last_seen":  u.LastSeen.Format(time.RFC3339),
+		"created_at": u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Rotate replaces the token of the session with a new one hashed with the secret, expiring
+// at the giving time, which invalidates the previous token of the session.
+func (u *Session) Rotate(expiration time.Time, secret []byte) {
+	u.Token = NewToken()
+	u.TokenHash = HashToken(secret, u.Token)
+	u.plainToken = ""
+	u.Expires = expiration
+	u.LastSeen = time.Now().UTC()
+}
+
 // Expired returns true/false if the the given session is expired.
 func (u *Session) Expired() bool {
 	return time.Now().After(u.Expires)
@@ -122,32 +189,97 @@ func (u *Session) WithFields(fields map[string]interface{}) error {
 		return errors.New("Expected 'public_id' key")
 	}
 
-	if token, ok := fields["token"].(string); ok {
-		u.Token = token
-	} else {
-		return errors.New("Expected 'token' key")
+	// Sessions created before tokens were hashed hold their token as is.
+	u.TokenHash, _ = fields["token_hash"].(string)
+	u.plainToken, _ = fields["token"].(string)
+
+	if u.TokenHash == "" && u.plainToken == "" {
+		return errors.New("Expected 'token_hash' key")
+	}
+
+	// The device fields are missing from sessions created before they were recorded.
+	if agent, ok := fields["user_agent"].(string); ok {
+		u.UserAgent = agent
+	}
+
+	if ip, ok := fields["ip"].(string); ok {
+		u.IP = ip
+	}
+
+	if label, ok := fields["label"].(string); ok {
+		u.Label = label
 	}
 
-	if expires, ok := fields["expires"]; ok && expires != "" {
-		switch co := expires.(type) {
-		case string:
-			t, err := time.Parse(time.RFC3339, co)
-			if err != nil {
-				return err
-			}
+	for key, target := range map[string]*time.Time{
+		"expires":    &u.Expires,
+		"last_seen":  &u.LastSeen,
+		"created_at": &u.CreatedAt,
+	} {
+		t, err := db.TimeOf(fields[key])
+		if err != nil {
+			return fmt.Errorf("Invalid %q: %s", key, err)
+		}
 
-			u.Expires = t.UTC()
-		case time.Time:
-			u.Expires = co.UTC()
+		if !t.IsZero() {
+			*target = t
 		}
 	}
 
 	return nil
 }
 
+//====================================================================================================
+
+// UpdateSessionSeen defines the set of data sent when recording the use of a session, where
+// the expiry of the session is only updated if Expires is set.
+type UpdateSessionSeen struct {
+	PublicID string    `json:"public_id"`
+	LastSeen time.Time `json:"last_seen"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Fields returns a map representing the data of the session's use.
+func (u UpdateSessionSeen) Fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"last_seen": u.LastSeen.Format(time.RFC3339),
+		"public_id": u.PublicID,
+	}
+
+	if !u.Expires.IsZero() {
+		fields["expires"] = u.Expires.Format(time.RFC3339)
+	}
+
+	return fields
+}
+
+// Table returns the given table which the given struct corresponds to.
+func (u UpdateSessionSeen) Table() string {
+	return tableName
+}
+
+//====================================================================================================
+
+// UpdateTokenHash defines the set of data sent when replacing the token a legacy session or
+// refresh token holds as is with it's hash, which also removes the token.
+type UpdateTokenHash struct {
+	TokenHash string `json:"-"`
+}
+
+// Fields returns a map representing the data of the token's hash.
+func (u UpdateTokenHash) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"token":      "",
+		"token_hash": u.TokenHash,
+	}
+}
+
+//====================================================================================================
+
 // ParseToken parses the base64 encoded token, which it returns the
-// associated userID and session token.
-func ParseToken(val string) (userID string, token string, err error) {
+// associated userID, sessionID and session token. Tokens issued before users held a
+// session for each device are of the UserID:Token format, for which the sessionID is
+// returned empty.
+func ParseToken(val string) (userID string, sessionID string, token string, err error) {
 	var decoded []byte
 
 	decoded, err = base64.StdEncoding.DecodeString(val)
@@ -155,15 +287,20 @@ func ParseToken(val string) (userID string, token string, err error) {
 		return
 	}
 
-	// Attempt to get the session token split which has the userid:session_token.
+	// Attempt to get the session token split which has the userid:session_id:session_token.
 	sessionToken := strings.Split(string(decoded), ":")
-	if len(sessionToken) != 2 {
-		err = errors.New("Invalid SessionToken: Token must be UserID:Token  format")
-		return
-	}
 
-	userID = sessionToken[0]
-	token = sessionToken[1]
+	switch len(sessionToken) {
+	case 2:
+		userID = sessionToken[0]
+		token = sessionToken[1]
+	case 3:
+		userID = sessionToken[0]
+		sessionID = sessionToken[1]
+		token = sessionToken[2]
+	default:
+		err = errors.New("Invalid SessionToken: Token must be UserID:SessionID:Token format")
+	}
 
 	return
 }
@@ -1,6 +1,7 @@
 package session_test
 
 import (
+	"encoding/base64"
 	"testing"
 	"time"
 
@@ -22,10 +23,23 @@ func TestSessionWithField(t *testing.T) {
 	}
 	tests.Passed("Should have successfully filled session with fields.")
 
-	if nw.Token != "2332323-23220-Gu34433-23232232" {
-		tests.Failed("Should have matched expected token on session.")
+	if !nw.Legacy() || !nw.ValidateToken(nil, "2332323-23220-Gu34433-23232232") {
+		tests.Failed("Should have matched expected legacy token on session.")
 	}
-	tests.Passed("Should have matched expected token on session.")
+	tests.Passed("Should have matched expected legacy token on session.")
+
+	secret := []byte("secret")
+	nw.Upgrade(secret)
+
+	if nw.Legacy() || !nw.ValidateToken(secret, "2332323-23220-Gu34433-23232232") {
+		tests.Failed("Should have upgraded legacy token to it's hash.")
+	}
+	tests.Passed("Should have upgraded legacy token to it's hash.")
+
+	if nw.Fields()["token"] != "" {
+		tests.Failed("Should have removed legacy token from session fields.")
+	}
+	tests.Passed("Should have removed legacy token from session fields.")
 }
 
 // TestSession validates the methods and returns attached to the session model.
@@ -55,3 +69,58 @@ func TestSession(t *testing.T) {
 	}
 	tests.Passed("Should have a 'expires' field")
 }
+
+// TestSessionToken validates the session token names the user and session it belongs to.
+func TestSessionToken(t *testing.T) {
+	secret := []byte("secret")
+	se := session.New("bob", time.Now().Add(time.Hour), session.Device{Label: "laptop"}, secret)
+
+	userID, sessionID, token, err := session.ParseToken(se.SessionToken())
+	if err != nil {
+		tests.Failed("Should have successfully parsed session token: %+q.", err)
+	}
+	tests.Passed("Should have successfully parsed session token.")
+
+	if userID != "bob" || sessionID != se.PublicID {
+		tests.Failed("Should have parsed user and session of token.")
+	}
+	tests.Passed("Should have parsed user and session of token.")
+
+	if !se.ValidateToken(secret, token) {
+		tests.Failed("Should have validated parsed token against session.")
+	}
+	tests.Passed("Should have validated parsed token against session.")
+
+	if se.ValidateToken([]byte("other"), token) {
+		tests.Failed("Should have rejected parsed token hashed with another secret.")
+	}
+	tests.Passed("Should have rejected parsed token hashed with another secret.")
+
+	if se.ValidateToken(secret, se.SessionToken()) {
+		tests.Failed("Should have rejected encoded token against session.")
+	}
+	tests.Passed("Should have rejected encoded token against session.")
+
+	for key, value := range se.Fields() {
+		if value == token {
+			tests.Failed("Should have stored hash of token rather than the token in %q.", key)
+		}
+	}
+	tests.Passed("Should have stored hash of token rather than the token.")
+
+	if _, ok := se.SafeFields()["token"]; ok {
+		tests.Failed("Should have left token out of safe fields.")
+	}
+	tests.Passed("Should have left token out of safe fields.")
+
+	userID, sessionID, token, err = session.ParseToken(base64.StdEncoding.EncodeToString([]byte("bob:legacy-token")))
+	if err != nil {
+		tests.Failed("Should have successfully parsed legacy session token: %+q.", err)
+	}
+	tests.Passed("Should have successfully parsed legacy session token.")
+
+	if userID != "bob" || sessionID != "" || token != "legacy-token" {
+		tests.Failed("Should have parsed user and token of legacy token without a session.")
+	}
+	tests.Passed("Should have parsed user and token of legacy token without a session.")
+}
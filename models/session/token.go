@@ -0,0 +1,53 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// tokenSize defines the number of random bytes within a token.
+const tokenSize = 32
+
+// NewToken returns a new token of random bytes from crypto/rand, encoded as url safe
+// base64. It panics if crypto/rand fails, as no safe token can then be issued.
+func NewToken() string {
+	data := make([]byte, tokenSize)
+
+	if _, err := rand.Read(data); err != nil {
+		panic(fmt.Sprintf("Failed to read random token: %s", err))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// HashToken returns the hex encoded HMAC-SHA256 of the token keyed by the secret, which
+// is stored in place of the token.
+func HashToken(secret []byte, token string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(token))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// matchToken returns true/false if the token matches the stored hash, or the stored
+// plain token of records created before tokens were hashed, comparing in constant time.
+func matchToken(secret []byte, hash string, plain string, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	if hash != "" {
+		return subtle.ConstantTimeCompare([]byte(HashToken(secret, token)), []byte(hash)) == 1
+	}
+
+	if plain == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(plain)) == 1
+}
@@ -0,0 +1,169 @@
+// Package ticket defines single-use tokens mailed to users to prove they own their
+// email, such as to verify it or to reset their password.
+package ticket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/models/session"
+	uuid "github.com/satori/go.uuid"
+)
+
+// UserIndex defines the index name of the user a ticket belongs to.
+const UserIndex = "user_id"
+
+// contains the errors returned when parsing and validating tickets.
+var (
+	// ErrInvalidToken is returned for tokens which are malformed or not signed by the secret.
+	ErrInvalidToken = errors.New("Invalid ticket token")
+
+	// ErrExpired is returned for tokens past their expiry, and matches ErrInvalidToken.
+	ErrExpired = fmt.Errorf("Ticket token has expired: %w", ErrInvalidToken)
+)
+
+// Ticket defines a single-use token mailed to a user at Email, which proves the user owns
+// the email if it is returned before it expires. The token mailed is signed, carrying the
+// ticket's id and expiry so forged and expired tokens are rejected without retrieving the
+// ticket, where only the hash of the token is stored.
+//
+// As with a session.Session, Token is only set on a new ticket.
+type Ticket struct {
+	PublicID  string    `json:"public_id"`
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	Token     string    `json:"-"`
+	TokenHash string    `json:"-"`
+	Expires   time.Time `json:"expires"`
+}
+
+// New returns a new ticket for the user, mailed to the email, whoes token is hashed with
+// the secret.
+func New(userID string, email string, expiration time.Time, secret []byte) *Ticket {
+	token := session.NewToken()
+
+	return &Ticket{
+		PublicID:  uuid.NewV4().String(),
+		UserID:    userID,
+		Email:     email,
+		Token:     token,
+		TokenHash: session.HashToken(secret, token),
+		Expires:   expiration.UTC(),
+	}
+}
+
+// SignedToken returns the token mailed for the new ticket, in the form payload.signature
+// where the payload is the base64 encoded PublicID:Expires:Token and the signature is the
+// HMAC-SHA256 of the payload keyed by the secret.
+func (t Ticket) SignedToken(secret []byte) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d:%s", t.PublicID, t.Expires.Unix(), t.Token)))
+	return payload + "." + sign(secret, payload)
+}
+
+// ParseToken returns the ticket id and token of the signed token, if it is signed by the
+// secret and has not expired.
+func ParseToken(secret []byte, signed string) (ticketID string, token string, err error) {
+	dot := strings.LastIndex(signed, ".")
+	if dot < 0 {
+		return "", "", fmt.Errorf("Token must be payload.signature format: %w", ErrInvalidToken)
+	}
+
+	payload, signature := signed[:dot], signed[dot+1:]
+
+	if !hmac.Equal([]byte(sign(secret, payload)), []byte(signature)) {
+		return "", "", fmt.Errorf("Token signature does not match: %w", ErrInvalidToken)
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", err, ErrInvalidToken)
+	}
+
+	parts := strings.Split(string(decoded), ":")
+	if len(parts) != 3 {
+		return "", "", fmt.Errorf("Token must be TicketID:Expires:Token format: %w", ErrInvalidToken)
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", err, ErrInvalidToken)
+	}
+
+	if time.Now().Unix() >= expires {
+		return "", "", ErrExpired
+	}
+
+	return parts[0], parts[2], nil
+}
+
+// sign returns the url safe base64 encoded HMAC-SHA256 of the payload keyed by the secret.
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateToken validates that the provided token, as returned by ParseToken, matches the
+// token of the ticket hashed with the secret. The comparison takes constant time.
+func (t Ticket) ValidateToken(secret []byte, token string) bool {
+	if token == "" || t.TokenHash == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(session.HashToken(secret, token)), []byte(t.TokenHash))
+}
+
+// Expired returns true/false if the the ticket is expired.
+func (t Ticket) Expired() bool {
+	return !time.Now().Before(t.Expires)
+}
+
+// Fields returns a map representing the data of the ticket.
+func (t Ticket) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"public_id":  t.PublicID,
+		"user_id":    t.UserID,
+		"email":      t.Email,
+		"token_hash": t.TokenHash,
+		"expires":    t.Expires.Format(time.RFC3339),
+	}
+}
+
+// WithFields attempts to syncing the giving data within the provided
+// map into it's own fields.
+func (t *Ticket) WithFields(fields map[string]interface{}) error {
+	for key, target := range map[string]*string{
+		"public_id":  &t.PublicID,
+		"user_id":    &t.UserID,
+		"email":      &t.Email,
+		"token_hash": &t.TokenHash,
+	} {
+		value, ok := fields[key].(string)
+		if !ok {
+			return fmt.Errorf("Expected '%s' key", key)
+		}
+
+		*target = value
+	}
+
+	expires, err := db.TimeOf(fields["expires"])
+	if err != nil {
+		return fmt.Errorf("Invalid %q: %s", "expires", err)
+	}
+
+	if expires.IsZero() {
+		return errors.New("Expected 'expires' key")
+	}
+
+	t.Expires = expires
+
+	return nil
+}
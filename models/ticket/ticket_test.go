@@ -0,0 +1,51 @@
+package ticket_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influx6/backoffice/models/ticket"
+	"github.com/influx6/faux/tests"
+)
+
+// TestTicketToken validates the signed tokens of tickets.
+func TestTicketToken(t *testing.T) {
+	secret := []byte("secret")
+	nw := ticket.New("2332323-23220-Gu34433-23232232", "bob@guma.com", time.Now().Add(time.Hour), secret)
+
+	ticketID, token, err := ticket.ParseToken(secret, nw.SignedToken(secret))
+	if err != nil {
+		tests.Failed("Should have successfully parsed signed token: %+q.", err)
+	}
+	tests.Passed("Should have successfully parsed signed token.")
+
+	if ticketID != nw.PublicID || !nw.ValidateToken(secret, token) {
+		tests.Failed("Should have matched token of ticket.")
+	}
+	tests.Passed("Should have matched token of ticket.")
+
+	if _, _, err := ticket.ParseToken([]byte("forged"), nw.SignedToken(secret)); !errors.Is(err, ticket.ErrInvalidToken) {
+		tests.Failed("Should have refused token signed by another secret.")
+	}
+	tests.Passed("Should have refused token signed by another secret.")
+
+	expired := ticket.New("2332323-23220-Gu34433-23232232", "bob@guma.com", time.Now().Add(-time.Minute), secret)
+
+	if _, _, err := ticket.ParseToken(secret, expired.SignedToken(secret)); !errors.Is(err, ticket.ErrExpired) {
+		tests.Failed("Should have refused expired token.")
+	}
+	tests.Passed("Should have refused expired token.")
+
+	var stored ticket.Ticket
+
+	if err := stored.WithFields(nw.Fields()); err != nil {
+		tests.Failed("Should have successfully filled ticket with fields: %+q.", err)
+	}
+	tests.Passed("Should have successfully filled ticket with fields.")
+
+	if stored.Token != "" || !stored.ValidateToken(secret, token) || stored.Expired() {
+		tests.Failed("Should have stored only hash of token.")
+	}
+	tests.Passed("Should have stored only hash of token.")
+}
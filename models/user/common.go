@@ -0,0 +1,80 @@
+package user
+
+import "strings"
+
+// commonPasswords contains the lowercase passwords of the common passwords blocklist,
+// drawn from the most frequent passwords found in public breaches.
+var commonPasswords = func() map[string]struct{} {
+	words := strings.Fields(commonPasswordList)
+
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		set[word] = struct{}{}
+	}
+
+	return set
+}()
+
+// commonPasswordList contains the common passwords blocklist separated by spaces.
+const commonPasswordList = `
+0000 00000 000000 007007 01012011 010203 0987654321 101010 102030 1111 11111 111111
+1111111 11111111 111222 112233 11223344 1212 121212 12121212 123123 123123123 1232323q
+123321 1234 12341234 12344321 12345 123456 1234567 12345678 123456789 1234567890
+123456a 123456q 12345a 12345q 1234qwer 123654 123abc 123qwe 12qwaszx 1313 131313 147147
+147258 147258369 159357 159753 1987 1988 1989 1990 1991 1992 1993 1q2w3e4r 1q2w3e4r5t
+1qaz2wsx 1qazxsw2 2000 2112 212121 2222 222222 232323 252525 315475 333333 4444 444444
+4815162342 5150 5555 55555 555555 654321 666666 696969 69696969 7777 777777 7777777
+789456 789456123 8675309 87654321 888888 88888888 987654 987654321 999999 aaaa aaaaaa
+abc123 abc12345 abcd1234 abcdef access action adidas admin administrator airborne
+alaska albert alex alexande alexis amanda america andrea andrew andrey angel angela
+angels animal anthony apollo apple apples arsenal arthur asd123 asdasd asdf asdf1234
+asdfasdf asdfgh asdfghjk asdfghjkl ashley august austin azerty baby babygirl badboy
+badger bailey banana bandit barbara barney baseball baseball1 batman batman1 bear
+beatles beaver beavis beer benjamin bigboy bigdaddy bigdick bigdog bigtits birdie bitch
+bitches biteme black blazer blink182 blowjob blowme blue bollocks bond007 bonnie
+boobies booboo booger boomer boston brandon brandy braves brian bronco broncos brooklyn
+bubba bubbles buddy buffalo bulldog bullshit buster butter butthead calvin camaro
+cameron canada captain carlos carmen carolina caroline carter cartman casper cassie
+celtic champion chance changeme charles charlie cheese chelsea cherry chester chicago
+chicken chris christin cocacola cock coffee compaq computer contrasena cookie cool
+cooper copper corvette cowboy cowboys creative cricket crystal dakota dallas daniel
+danielle darkness dave david debbie december default dennis destiny dexter diablo
+diamond dick dickhead digital doctor doggie dolphin dolphins donald donkey dragon
+dragon1 dreams driver drowssap drummer eagles eclipse edward einstein elephant eminem
+enigma enter explorer falcon family fender ferrari fire fish fishing florida flower
+floyd fluffy flyers football football1 forest forever fred freddy freedom friday friend
+friends fucking fuckme gabriel gandalf garfield gateway gators gemini genesis genius
+george gfhjkm ghbdtn giants gibson ginger girls godzilla golden golf golfer goober
+google gordon green guest guinness guitar gunner hahaha hammer hannah happy hardcore
+harley haslo heather heaven hello hello1 helpme hockey hooters horny horses hotdog
+hotrod hunter iceman iloveyou iloveyou1 internet iwantu jack jackass jackie jackson
+jaguar jake james jasmine jason jasper jennifer jeremy jessica jessie jester john
+johnny johnson jonathan jordan jordan23 joseph joshua junior justin killer kimberly
+kitten klaster knight kristina lacrosse ladies lakers lasvegas lauren legend letmein
+letmein1 lifehack little liverpoo liverpool lol123 london louise love lovely loveme
+lover lovers lozinka lucky maddog madison maggie magic magnum marcus marina marine
+marlboro martin marvin maryjane master matrix matthew maverick maximus maxwell melissa
+member mercedes merlin metallic metallica mexico michael michelle michigan mickey
+midnight mike miller minecraft money monica monkey monkey1 monster montana morgan
+motdepasse mother mountain muffin murphy mustang mustang1 nascar natasha nathan ncc1701
+nelson newyork nicholas nicole nikita nintendo nirvana nissan nothing november oliver
+online orange ou812 p@ssw0rd p@ssword pa55word packers pakistan pantera panther panties
+paradise parker parola pass passw0rd password password1 password123 passwort patrick
+peaches peanut pepper peter phantom phoenix pimpin platinum playboy player please
+pokemon police poohbear pookie porn porsche power prince princess princess1 private
+pumpkin purple q1w2e3 q1w2e3r4 q1w2e3r4t5 qazwsx qazwsxedc qazxsw qqqqqq qwaszx
+qweasdzxc qweqwe qwer1234 qwert qwerty qwerty1 qwerty12 qwerty123 qwertyu qwertyui
+qwertyuiop rabbit rachel racing raiders rainbow ranger rangers razz rebecca red123
+redskins redsox redwings richard robert rocket root rosebud runner rush2112 sabrina
+salasana samantha sammy samson samsung samuel sandra saturn school scooby scooter
+scorpio scorpion scott scotty secret senha sergey sexsex shadow shannon sharon shelby
+shithead sierra silver simple skippy slayer slipknot smokey snickers sniper snoopy
+snowball soccer sophie spanky sparky speedy spencer spider spitfire stalker star
+startrek starwars steelers stella steve steven stupid success suckit summer sunshine
+sunshine1 superman superman1 surfer sydney taylor tennis test tester testing theman
+therock thomas thunder thx1138 tiffany tiger tigers tigger tits tomcat topgun toyota
+travis trinity trouble trustno1 trustno11 tucker turtle united vampire vanessa victor
+victoria viking viper voodoo voyager wachtwoord walter warrior welcome welcome1
+whatever william williams willie willow wilson winner winston winter wizard xavier
+xxxxxx xxxxxxxx yamaha yankees yellow zxcvbn zxcvbnm zzzzzz
+`
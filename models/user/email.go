@@ -0,0 +1,39 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidEmail is returned when an email can not be normalized.
+var ErrInvalidEmail = errors.New("Invalid email address")
+
+// NormalizeEmail returns the email in the form users are stored and retrieved by, where
+// surrounding spaces are removed, the email is lowercased and an internationalized domain
+// is converted to it's ASCII (punycode) form, so the same address always matches the
+// same user. It returns an error matching ErrInvalidEmail if the email is not of the form
+// local@domain.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return "", fmt.Errorf("%q must be of the form local@domain: %w", email, ErrInvalidEmail)
+	}
+
+	local, domain := email[:at], email[at+1:]
+
+	if strings.ContainsAny(local, " \t\r\n") {
+		return "", fmt.Errorf("%q must not contain spaces: %w", email, ErrInvalidEmail)
+	}
+
+	domain, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("%q has an invalid domain: %s: %w", email, err, ErrInvalidEmail)
+	}
+
+	return strings.ToLower(local) + "@" + strings.ToLower(domain), nil
+}
@@ -0,0 +1,366 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// contains the errors returned when hashing and verifying passwords.
+var (
+	// ErrPasswordMismatch is returned when a password does not match a hash.
+	ErrPasswordMismatch = errors.New("Password does not match hash")
+
+	// ErrUnknownHash is returned for hashes of an algorithm no PasswordHasher in Hashers
+	// handles, or which are malformed.
+	ErrUnknownHash = errors.New("Unknown password hash")
+)
+
+// PasswordHasher defines an interface which hashes passwords with a single algorithm,
+// where hashes are in the PHC string format, $<id>$<params>$<salt>$<hash>, recording
+// the algorithm and parameters used so they can be verified after the parameters of the
+// hasher are changed.
+type PasswordHasher interface {
+	// Hash returns the hash of the password with a new random salt.
+	Hash(password string) (string, error)
+
+	// Verify returns nil if the password matches the hash, or an error matching
+	// ErrPasswordMismatch if it does not.
+	Verify(hash string, password string) error
+
+	// Matches returns true/false if the hash is of the algorithm of the hasher.
+	Matches(hash string) bool
+
+	// NeedsRehash returns true/false if the hash was not produced with the current
+	// parameters of the hasher.
+	NeedsRehash(hash string) bool
+}
+
+// Hasher is the PasswordHasher with which New, ChangePassword and Rehash hash passwords,
+// which can be replaced before any users are created. Users whoes hash is of another
+// hasher or parameters are rehashed with Hasher on their next login.
+var Hasher PasswordHasher = DefaultArgon2id
+
+// Hashers contains the PasswordHashers with which Authenticate verifies the hashes of
+// users, where the first to match a hash verifies it.
+var Hashers = []PasswordHasher{DefaultArgon2id, DefaultScrypt, DefaultBcrypt}
+
+// hasherOf returns the hasher which verifies the hash, preferring Hasher.
+func hasherOf(hash string) (PasswordHasher, error) {
+	if Hasher.Matches(hash) {
+		return Hasher, nil
+	}
+
+	for _, hasher := range Hashers {
+		if hasher.Matches(hash) {
+			return hasher, nil
+		}
+	}
+
+	return nil, ErrUnknownHash
+}
+
+// maxHashMemory is the most memory in bytes which hashes are verified with, so a malformed
+// or hostile hash can not exhaust the memory of the process.
+const maxHashMemory = 1 << 30
+
+//====================================================================================================
+
+// DefaultArgon2id defines the Argon2id hasher with the parameters recommended by OWASP.
+var DefaultArgon2id = Argon2id{Time: 2, Memory: 19 * 1024, Threads: 1, SaltLength: 16, KeyLength: 32}
+
+// Argon2id implements PasswordHasher with argon2id, producing hashes of the form
+// $argon2id$v=19$m=<Memory>,t=<Time>,p=<Threads>$<salt>$<hash>. Memory is in KiB.
+type Argon2id struct {
+	Time       uint32
+	Memory     uint32
+	Threads    uint8
+	SaltLength int
+	KeyLength  uint32
+}
+
+// maxArgon2Time is the most passes of argon2id which hashes are verified with.
+const maxArgon2Time = 64
+
+// Hash implements PasswordHasher.
+func (a Argon2id) Hash(password string) (string, error) {
+	salt, err := newSalt(a.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, a.Memory, a.Time, a.Threads, encode(salt), encode(key)), nil
+}
+
+// Verify implements PasswordHasher.
+func (a Argon2id) Verify(hash string, password string) error {
+	parsed, err := parsePHC(hash, "argon2id", "m", "t", "p")
+	if err != nil {
+		return err
+	}
+
+	// argon2 panics for no passes or threads, and needs 8KiB of memory per thread.
+	m, t, p := parsed.Params["m"], parsed.Params["t"], parsed.Params["p"]
+	if parsed.Version != argon2.Version || t < 1 || t > maxArgon2Time || p < 1 || p > 255 || m < 8*p || m > maxHashMemory/1024 {
+		return fmt.Errorf("Unsupported argon2id parameters: %w", ErrUnknownHash)
+	}
+
+	key := argon2.IDKey([]byte(password), parsed.Salt, uint32(t), uint32(m), uint8(p), uint32(len(parsed.Key)))
+
+	return compareKeys(key, parsed.Key)
+}
+
+// Matches implements PasswordHasher.
+func (a Argon2id) Matches(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// NeedsRehash implements PasswordHasher.
+func (a Argon2id) NeedsRehash(hash string) bool {
+	parsed, err := parsePHC(hash, "argon2id", "m", "t", "p")
+	if err != nil {
+		return true
+	}
+
+	return parsed.Version != argon2.Version ||
+		parsed.Params["m"] != int(a.Memory) ||
+		parsed.Params["t"] != int(a.Time) ||
+		parsed.Params["p"] != int(a.Threads) ||
+		len(parsed.Salt) != a.SaltLength ||
+		len(parsed.Key) != int(a.KeyLength)
+}
+
+//====================================================================================================
+
+// DefaultScrypt defines the scrypt hasher with the parameters recommended by OWASP.
+var DefaultScrypt = Scrypt{LogN: 17, R: 8, P: 1, SaltLength: 16, KeyLength: 32}
+
+// Scrypt implements PasswordHasher with scrypt, producing hashes of the form
+// $scrypt$ln=<LogN>,r=<R>,p=<P>$<salt>$<hash>, where N is 2^LogN.
+type Scrypt struct {
+	LogN       int
+	R          int
+	P          int
+	SaltLength int
+	KeyLength  int
+}
+
+// maxScryptParallelism is the most parallelism of scrypt which hashes are verified with.
+const maxScryptParallelism = 16
+
+// Hash implements PasswordHasher.
+func (s Scrypt) Hash(password string) (string, error) {
+	salt, err := newSalt(s.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<uint(s.LogN), s.R, s.P, s.KeyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", s.LogN, s.R, s.P, encode(salt), encode(key)), nil
+}
+
+// Verify implements PasswordHasher.
+func (s Scrypt) Verify(hash string, password string) error {
+	parsed, err := parsePHC(hash, "scrypt", "ln", "r", "p")
+	if err != nil {
+		return err
+	}
+
+	// scrypt uses 128*r*N bytes of memory, and p times the work of a single pass.
+	ln, r, p := parsed.Params["ln"], parsed.Params["r"], parsed.Params["p"]
+	if ln < 1 || ln >= 32 || r < 1 || p < 1 || p > maxScryptParallelism || r > maxHashMemory>>uint(ln)/128 {
+		return fmt.Errorf("Unsupported scrypt parameters: %w", ErrUnknownHash)
+	}
+
+	key, err := scrypt.Key([]byte(password), parsed.Salt, 1<<uint(ln), r, p, len(parsed.Key))
+	if err != nil {
+		return fmt.Errorf("%s: %w", err, ErrUnknownHash)
+	}
+
+	return compareKeys(key, parsed.Key)
+}
+
+// Matches implements PasswordHasher.
+func (s Scrypt) Matches(hash string) bool {
+	return strings.HasPrefix(hash, "$scrypt$")
+}
+
+// NeedsRehash implements PasswordHasher.
+func (s Scrypt) NeedsRehash(hash string) bool {
+	parsed, err := parsePHC(hash, "scrypt", "ln", "r", "p")
+	if err != nil {
+		return true
+	}
+
+	return parsed.Params["ln"] != s.LogN ||
+		parsed.Params["r"] != s.R ||
+		parsed.Params["p"] != s.P ||
+		len(parsed.Salt) != s.SaltLength ||
+		len(parsed.Key) != s.KeyLength
+}
+
+//====================================================================================================
+
+// DefaultBcrypt defines the bcrypt hasher with the cost users were hashed with before
+// hashers were pluggable.
+var DefaultBcrypt = Bcrypt{Cost: 10}
+
+// Bcrypt implements PasswordHasher with bcrypt, producing hashes in bcrypt's own modular
+// crypt format, $2a$<Cost>$<salt and hash>, which the PHC string format extends. As bcrypt
+// only hashes the first 72 bytes of a password, Hash refuses longer passwords instead of
+// silently truncating them, though Verify still verifies hashes of truncated passwords
+// made before. Users refuse passwords which bcrypt would truncate with a PasswordError.
+type Bcrypt struct {
+	Cost int
+}
+
+// maxBcryptLength is the number of bytes of a password which bcrypt hashes.
+const maxBcryptLength = 72
+
+// Hash implements PasswordHasher.
+func (b Bcrypt) Hash(password string) (string, error) {
+	if len(password) > maxBcryptLength {
+		return "", fmt.Errorf("bcrypt hashes at most %d bytes of a password, got %d", maxBcryptLength, len(password))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify implements PasswordHasher.
+func (b Bcrypt) Verify(hash string, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return fmt.Errorf("%s: %w", err, ErrPasswordMismatch)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", err, ErrUnknownHash)
+	}
+
+	return nil
+}
+
+// Matches implements PasswordHasher.
+func (b Bcrypt) Matches(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// NeedsRehash implements PasswordHasher.
+func (b Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.Cost
+}
+
+//====================================================================================================
+
+// phc defines the fields of a hash in the PHC string format.
+type phc struct {
+	Version int
+	Params  map[string]int
+	Salt    []byte
+	Key     []byte
+}
+
+// parsePHC parses the hash of the algorithm id, which must hold all the giving params.
+func parsePHC(hash string, id string, params ...string) (phc, error) {
+	var parsed phc
+
+	parts := strings.Split(hash, "$")
+	if len(parts) < 5 || parts[0] != "" || parts[1] != id {
+		return parsed, fmt.Errorf("Hash is not of %s: %w", id, ErrUnknownHash)
+	}
+
+	fields := parts[2:]
+
+	if strings.HasPrefix(fields[0], "v=") {
+		version, err := strconv.Atoi(strings.TrimPrefix(fields[0], "v="))
+		if err != nil {
+			return parsed, fmt.Errorf("Invalid %s version: %w", id, ErrUnknownHash)
+		}
+
+		parsed.Version = version
+		fields = fields[1:]
+	}
+
+	if len(fields) != 3 {
+		return parsed, fmt.Errorf("Hash must be $%s$<params>$<salt>$<hash> format: %w", id, ErrUnknownHash)
+	}
+
+	parsed.Params = make(map[string]int, len(params))
+
+	for _, param := range strings.Split(fields[0], ",") {
+		pair := strings.SplitN(param, "=", 2)
+		if len(pair) != 2 {
+			return parsed, fmt.Errorf("Invalid %s parameter %q: %w", id, param, ErrUnknownHash)
+		}
+
+		value, err := strconv.Atoi(pair[1])
+		if err != nil || value < 0 {
+			return parsed, fmt.Errorf("Invalid %s parameter %q: %w", id, param, ErrUnknownHash)
+		}
+
+		parsed.Params[pair[0]] = value
+	}
+
+	for _, param := range params {
+		if _, ok := parsed.Params[param]; !ok {
+			return parsed, fmt.Errorf("Missing %s parameter %q: %w", id, param, ErrUnknownHash)
+		}
+	}
+
+	var err error
+
+	if parsed.Salt, err = base64.RawStdEncoding.DecodeString(fields[1]); err != nil {
+		return parsed, fmt.Errorf("Invalid %s salt: %w", id, ErrUnknownHash)
+	}
+
+	if parsed.Key, err = base64.RawStdEncoding.DecodeString(fields[2]); err != nil || len(parsed.Key) == 0 {
+		return parsed, fmt.Errorf("Invalid %s hash: %w", id, ErrUnknownHash)
+	}
+
+	return parsed, nil
+}
+
+// newSalt returns a new random salt of the length.
+func newSalt(length int) ([]byte, error) {
+	salt := make([]byte, length)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
+
+// encode returns the data in the unpadded base64 encoding of the PHC string format.
+func encode(data []byte) string {
+	return base64.RawStdEncoding.EncodeToString(data)
+}
+
+// compareKeys returns an error matching ErrPasswordMismatch if the keys differ, taking
+// constant time.
+func compareKeys(key []byte, expected []byte) error {
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return ErrPasswordMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,276 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ErrWeakPassword is matched by all PasswordErrors.
+var ErrWeakPassword = errors.New("Password does not meet policy")
+
+// PasswordError defines the error returned when a password violates a PasswordPolicy,
+// containing a message for each violated rule, such as "must be at least 10 characters".
+type PasswordError struct {
+	Violations []string
+}
+
+// Error implements the error interface.
+func (p PasswordError) Error() string {
+	return fmt.Sprintf("%s: password %s", ErrWeakPassword, strings.Join(p.Violations, ", "))
+}
+
+// Is reports if the target is ErrWeakPassword, allowing errors.Is to match all
+// PasswordErrors.
+func (p PasswordError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
+
+// DefaultPasswordPolicy defines the PasswordPolicy enforced by New and ChangePassword.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    10,
+	MaxLength:    64,
+	MinClasses:   2,
+	MinScore:     3,
+	RejectCommon: true,
+	RejectEmail:  true,
+}
+
+// PasswordPolicy defines the rules passwords must meet, where the zero value of each
+// rule disables it.
+type PasswordPolicy struct {
+	// MinLength and MaxLength are the bounds of the number of characters of passwords.
+	MinLength int
+	MaxLength int
+
+	// MinClasses is the number of character classes, of lowercase letters, uppercase
+	// letters, digits and symbols, which passwords must contain.
+	MinClasses int
+
+	// MinScore is the lowest Score from 0 to 4 which passwords must have.
+	MinScore int
+
+	// RejectCommon rejects passwords found within the common passwords blocklist, along
+	// with those which only add digits or symbols before or after one.
+	RejectCommon bool
+
+	// RejectEmail rejects passwords containing the email, or the local part of the email,
+	// of their user.
+	RejectEmail bool
+}
+
+// Validate returns a PasswordError listing all rules of the policy which the password of
+// the user with the giving email violates, or nil if it violates none.
+func (p PasswordPolicy) Validate(password string, email string) error {
+	var violations []string
+
+	length := len([]rune(password))
+
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters", p.MaxLength))
+	}
+
+	if p.MinClasses > 0 && classesOf(password) < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("must contain %d of lowercase letters, uppercase letters, digits and symbols", p.MinClasses))
+	}
+
+	if p.RejectEmail && containsEmail(password, email) {
+		violations = append(violations, "must not contain the email")
+	}
+
+	if p.RejectCommon && Common(password) {
+		violations = append(violations, "is too common")
+	} else if p.MinScore > 0 && Score(password) < p.MinScore {
+		violations = append(violations, "is too easy to guess")
+	}
+
+	if len(violations) != 0 {
+		return PasswordError{Violations: violations}
+	}
+
+	return nil
+}
+
+// containsEmail returns true/false if the password contains the email, or it's local part
+// of at least 3 characters, ignoring case.
+func containsEmail(password string, email string) bool {
+	password = strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if email == "" {
+		return false
+	}
+
+	if strings.Contains(password, email) {
+		return true
+	}
+
+	local := email
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		local = email[:at]
+	}
+
+	return len(local) >= 3 && strings.Contains(password, local)
+}
+
+// classes of the characters of passwords.
+const (
+	classLower = 1 << iota
+	classUpper
+	classDigit
+	classSymbol
+	classOther
+)
+
+// classOf returns the class of the character.
+func classOf(r rune) int {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return classLower
+	case r >= 'A' && r <= 'Z':
+		return classUpper
+	case r >= '0' && r <= '9':
+		return classDigit
+	case r < unicode.MaxASCII:
+		return classSymbol
+	case unicode.IsLower(r):
+		return classLower
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsDigit(r):
+		return classDigit
+	case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+		return classSymbol
+	}
+
+	return classOther
+}
+
+// classesOf returns the number of character classes of lowercase letters, uppercase letters,
+// digits and symbols within the password.
+func classesOf(password string) int {
+	var mask int
+	for _, r := range password {
+		mask |= classOf(r)
+	}
+
+	var total int
+	for _, class := range []int{classLower, classUpper, classDigit, classSymbol} {
+		if mask&class != 0 {
+			total++
+		}
+	}
+
+	return total
+}
+
+// Score returns the strength of the password from 0, too guessable, to 4, very unguessable,
+// in the manner of zxcvbn. The guesses needed are estimated from the characters the password
+// draws from and it's length, where characters which repeat or continue a sequence from the
+// previous character, such as "aaa", "abc" or "321", add little, and common passwords
+// within the password count as a single character. The scores are given at 10^3, 10^6, 10^8
+// and 10^10 guesses as with zxcvbn.
+func Score(password string) int {
+	bits := entropy(password)
+
+	switch {
+	case bits < 10:
+		return 0
+	case bits < 20:
+		return 1
+	case bits < 26.6:
+		return 2
+	case bits < 33.2:
+		return 3
+	}
+
+	return 4
+}
+
+// entropy returns the estimated bits of entropy of the password.
+func entropy(password string) float64 {
+	if password == "" {
+		return 0
+	}
+
+	var mask int
+	for _, r := range password {
+		mask |= classOf(r)
+	}
+
+	var pool float64
+	for class, size := range map[int]float64{classLower: 26, classUpper: 26, classDigit: 10, classSymbol: 33, classOther: 100} {
+		if mask&class != 0 {
+			pool += size
+		}
+	}
+
+	runes := []rune(strings.ToLower(password))
+	weights := make([]float64, len(runes))
+
+	for i, r := range runes {
+		weights[i] = 1
+
+		if i > 0 {
+			if delta := r - runes[i-1]; delta >= -1 && delta <= 1 {
+				weights[i] = 0.25
+			}
+		}
+	}
+
+	// Common passwords within the password are as guessable as a single character.
+	lower := string(runes)
+	for word := range commonPasswords {
+		if len(word) < 4 {
+			continue
+		}
+
+		for start := strings.Index(lower, word); start >= 0; {
+			at := len([]rune(lower[:start]))
+			for i := at + 1; i < at+len([]rune(word)); i++ {
+				weights[i] = 0
+			}
+
+			next := strings.Index(lower[start+len(word):], word)
+			if next < 0 {
+				break
+			}
+
+			start += len(word) + next
+		}
+	}
+
+	var length float64
+	for _, weight := range weights {
+		length += weight
+	}
+
+	return length * math.Log2(pool)
+}
+
+// Common returns true/false if the password, ignoring case, is found within the common
+// passwords blocklist, either as is or without digits and symbols added before or after it.
+func Common(password string) bool {
+	lower := strings.ToLower(password)
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+
+	trimmed := strings.TrimFunc(lower, func(r rune) bool {
+		class := classOf(r)
+		return class == classDigit || class == classSymbol
+	})
+
+	if trimmed == "" || trimmed == lower {
+		return false
+	}
+
+	_, ok := commonPasswords[trimmed]
+	return ok
+}
@@ -2,22 +2,23 @@ package user
 
 import (
 	"errors"
-
-	"golang.org/x/crypto/bcrypt"
+	"fmt"
+	"strings"
 
 	"github.com/influx6/backoffice/models/profile"
 	uuid "github.com/satori/go.uuid"
 )
 
 const (
-	hashComplexity = 10
-	tableName      = "users"
-	timeFormat     = "Mon Jan 2 15:04:05 -0700 MST 2006"
+	tableName  = "users"
+	timeFormat = "Mon Jan 2 15:04:05 -0700 MST 2006"
 )
 
-// UpdateUserPassword defines the set of data sent when updating a users password.
+// UpdateUserPassword defines the set of data sent when updating a users password, where
+// CurrentPassword is the password being replaced.
 type UpdateUserPassword struct {
 	PublicID        string `json:"public_id"`
+	CurrentPassword string `json:"current_password"`
 	Password        string `json:"password"`
 	PasswordConfirm string `json:"password_confirm"`
 }
@@ -45,37 +46,146 @@ func (u UpdateUser) Table() string {
 
 //====================================================================================================
 
+// UpdateUserRoles defines the set of data sent when updating the roles of a user.
+type UpdateUserRoles struct {
+	PublicID string   `json:"public_id"`
+	Roles    []string `json:"roles"`
+}
+
+// Fields returns a map representing the data of the user's roles.
+func (u UpdateUserRoles) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"roles":     joinRoles(u.Roles),
+		"public_id": u.PublicID,
+	}
+}
+
+// Table returns the given table which the given struct corresponds to.
+func (u UpdateUserRoles) Table() string {
+	return tableName
+}
+
+//====================================================================================================
+
+// UpdateUserVerified defines the set of data sent when updating if a user verified it's email.
+type UpdateUserVerified struct {
+	PublicID string `json:"public_id"`
+	Verified bool   `json:"verified"`
+}
+
+// Fields returns a map representing the data of the user's verification.
+func (u UpdateUserVerified) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"verified":  u.Verified,
+		"public_id": u.PublicID,
+	}
+}
+
+// Table returns the given table which the given struct corresponds to.
+func (u UpdateUserVerified) Table() string {
+	return tableName
+}
+
+//====================================================================================================
+
+// UpdateUserHash defines the set of data sent when replacing the hash of a user's password,
+// such as after Rehash.
+type UpdateUserHash struct {
+	PublicID string `json:"public_id"`
+	Hash     string `json:"-"`
+}
+
+// Fields returns a map representing the data of the user's hash.
+func (u UpdateUserHash) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"hash":      u.Hash,
+		"public_id": u.PublicID,
+	}
+}
+
+// Table returns the given table which the given struct corresponds to.
+func (u UpdateUserHash) Table() string {
+	return tableName
+}
+
+//====================================================================================================
+
 // NewUser defines the set of data received to create a new user.
 type NewUser struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
-// User is a type defining the given user related fields for a given.
+// User is a type defining the given user related fields for a given. Roles name the
+// roles of the user, which grant it permissions through a policy. Verified reports if the
+// user proved it owns it's email.
 type User struct {
 	Email     string           `json:"email"`
 	PublicID  string           `json:"public_id"`
 	PrivateID string           `json:"private_id,omitempty"`
 	Hash      string           `json:"hash,omitempty"`
+	Verified  bool             `json:"verified"`
+	Roles     []string         `json:"roles,omitempty"`
 	Profile   *profile.Profile `json:"profile,omitempty"`
 }
 
-// New returns a new User instance based on the provided data.
+// New returns a new User instance based on the provided data, where the email is
+// normalized with NormalizeEmail and the password must meet DefaultPasswordPolicy.
 func New(nw NewUser) (*User, error) {
+	return NewWithPolicy(nw, DefaultPasswordPolicy)
+}
+
+// NewWithPolicy is the same as New but the password must meet the provided policy.
+func NewWithPolicy(nw NewUser, policy PasswordPolicy) (*User, error) {
+	email, err := NormalizeEmail(nw.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	var u User
-	u.Email = nw.Email
+	u.Email = email
 	u.PublicID = uuid.NewV4().String()
 	u.PrivateID = uuid.NewV4().String()
 
-	u.ChangePassword(nw.Password)
+	if err := u.ChangePasswordWithPolicy(nw.Password, policy); err != nil {
+		return nil, err
+	}
 
 	return &u, nil
 }
 
-// Authenticate attempts to authenticate the giving password to the provided user.
+// Authenticate attempts to authenticate the giving password to the provided user, with
+// the hasher of Hashers matching the user's hash. It returns an error matching
+// ErrPasswordMismatch if the password does not match.
 func (u User) Authenticate(password string) error {
-	pass := []byte(u.PrivateID + ":" + password)
-	return bcrypt.CompareHashAndPassword([]byte(u.Hash), pass)
+	hasher, err := hasherOf(u.Hash)
+	if err != nil {
+		return err
+	}
+
+	return hasher.Verify(u.Hash, u.PrivateID+":"+password)
+}
+
+// NeedsRehash returns true/false if the user's hash was not produced by Hasher with it's
+// current parameters.
+func (u User) NeedsRehash() bool {
+	return !Hasher.Matches(u.Hash) || Hasher.NeedsRehash(u.Hash)
+}
+
+// Rehash hashes the password again with Hasher if NeedsRehash, returning true if the hash
+// was replaced. It must only be called with a password which Authenticate accepted, and
+// does not check the password against any PasswordPolicy, so users whoes password predates the policy
+// can still log in.
+func (u *User) Rehash(password string) (bool, error) {
+	if !u.NeedsRehash() {
+		return false, nil
+	}
+
+	if err := u.setPassword(password); err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
 // Table returns the given table which the given struct corresponds to.
@@ -84,12 +194,17 @@ func (u User) Table() string {
 }
 
 // SafeFields returns a map representing the data of the user with important
-// security fields removed.
+// security fields removed, holding the roles of the user as a list.
 func (u User) SafeFields() map[string]interface{} {
 	fields := u.Fields()
 
 	delete(fields, "hash")
 	delete(fields, "private_id")
+	delete(fields, "roles")
+
+	if len(u.Roles) != 0 {
+		fields["roles"] = u.Roles
+	}
 
 	return fields
 }
@@ -101,6 +216,8 @@ func (u User) Fields() map[string]interface{} {
 		"email":      u.Email,
 		"private_id": u.PrivateID,
 		"public_id":  u.PublicID,
+		"roles":      joinRoles(u.Roles),
+		"verified":   u.Verified,
 	}
 
 	if u.Profile != nil {
@@ -110,15 +227,38 @@ func (u User) Fields() map[string]interface{} {
 	return fields
 }
 
-// ChangePassword uses the provided password to set the users password hash.
+// ChangePassword uses the provided password to set the users password hash, returning a
+// PasswordError if the password does not meet DefaultPasswordPolicy for the user's email.
 func (u *User) ChangePassword(password string) error {
-	pass := []byte(u.PrivateID + ":" + password)
-	hash, err := bcrypt.GenerateFromPassword(pass, hashComplexity)
+	return u.ChangePasswordWithPolicy(password, DefaultPasswordPolicy)
+}
+
+// ChangePasswordWithPolicy is the same as ChangePassword but the password must meet the
+// provided policy.
+func (u *User) ChangePasswordWithPolicy(password string, policy PasswordPolicy) error {
+	if err := policy.Validate(password, u.Email); err != nil {
+		return err
+	}
+
+	return u.setPassword(password)
+}
+
+// setPassword sets the users password hash with Hasher, where the password is hashed
+// along with the user's private id. As bcrypt hashes at most 72 bytes, of which the
+// private id takes it's share, longer passwords are refused with a PasswordError.
+func (u *User) setPassword(password string) error {
+	salted := u.PrivateID + ":" + password
+
+	if _, ok := Hasher.(Bcrypt); ok && len(salted) > maxBcryptLength {
+		return PasswordError{Violations: []string{fmt.Sprintf("must be at most %d bytes", maxBcryptLength-len(salted)+len(password))}}
+	}
+
+	hash, err := Hasher.Hash(salted)
 	if err != nil {
 		return err
 	}
 
-	u.Hash = string(hash)
+	u.Hash = hash
 	return nil
 }
 
@@ -149,5 +289,39 @@ func (u *User) WithFields(fields map[string]interface{}) error {
 		return errors.New("Expected 'hash' key")
 	}
 
+	// Users created before roles were recorded hold none.
+	if roles, ok := fields["roles"].(string); ok {
+		u.Roles = splitRoles(roles)
+	}
+
+	// Users created before emails were verified are unverified, where drivers return
+	// booleans as integers or strings for some dialects.
+	switch verified := fields["verified"].(type) {
+	case bool:
+		u.Verified = verified
+	case int64:
+		u.Verified = verified != 0
+	case string:
+		u.Verified = verified == "1" || verified == "true"
+	}
+
 	return nil
 }
+
+// joinRoles returns the roles as stored, separated by commas.
+func joinRoles(roles []string) string {
+	return strings.Join(roles, ",")
+}
+
+// splitRoles returns the roles of their stored form.
+func splitRoles(roles string) []string {
+	var split []string
+
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			split = append(split, role)
+		}
+	}
+
+	return split
+}
@@ -1,6 +1,8 @@
 package user_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/influx6/backoffice/models/user"
@@ -25,13 +27,32 @@ func TestUserWithField(t *testing.T) {
 		tests.Failed("Should have matched expected email on user.")
 	}
 	tests.Passed("Should have matched expected email on user.")
+
+	if len(nw.Roles) != 0 {
+		tests.Failed("Should have no roles on user stored without roles.")
+	}
+	tests.Passed("Should have no roles on user stored without roles.")
+
+	nw.Roles = []string{"admin", "auditor"}
+
+	var loaded user.User
+	if err := loaded.WithFields(nw.Fields()); err != nil {
+		tests.Failed("Should have successfully loaded user with roles: %+q.", err)
+	}
+	tests.Passed("Should have successfully loaded user with roles.")
+
+	if len(loaded.Roles) != 2 || loaded.Roles[0] != "admin" || loaded.Roles[1] != "auditor" {
+		tests.Info("Roles: %+q", loaded.Roles)
+		tests.Failed("Should have matched expected roles on user.")
+	}
+	tests.Passed("Should have matched expected roles on user.")
 }
 
 // TestUser validates the methods and returns attached to the user model.
 func TestUser(t *testing.T) {
 	oldUser, err := user.New(user.NewUser{
 		Email:    "bob@guma.com",
-		Password: "glow",
+		Password: "Glow-Worm-Lantern-42",
 	})
 
 	if err != nil {
@@ -65,8 +86,219 @@ func TestUser(t *testing.T) {
 
 	}
 
-	if err := oldUser.Authenticate("glow"); err != nil {
+	if err := oldUser.Authenticate("Glow-Worm-Lantern-42"); err != nil {
 		tests.Failed("Should have successfully authenticated with provided password: %+q.", err)
 	}
 	tests.Passed("Should have successfully authenticated with provided password.")
 }
+
+// TestNormalizeEmail validates the normalized form of emails.
+func TestNormalizeEmail(t *testing.T) {
+	for email, normalized := range map[string]string{
+		"bob@guma.com":       "bob@guma.com",
+		"  Bob@GUMA.com\t":   "bob@guma.com",
+		"bob+news@Guma.com":  "bob+news@guma.com",
+		"bob@Bücher.example": "bob@xn--bcher-kva.example",
+	} {
+		got, err := user.NormalizeEmail(email)
+		if err != nil {
+			tests.Failed("Should have successfully normalized %q: %+q.", email, err)
+		}
+
+		if got != normalized {
+			tests.Info("Normalized: %q", got)
+			tests.Failed("Should have normalized %q to %q.", email, normalized)
+		}
+		tests.Passed("Should have normalized %q to %q.", email, normalized)
+	}
+
+	for _, email := range []string{"", "bob", "@guma.com", "bob@", "bo b@guma.com"} {
+		if _, err := user.NormalizeEmail(email); !errors.Is(err, user.ErrInvalidEmail) {
+			tests.Failed("Should have rejected %q with ErrInvalidEmail: %+q.", email, err)
+		}
+		tests.Passed("Should have rejected %q with ErrInvalidEmail.", email)
+	}
+}
+
+// TestPasswordPolicy validates the rules of the default password policy.
+func TestPasswordPolicy(t *testing.T) {
+	policy := user.DefaultPasswordPolicy
+
+	for _, password := range []string{"Glow-Worm-Lantern-42", "correct horse battery staple", "Tr0ub4dor&3xyz"} {
+		if err := policy.Validate(password, "bob@guma.com"); err != nil {
+			tests.Failed("Should have accepted %q: %+q.", password, err)
+		}
+		tests.Passed("Should have accepted %q.", password)
+	}
+
+	for password, violation := range map[string]string{
+		"glow":                     "must be at least 10 characters",
+		strings.Repeat("Ab1-", 17): "must be at most 64 characters",
+		"glowwormlantern":          "must contain 2 of lowercase letters, uppercase letters, digits and symbols",
+		"Password123!":             "is too common",
+		"qwertyuiop":               "is too common",
+		"aaaaaaaaaaaaaaa1":         "is too easy to guess",
+		"abcdefghijk12345":         "is too easy to guess",
+		"Bob@guma.com-2024":        "must not contain the email",
+		"I-am-bob-the-builder":     "must not contain the email",
+	} {
+		err := policy.Validate(password, "bob@guma.com")
+		if !errors.Is(err, user.ErrWeakPassword) {
+			tests.Failed("Should have rejected %q with ErrWeakPassword: %+q.", password, err)
+		}
+
+		var weak user.PasswordError
+		if !errors.As(err, &weak) || !contains(weak.Violations, violation) {
+			tests.Info("Violations: %q", weak.Violations)
+			tests.Failed("Should have rejected %q as %q.", password, violation)
+		}
+		tests.Passed("Should have rejected %q as %q.", password, violation)
+	}
+
+	if _, err := user.New(user.NewUser{Email: "bob@guma.com", Password: "glow"}); !errors.Is(err, user.ErrWeakPassword) {
+		tests.Failed("Should have refused to create user with weak password: %+q.", err)
+	}
+	tests.Passed("Should have refused to create user with weak password.")
+
+	if err := (user.PasswordPolicy{}).Validate("glow", "bob@guma.com"); err != nil {
+		tests.Failed("Should have accepted any password with empty policy: %+q.", err)
+	}
+	tests.Passed("Should have accepted any password with empty policy.")
+}
+
+// TestScore validates the strength scores of passwords.
+func TestScore(t *testing.T) {
+	for password, score := range map[string]int{
+		"":                             0,
+		"aaaaaaaa":                     0,
+		"password":                     0,
+		"glow":                         1,
+		"correct horse battery staple": 4,
+	} {
+		if got := user.Score(password); got != score {
+			tests.Failed("Should have scored %q %d but got %d.", password, score, got)
+		}
+		tests.Passed("Should have scored %q %d.", password, score)
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestPasswordHashers validates the hashes of each PasswordHasher.
+func TestPasswordHashers(t *testing.T) {
+	for prefix, hasher := range map[string]user.PasswordHasher{
+		"$argon2id$v=19$m=1024,t=1,p=1$": user.Argon2id{Time: 1, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32},
+		"$scrypt$ln=10,r=8,p=1$":         user.Scrypt{LogN: 10, R: 8, P: 1, SaltLength: 16, KeyLength: 32},
+		"$2a$04$":                        user.Bcrypt{Cost: 4},
+	} {
+		hash, err := hasher.Hash("Glow-Worm-Lantern-42")
+		if err != nil {
+			tests.Failed("Should have successfully hashed password with %q: %+q.", prefix, err)
+		}
+
+		if !strings.HasPrefix(hash, prefix) || !hasher.Matches(hash) {
+			tests.Info("Hash: %s", hash)
+			tests.Failed("Should have recorded algorithm and parameters as %q.", prefix)
+		}
+		tests.Passed("Should have recorded algorithm and parameters as %q.", prefix)
+
+		if err := hasher.Verify(hash, "Glow-Worm-Lantern-42"); err != nil {
+			tests.Failed("Should have verified password with %q: %+q.", prefix, err)
+		}
+
+		if err := hasher.Verify(hash, "Glowing-Ember-Coal-36"); !errors.Is(err, user.ErrPasswordMismatch) {
+			tests.Failed("Should have refused wrong password with %q: %+q.", prefix, err)
+		}
+		tests.Passed("Should have verified only matching password with %q.", prefix)
+
+		if hasher.NeedsRehash(hash) {
+			tests.Failed("Should not have rehashed hash of current parameters with %q.", prefix)
+		}
+		tests.Passed("Should not have rehashed hash of current parameters with %q.", prefix)
+	}
+
+	if !(user.Argon2id{Time: 2, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32}).NeedsRehash("$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5") {
+		tests.Failed("Should have rehashed hash of lower cost.")
+	}
+	tests.Passed("Should have rehashed hash of lower cost.")
+
+	for _, hash := range []string{
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=8,t=1,p=2$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$scrypt$ln=10,r=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$scrypt$ln=10,r=8,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$scrypt$ln=30,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+	} {
+		hasher := user.PasswordHasher(user.DefaultArgon2id)
+		if user.DefaultScrypt.Matches(hash) {
+			hasher = user.DefaultScrypt
+		}
+
+		if err := hasher.Verify(hash, "Glow-Worm-Lantern-42"); !errors.Is(err, user.ErrUnknownHash) {
+			tests.Info("Error: %+q", err)
+			tests.Failed("Should have refused hash of unsupported parameters %q.", hash)
+		}
+		tests.Passed("Should have refused hash of unsupported parameters %q.", hash)
+	}
+
+	if _, err := (user.Bcrypt{Cost: 4}).Hash(strings.Repeat("a", 73)); err == nil {
+		tests.Failed("Should have refused to truncate password over 72 bytes with bcrypt.")
+	}
+	tests.Passed("Should have refused to truncate password over 72 bytes with bcrypt.")
+
+	defer func(hasher user.PasswordHasher) { user.Hasher = hasher }(user.Hasher)
+	user.Hasher = user.Bcrypt{Cost: 4}
+
+	if _, err := user.New(user.NewUser{Email: "bob@guma.com", Password: "Glowing-Ember-Coal-36-Under-Bright-Moon"}); !errors.Is(err, user.ErrWeakPassword) {
+		tests.Info("Error: %+q", err)
+		tests.Failed("Should have refused password bcrypt would truncate with the private id.")
+	}
+	tests.Passed("Should have refused password bcrypt would truncate with the private id.")
+}
+
+// TestUserRehash validates that hashes of an outdated hasher are replaced.
+func TestUserRehash(t *testing.T) {
+	current := user.Hasher
+	defer func() { user.Hasher = current }()
+
+	user.Hasher = user.Bcrypt{Cost: 4}
+
+	nw, err := user.New(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	user.Hasher = user.Argon2id{Time: 1, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32}
+
+	if err := nw.Authenticate("Glow-Worm-Lantern-42"); err != nil {
+		tests.Failed("Should have authenticated with hash of outdated hasher: %+q.", err)
+	}
+	tests.Passed("Should have authenticated with hash of outdated hasher.")
+
+	if rehashed, err := nw.Rehash("Glow-Worm-Lantern-42"); err != nil || !rehashed {
+		tests.Failed("Should have rehashed outdated hash: %+q.", err)
+	}
+	tests.Passed("Should have rehashed outdated hash.")
+
+	if !strings.HasPrefix(nw.Hash, "$argon2id$") || nw.NeedsRehash() {
+		tests.Info("Hash: %s", nw.Hash)
+		tests.Failed("Should have rehashed with current hasher.")
+	}
+	tests.Passed("Should have rehashed with current hasher.")
+
+	if err := nw.Authenticate("Glow-Worm-Lantern-42"); err != nil {
+		tests.Failed("Should have authenticated with new hash: %+q.", err)
+	}
+	tests.Passed("Should have authenticated with new hash.")
+}
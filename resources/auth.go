@@ -2,9 +2,11 @@ package resources
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/influx6/backoffice/auth"
+	"github.com/influx6/backoffice/db"
 	"github.com/influx6/backoffice/handlers"
 	"github.com/influx6/backoffice/utils"
 	"github.com/influx6/faux/sink"
@@ -13,10 +15,46 @@ import (
 )
 
 // Auth defines an handler which provides authorization handling for
-// a request, needing user authentication.
+// a request, needing user authentication. If Permission is set, the user must also be
+// granted it over the records owned by the user returned by Owner. The handlers.Principal
+// of the request is attached to the request context given to Next.
 type Auth struct {
 	handlers.BearerAuth
 	Next func(w http.ResponseWriter, r *http.Request, params map[string]string)
+
+	Permission string
+	Owner      Owner
+}
+
+// Owner defines a function returning the public id of the user owning the records acted
+// on by a request.
+type Owner func(r *http.Request, params map[string]string) (string, error)
+
+// OwnerParam returns an Owner returning the param of the giving name.
+func OwnerParam(name string) Owner {
+	return func(r *http.Request, params map[string]string) (string, error) {
+		return params[name], nil
+	}
+}
+
+// OwnerSelf is an Owner returning the user of the request's handlers.Principal, for
+// routes which only act on the records of the user making the request.
+func OwnerSelf(r *http.Request, params map[string]string) (string, error) {
+	principal, ok := handlers.PrincipalFrom(r.Context())
+	if !ok {
+		return "", nil
+	}
+
+	return principal.User.PublicID, nil
+}
+
+// Require returns a copy of the Auth which requires the permission of the user over the
+// records owned by the user returned by owner, where owner is nil for permissions only
+// granted with the any scope.
+func (u Auth) Require(permission string, owner Owner) Auth {
+	u.Permission = permission
+	u.Owner = owner
+	return u
 }
 
 // CheckAuthorization handles receiving requests to verify user authorization.
@@ -27,7 +65,7 @@ Header:
 			"Authorization":"Bearer <TOKEN>",
 		}
 
-		WHERE: <TOKEN> = <USERID>:<SESSIONTOKEN>
+		WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
 */
 func (u Auth) CheckAuthorization(w http.ResponseWriter, r *http.Request, params map[string]string) {
 	defer u.Log.Emit(sinks.Info("Authenticate Authorization").WithFields(sink.Fields{
@@ -37,14 +75,15 @@ func (u Auth) CheckAuthorization(w http.ResponseWriter, r *http.Request, params
 	}).Trace("Auth.CheckAuthorization").End())
 
 	// Retrieve authorization header.
-	if err := u.BearerAuth.CheckAuthorization(r.Header.Get("Authorization")); err != nil {
+	principal, err := u.check(r, params)
+	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Invalid Auth: Failed to validate authorization", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Invalid Auth: Failed to validate authorization", err)
 		return
 	}
 
@@ -52,7 +91,32 @@ func (u Auth) CheckAuthorization(w http.ResponseWriter, r *http.Request, params
 		return
 	}
 
-	u.Next(w, r, params)
+	u.Next(w, r.WithContext(handlers.WithPrincipal(r.Context(), principal)), params)
+}
+
+// check returns the principal of the request's authorization, which must be granted the
+// Permission if it is set.
+func (u Auth) check(r *http.Request, params map[string]string) (*handlers.Principal, error) {
+	principal, err := u.BearerAuth.PrincipalCtx(r.Context(), r.Header.Get("Authorization"))
+	if err != nil || u.Permission == "" {
+		return principal, err
+	}
+
+	var owner string
+
+	// Records without an owner are only acted on with permissions of any scope, where
+	// their absence is left for the next handler to report.
+	if u.Owner != nil {
+		if owner, err = u.Owner(r.WithContext(handlers.WithPrincipal(r.Context(), principal)), params); err != nil && !errors.Is(err, db.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	if !principal.Allows(u.Permission, owner) {
+		return nil, fmt.Errorf("User is not granted %q: %w", u.Permission, handlers.ErrForbidden)
+	}
+
+	return principal, nil
 }
 
 //==================================================================================================================================================================
@@ -0,0 +1,27 @@
+package resources
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/handlers"
+)
+
+// statusOf returns the http status code for an error returned by the handlers.
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, handlers.ErrValidation), errors.Is(err, db.ErrInvalidQuery):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, handlers.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, handlers.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, db.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, db.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
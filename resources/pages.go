@@ -0,0 +1,82 @@
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/handlers"
+)
+
+// contains the query-string names used by cursor pagination.
+const (
+	CursorName = "cursor"
+	LimitName  = "limit"
+
+	// DefaultPageLimit is the number of records returned per page of cursor pagination
+	// if none is requested.
+	DefaultPageLimit = 20
+)
+
+// wantsCursor reports if the request asks for a page of records by cursor, which it
+// does by providing the cursor param, left empty for the first page.
+func wantsCursor(r *http.Request) bool {
+	_, ok := r.URL.Query()[CursorName]
+	return ok
+}
+
+// cursorPage returns the cursor and limit of a request for a page of records by cursor,
+// where the :total param is used as the limit unless the limit param is provided.
+func cursorPage(r *http.Request, params map[string]string) (string, int, error) {
+	values := r.URL.Query()
+	cursor := values.Get(CursorName)
+
+	if cursor != "" {
+		if _, err := db.DecodeCursor(cursor); err != nil {
+			return "", 0, handlers.Invalid(CursorName, "is invalid")
+		}
+	}
+
+	limit := DefaultPageLimit
+
+	if total, err := strconv.Atoi(params[ResponsePerPageName]); err == nil && total > 0 {
+		limit = total
+	}
+
+	if value := values.Get(LimitName); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return "", 0, handlers.Invalid(LimitName, "must be a positive integer")
+		}
+
+		limit = parsed
+	}
+
+	return cursor, limit, nil
+}
+
+// setPageLinks sets the Link header of the response to the next and previous pages of
+// records, keeping all other query-string params of the request.
+func setPageLinks(w http.ResponseWriter, r *http.Request, limit int, next string, prev string) {
+	var links []string
+
+	for _, link := range []struct{ rel, cursor string }{{"next", next}, {"prev", prev}} {
+		if link.cursor == "" {
+			continue
+		}
+
+		values := r.URL.Query()
+		values.Set(CursorName, link.cursor)
+		values.Set(LimitName, strconv.Itoa(limit))
+
+		target := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", target.String(), link.rel))
+	}
+
+	if len(links) != 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -20,6 +20,16 @@ type Profiles struct {
 	Sessions handlers.Sessions
 }
 
+// owner returns the public id of the user owning the profile of the public_id param.
+func (u Profiles) owner(r *http.Request, params map[string]string) (string, error) {
+	nu, err := u.Profiles.GetCtx(r.Context(), params["public_id"])
+	if err != nil {
+		return "", err
+	}
+
+	return nu.UserID, nil
+}
+
 // GetForUser handles receiving requests to get a user's profile from the backend.
 /* Service API
 	HTTP Method: GET
@@ -28,10 +38,10 @@ type Profiles struct {
 				"Authorization":"Bearer <TOKEN>",
 			}
 
-			WHERE: <TOKEN> = <USERID>:<SESSIONTOKEN>
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
 
 	Request:
-		Path: /profile/users/:user_id
+		Path: /profiles/users/:user_id
 		Body: None
 
    Response: (Success, 200)
@@ -43,12 +53,13 @@ type Profiles struct {
 			"email":"",
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Profiles) GetForUser(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -68,18 +79,18 @@ func (u Profiles) GetForUser(w http.ResponseWriter, r *http.Request, params map[
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read `user_id` in params", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
-	nu, err := u.Profiles.GetByUser(userID)
+	nu, err := u.Profiles.GetByUserCtx(r.Context(), userID)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve user's profile", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve user's profile", err)
 		return
 	}
 
@@ -104,7 +115,7 @@ func (u Profiles) GetForUser(w http.ResponseWriter, r *http.Request, params map[
 				"Authorization":"Bearer <TOKEN>",
 			}
 
-			WHERE: <TOKEN> = <USERID>:<SESSIONTOKEN>
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
 
 	Request:
 		Path: /profiles/:public_id
@@ -119,12 +130,13 @@ func (u Profiles) GetForUser(w http.ResponseWriter, r *http.Request, params map[
 			"email":"",
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Profiles) Get(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -143,18 +155,18 @@ func (u Profiles) Get(w http.ResponseWriter, r *http.Request, params map[string]
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
-	nu, err := u.Profiles.Get(publicID)
+	nu, err := u.Profiles.GetCtx(r.Context(), publicID)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve user profile", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve user profile", err)
 		return
 	}
 
@@ -179,13 +191,21 @@ func (u Profiles) Get(w http.ResponseWriter, r *http.Request, params map[string]
 				"Authorization":"Bearer <TOKEN>",
 			}
 
-			WHERE: <TOKEN> = <USERID>:<SESSIONTOKEN>
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
 
 	Request:
-		Path: /admin/profiles/
+		Path: /admin/profiles
+		Path: /admin/profiles/:total/:page
+		Query: (Optional)
+			cursor=<CURSOR>&limit=<N>	pages by cursor, where limit overrides the :total param
+
+			WHERE: an empty cursor requests the first page of records ordered by their creation,
+			where the next and prev cursors of each page are returned in the body and Link header.
 		Body: None
 
    Response: (Success, 200)
+	Header:
+		Link: <NEXT_URL>; rel="next", <PREV_URL>; rel="prev"
 	Body:
 		{
 			page: 1,
@@ -198,15 +218,18 @@ func (u Profiles) Get(w http.ResponseWriter, r *http.Request, params map[string]
 				"profile_id":"",
 				"email":"",
 				"address":"",
-			}]
+			}],
+			next: "<CURSOR>",
+			prev: "<CURSOR>",
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Profiles) GetAll(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -219,17 +242,32 @@ func (u Profiles) GetAll(w http.ResponseWriter, r *http.Request, params map[stri
 	responsePerPage, _ := strconv.Atoi(params[ResponsePerPageName])
 	page, _ := strconv.Atoi(params[PerPageName])
 
-	nus, err := u.Profiles.GetAll(page, responsePerPage)
+	var nus handlers.ProfileRecords
+	var err error
+
+	if wantsCursor(r) {
+		var cursor string
+		var limit int
+
+		if cursor, limit, err = cursorPage(r, params); err == nil {
+			nus, err = u.Profiles.GetPageCtx(r.Context(), cursor, limit)
+		}
+	} else {
+		nus, err = u.Profiles.GetAllCtx(r.Context(), page, responsePerPage)
+	}
+
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve users", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve users", err)
 		return
 	}
 
+	setPageLinks(w, r, nus.ResponsePerPage, nus.Next, nus.Prev)
+
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(nus); err != nil {
@@ -251,10 +289,10 @@ func (u Profiles) GetAll(w http.ResponseWriter, r *http.Request, params map[stri
 				"Authorization":"Bearer <TOKEN>",
 			}
 
-			WHERE: <TOKEN> = <USERID>:<SESSIONTOKEN>
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
 
 	Request:
-		Path: /profiles/
+		Path: /profiles
 		Body:
 			{
 				"first_name":"",
@@ -275,12 +313,13 @@ func (u Profiles) GetAll(w http.ResponseWriter, r *http.Request, params map[stri
 				"address":"",
 			}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 		Body:
 			{
 				"status":"",
 				"title":"",
-				"message":"",
+				"detail":"",
+				"fields":{},
 			}
 */
 func (u Profiles) Create(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -301,11 +340,11 @@ func (u Profiles) Create(w http.ResponseWriter, r *http.Request, params map[stri
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
 		return
 	}
 
-	existingUser, err := u.Users.Get(nw.UserID)
+	existingUser, err := u.Users.GetCtx(r.Context(), nw.UserID)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
@@ -313,18 +352,18 @@ func (u Profiles) Create(w http.ResponseWriter, r *http.Request, params map[stri
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to get user for profile", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to get user for profile", err)
 		return
 	}
 
-	newProfile, err := u.Profiles.Create(existingUser, &nw)
+	newProfile, err := u.Profiles.CreateCtx(r.Context(), existingUser, &nw)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to save new user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to save new user", err)
 		return
 	}
 
@@ -349,10 +388,10 @@ func (u Profiles) Create(w http.ResponseWriter, r *http.Request, params map[stri
 				"Authorization":"Bearer <TOKEN>",
 			}
 
-			WHERE: <TOKEN> = <USERID>:<SESSIONTOKEN>
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
 
 	Request:
-		Path: /profile/:public_id
+		Path: /profiles/:public_id
 		Body:
 			{
 				"first_name":"",
@@ -365,12 +404,13 @@ func (u Profiles) Create(w http.ResponseWriter, r *http.Request, params map[stri
    Response: (Success, 201)
 	Body: None
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Profiles) Update(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -389,7 +429,7 @@ func (u Profiles) Update(w http.ResponseWriter, r *http.Request, params map[stri
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
@@ -404,7 +444,7 @@ func (u Profiles) Update(w http.ResponseWriter, r *http.Request, params map[stri
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
 		return
 	}
 
@@ -415,18 +455,17 @@ func (u Profiles) Update(w http.ResponseWriter, r *http.Request, params map[stri
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to connect to database", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if err := u.Profiles.Update(nw); err != nil {
-		err := errors.New("Failed to update user details")
+	if err := u.Profiles.UpdateCtx(r.Context(), nw); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to connect to database", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to update profile", err)
 		return
 	}
 
@@ -441,21 +480,22 @@ func (u Profiles) Update(w http.ResponseWriter, r *http.Request, params map[stri
 				"Authorization":"Bearer <TOKEN>",
 			}
 
-			WHERE: <TOKEN> = <USERID>:<SESSIONTOKEN>
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
 
 	Request:
-		Path: /profile/:public_id
+		Path: /profiles/:public_id
 		Body: None
 
    Response: (Success, 201)
 		Body: None
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Profiles) Delete(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -474,17 +514,17 @@ func (u Profiles) Delete(w http.ResponseWriter, r *http.Request, params map[stri
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read param", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
-	if err := u.Profiles.Delete(profileID); err != nil {
+	if err := u.Profiles.DeleteCtx(r.Context(), profileID); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to delete user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to delete user", err)
 		return
 	}
 
@@ -0,0 +1,160 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/handlers"
+)
+
+// fieldKind defines how the query-string values of a filterable field are parsed.
+type fieldKind int
+
+// contains the supported kinds of filterable fields.
+const (
+	stringField fieldKind = iota
+	timeField
+)
+
+// userFilters contains the fields of a user which can be filtered and sorted on.
+var userFilters = map[string]fieldKind{
+	"email":      stringField,
+	"public_id":  stringField,
+	"created_at": timeField,
+	"updated_at": timeField,
+}
+
+// parseQuery returns the db.Query described by the query-string values, allowing only
+// the provided fields. Filters take the form field=value for equality or
+// field[op]=value for any other db.Operator, where "in" takes a comma separated list,
+// "null" takes true or false and time fields take RFC3339 values. The sort parameter
+// takes a comma separated list of fields, each prefixed with "-" for descending order,
+// while limit and offset take positive integers.
+func parseQuery(values url.Values, fields map[string]fieldKind) (db.Query, error) {
+	var query db.Query
+	invalid := handlers.ValidationError{Fields: make(map[string]string)}
+
+	for key, list := range values {
+		value := list[len(list)-1]
+
+		switch key {
+		case "limit", "offset":
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 0 {
+				invalid.Fields[key] = "must be a positive integer"
+				continue
+			}
+
+			if key == "limit" {
+				query.Limit = number
+			} else {
+				query.Offset = number
+			}
+
+			continue
+
+		case "sort":
+			for _, field := range strings.Split(value, ",") {
+				order := db.Order{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+
+				if _, ok := fields[order.Field]; !ok {
+					invalid.Fields[key] = fmt.Sprintf("can not sort on %q", order.Field)
+					continue
+				}
+
+				query.Order = append(query.Order, order)
+			}
+
+			continue
+		}
+
+		field, op := key, db.Eq
+
+		if index := strings.Index(key, "["); index != -1 && strings.HasSuffix(key, "]") {
+			field, op = key[:index], db.Operator(key[index+1:len(key)-1])
+		}
+
+		kind, ok := fields[field]
+		if !ok {
+			invalid.Fields[key] = "is not a filterable field"
+			continue
+		}
+
+		predicate, err := parsePredicate(field, op, value, kind)
+		if err != nil {
+			invalid.Fields[key] = err.Error()
+			continue
+		}
+
+		query.Where = append(query.Where, predicate)
+	}
+
+	if len(invalid.Fields) != 0 {
+		return db.Query{}, invalid
+	}
+
+	return query, nil
+}
+
+// parsePredicate returns the db.Predicate for a single filter.
+func parsePredicate(field string, op db.Operator, value string, kind fieldKind) (db.Predicate, error) {
+	switch op {
+	case db.IsNull:
+		null, err := strconv.ParseBool(value)
+		if err != nil {
+			return db.Predicate{}, errors.New("must be true or false")
+		}
+
+		return db.Where(field, op, null), nil
+
+	case db.Like:
+		if kind != stringField {
+			return db.Predicate{}, errors.New("does not support like")
+		}
+
+		return db.Where(field, op, value), nil
+
+	case db.In:
+		var items []interface{}
+
+		for _, item := range strings.Split(value, ",") {
+			parsed, err := parseValue(item, kind)
+			if err != nil {
+				return db.Predicate{}, err
+			}
+
+			items = append(items, parsed)
+		}
+
+		return db.Where(field, op, items), nil
+
+	case db.Eq, db.Ne, db.Lt, db.Lte, db.Gt, db.Gte:
+		parsed, err := parseValue(value, kind)
+		if err != nil {
+			return db.Predicate{}, err
+		}
+
+		return db.Where(field, op, parsed), nil
+	}
+
+	return db.Predicate{}, fmt.Errorf("has unknown operator %q", op)
+}
+
+// parseValue returns the value of a filter as the giving kind.
+func parseValue(value string, kind fieldKind) (interface{}, error) {
+	if kind == timeField {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.New("must be a RFC3339 time")
+		}
+
+		return parsed.UTC(), nil
+	}
+
+	return value, nil
+}
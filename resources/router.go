@@ -0,0 +1,181 @@
+package resources
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// HandlerFunc defines the function signature of all resource handlers, which receive
+// the path params of the request.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request, params map[string]string)
+
+// paramsKey defines the context key for the path params of a request.
+type paramsKey struct{}
+
+// Params returns the path params stored in the request context by a Router. This allows
+// plain http.Handlers mounted with Router.Handler to retrieve them.
+func Params(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params
+}
+
+// Router defines a http.Handler which dispatches requests to the HandlerFunc registered
+// for the request method and a path pattern such as /users/:user_id, where segments
+// starting with ":" match any single segment and are delivered as params.
+type Router struct {
+	routes []route
+
+	// NotFound handles requests which match no route, defaulting to http.NotFound.
+	NotFound http.Handler
+}
+
+// route defines a single pattern registered with a Router.
+type route struct {
+	method   string
+	segments []string
+	statics  int
+	handler  HandlerFunc
+}
+
+// NewRouter returns a new instance of Router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers the HandlerFunc for the giving method and path pattern.
+func (rt *Router) Handle(method string, pattern string, handler HandlerFunc) {
+	segments := splitPath(pattern)
+
+	var statics int
+
+	for _, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			statics++
+		}
+	}
+
+	rt.routes = append(rt.routes, route{
+		method:   strings.ToUpper(method),
+		segments: segments,
+		statics:  statics,
+		handler:  handler,
+	})
+}
+
+// Handler registers the http.Handler for the giving method and path pattern. The path
+// params of a request are retrieved with Params.
+func (rt *Router) Handler(method string, pattern string, handler http.Handler) {
+	rt.Handle(method, pattern, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// ServeHTTP implements the http.Handler interface. Requests whoes path matches a pattern
+// registered for another method are responded to with 405 Method Not Allowed.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.EscapedPath())
+
+	var matched *route
+	var matchedParams map[string]string
+	var allowed []string
+
+	for index := range rt.routes {
+		rn := &rt.routes[index]
+
+		params, ok := rn.match(segments)
+		if !ok {
+			continue
+		}
+
+		if rn.method != r.Method {
+			if !contains(allowed, rn.method) {
+				allowed = append(allowed, rn.method)
+			}
+
+			continue
+		}
+
+		// The route with the most static segments wins, so /users/password/:user_id
+		// is chosen over /users/:total/:page.
+		if matched == nil || rn.statics > matched.statics {
+			matched = rn
+			matchedParams = params
+		}
+	}
+
+	if matched == nil {
+		if len(allowed) != 0 {
+			sort.Strings(allowed)
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		if rt.NotFound != nil {
+			rt.NotFound.ServeHTTP(w, r)
+			return
+		}
+
+		http.NotFound(w, r)
+		return
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), paramsKey{}, matchedParams))
+
+	matched.handler(w, r, matchedParams)
+}
+
+// match returns the params of the giving path segments if they match the route.
+func (rn *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rn.segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+
+	for index, segment := range rn.segments {
+		if strings.HasPrefix(segment, ":") {
+			value, err := url.PathUnescape(segments[index])
+			if err != nil {
+				return nil, false
+			}
+
+			params[segment[1:]] = value
+			continue
+		}
+
+		if segment != segments[index] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// splitPath returns the segments of the giving path, ignoring leading, trailing and
+// repeated slashes.
+func splitPath(path string) []string {
+	var segments []string
+
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments
+}
+
+// contains reports if the giving value is within the list.
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package resources
+
+import (
+	"errors"
+	"net/http"
+	"path"
+)
+
+// Routes defines the resources mounted by Mount, where any nil resource has it's routes
+// skipped. All routes documented on the resources as requiring an Authorization header,
+// including all admin routes, are guarded by Auth. Admin routes require permissions of
+// any scope from the Policy of Auth, while routes reading, updating or deleting the records
+// of a user require the permissions of self scope, granted to the user owning the records.
+// The handlers of guarded routes recheck the permissions against the handlers.Principal
+// attached to the request context by Auth.
+type Routes struct {
+	// Prefix is prepended to all routes, eg "/api" gives "/api/users".
+	Prefix string
+
+	// AdminPrefix is prepended to all admin routes after Prefix, defaulting to "/admin".
+	AdminPrefix string
+
+	Auth          *Auth
+	Users         *Users
+	Profiles      *Profiles
+	Sessions      *Sessions
+	Verifications *Verifications
+}
+
+// NewServer returns a http.Handler serving all routes of the provided Routes.
+func NewServer(routes Routes) (http.Handler, error) {
+	router := NewRouter()
+
+	if err := Mount(router, routes); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// Mount registers all routes of the provided Routes with the router.
+func Mount(router *Router, routes Routes) error {
+	if routes.Auth == nil && (routes.Users != nil || routes.Profiles != nil || routes.Sessions != nil) {
+		return errors.New("Routes.Auth is required to guard mounted routes")
+	}
+
+	adminPrefix := routes.AdminPrefix
+	if adminPrefix == "" {
+		adminPrefix = "/admin"
+	}
+
+	public := func(method string, pattern string, handler HandlerFunc) {
+		router.Handle(method, path.Join("/", routes.Prefix, pattern), handler)
+	}
+
+	permitted := func(method string, pattern string, permission string, owner Owner, handler HandlerFunc) {
+		auth := routes.Auth.Require(permission, owner)
+		auth.Next = handler
+
+		router.Handle(method, path.Join("/", routes.Prefix, pattern), auth.CheckAuthorization)
+	}
+
+	guarded := func(method string, pattern string, handler HandlerFunc) {
+		permitted(method, pattern, "", nil, handler)
+	}
+
+	admin := func(method string, pattern string, permission string, handler HandlerFunc) {
+		permitted(method, path.Join(adminPrefix, pattern), permission, nil, handler)
+	}
+
+	if users := routes.Users; users != nil {
+		owner := OwnerParam("user_id")
+
+		public(http.MethodPost, "/users", users.Create)
+		public(http.MethodGet, "/users/:user_id", users.GetLimited)
+		permitted(http.MethodPut, "/users/:user_id", "users:update:self", owner, users.Update)
+		permitted(http.MethodPut, "/users/password/:user_id", "users:update:self", owner, users.UpdatePassword)
+		permitted(http.MethodDelete, "/users/:user_id", "users:delete:self", owner, users.Delete)
+		admin(http.MethodGet, "/users", "users:read:any", users.GetAll)
+		admin(http.MethodGet, "/users/:total/:page", "users:read:any", users.GetAll)
+		admin(http.MethodGet, "/users/:user_id", "users:read:any", users.Get)
+
+		if users.Users.Resets != nil {
+			public(http.MethodPost, "/users/password/reset", users.RequestPasswordReset)
+			public(http.MethodPost, "/users/password/reset/confirm", users.ResetPassword)
+		}
+	}
+
+	if profiles := routes.Profiles; profiles != nil {
+		guarded(http.MethodPost, "/profiles", profiles.Create)
+		permitted(http.MethodGet, "/profiles/:public_id", "profiles:read:self", profiles.owner, profiles.Get)
+		permitted(http.MethodGet, "/profiles/users/:user_id", "profiles:read:self", OwnerParam("user_id"), profiles.GetForUser)
+		permitted(http.MethodPut, "/profiles/:public_id", "profiles:update:self", profiles.owner, profiles.Update)
+		permitted(http.MethodDelete, "/profiles/:public_id", "profiles:delete:self", profiles.owner, profiles.Delete)
+		admin(http.MethodGet, "/profiles", "profiles:read:any", profiles.GetAll)
+		admin(http.MethodGet, "/profiles/:total/:page", "profiles:read:any", profiles.GetAll)
+	}
+
+	if verifications := routes.Verifications; verifications != nil {
+		public(http.MethodGet, "/users/verify", verifications.Verify)
+		public(http.MethodPost, "/users/verify", verifications.Verify)
+		public(http.MethodPost, "/users/verify/resend", verifications.Resend)
+	}
+
+	if sessions := routes.Sessions; sessions != nil {
+		public(http.MethodPost, "/sessions/login", sessions.Login)
+		public(http.MethodPost, "/sessions/refresh", sessions.Refresh)
+		public(http.MethodPost, "/sessions/logout", sessions.LogoutWithJSON)
+		public(http.MethodDelete, "/sessions/logout", sessions.Logout)
+		permitted(http.MethodGet, "/sessions", "sessions:read:self", OwnerSelf, sessions.List)
+		permitted(http.MethodDelete, "/sessions", "sessions:delete:self", OwnerSelf, sessions.RevokeOthers)
+		permitted(http.MethodDelete, "/sessions/:session_id", "sessions:delete:self", OwnerSelf, sessions.Revoke)
+		admin(http.MethodGet, "/sessions", "sessions:read:any", sessions.GetAll)
+		admin(http.MethodGet, "/sessions/:total/:page", "sessions:read:any", sessions.GetAll)
+		admin(http.MethodGet, "/sessions/:user_id", "sessions:read:any", sessions.Get)
+	}
+
+	return nil
+}
@@ -0,0 +1,591 @@
+package resources_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/backoffice/db"
+	"github.com/influx6/backoffice/db/memory"
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/mail"
+	"github.com/influx6/backoffice/models/user"
+	"github.com/influx6/backoffice/resources"
+	"github.com/influx6/backoffice/utils"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+	"github.com/influx6/faux/tests"
+)
+
+var log = sink.New(sinks.Stdout{})
+
+// sessionSecret keys the hashes of session tokens issued within tests.
+var sessionSecret = []byte("session-secret")
+
+// TestServer validates the routes mounted by resources.NewServer.
+func TestServer(t *testing.T) {
+	store := memory.New()
+
+	usersTable := db.TableName{Name: "users"}
+	profilesTable := db.TableName{Name: "profiles"}
+	sessionsTable := db.TableName{Name: "sessions"}
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	profiles := handlers.ProfilesFactory(log, store, profilesTable)
+	sessions := handlers.SessionsFactory(log, store, sessionSecret, time.Hour, sessionsTable)
+
+	server, err := resources.NewServer(resources.Routes{
+		Prefix:   "/api",
+		Auth:     &resources.Auth{BearerAuth: handlers.BearerAuthFactory(log, store, sessionSecret, usersTable, profilesTable, sessionsTable, time.Hour)},
+		Users:    &resources.Users{Users: users},
+		Profiles: &resources.Profiles{Profiles: profiles, Users: users, Sessions: sessions},
+		Sessions: &resources.Sessions{Sessions: sessions, Users: users},
+	})
+	if err != nil {
+		tests.Failed("Should have successfully created server: %+q.", err)
+	}
+	tests.Passed("Should have successfully created server.")
+
+	nu, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"})
+	if err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	t.Logf("Given the need to validate mounted routes")
+	{
+		t.Log("\tWhen retrieving a user through a public route")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("GET", "/api/users/"+nu.PublicID, nil))
+
+			if res.Code != http.StatusOK {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully retrieved user.")
+			}
+			tests.Passed("Should have successfully retrieved user.")
+
+			var fields map[string]interface{}
+			if err := json.NewDecoder(res.Body).Decode(&fields); err != nil {
+				tests.Failed("Should have successfully decoded user: %+q.", err)
+			}
+			tests.Passed("Should have successfully decoded user.")
+
+			if fields["public_id"] != nu.PublicID {
+				tests.Failed("Should have retrieved user with user_id param.")
+			}
+			tests.Passed("Should have retrieved user with user_id param.")
+		}
+
+		t.Log("\tWhen retrieving a user through an admin route without authorization")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("GET", "/api/admin/users/"+nu.PublicID, nil))
+
+			if res.Code == http.StatusOK {
+				tests.Failed("Should have guarded admin route.")
+			}
+			tests.Passed("Should have guarded admin route.")
+		}
+
+		t.Log("\tWhen requesting a route with the wrong method")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("PATCH", "/api/users/"+nu.PublicID, nil))
+
+			if res.Code != http.StatusMethodNotAllowed {
+				tests.Failed("Should have responded with 405 Method Not Allowed.")
+			}
+			tests.Passed("Should have responded with 405 Method Not Allowed.")
+
+			if res.Header().Get("Allow") != "DELETE, GET, PUT" {
+				tests.Info("Recieved: %s", res.Header().Get("Allow"))
+				tests.Failed("Should have listed allowed methods.")
+			}
+			tests.Passed("Should have listed allowed methods.")
+		}
+
+		t.Log("\tWhen retrieving an unknown user")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("GET", "/api/users/unknown", nil))
+
+			if res.Code != http.StatusNotFound {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have responded with 404 Not Found.")
+			}
+			tests.Passed("Should have responded with 404 Not Found.")
+
+			if res.Header().Get("Content-Type") != "application/problem+json" {
+				tests.Failed("Should have responded with application/problem+json.")
+			}
+			tests.Passed("Should have responded with application/problem+json.")
+
+			var problem utils.Problem
+			if err := json.NewDecoder(res.Body).Decode(&problem); err != nil {
+				tests.Failed("Should have successfully decoded problem: %+q.", err)
+			}
+			tests.Passed("Should have successfully decoded problem.")
+
+			if problem.Status != http.StatusNotFound {
+				tests.Failed("Should have delivered status in problem.")
+			}
+			tests.Passed("Should have delivered status in problem.")
+		}
+
+		t.Log("\tWhen logging in with invalid credentials")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("POST", "/api/sessions/login", strings.NewReader(`{"email":"bob@guma.com","password":"Glowing-Ember-Coal-36"}`)))
+
+			if res.Code != http.StatusUnauthorized {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have responded with 401 Unauthorized.")
+			}
+			tests.Passed("Should have responded with 401 Unauthorized.")
+
+			unknown := httptest.NewRecorder()
+			server.ServeHTTP(unknown, httptest.NewRequest("POST", "/api/sessions/login", strings.NewReader(`{"email":"alice@guma.com","password":"Glowing-Ember-Coal-36"}`)))
+
+			if unknown.Code != http.StatusUnauthorized || unknown.Body.String() != res.Body.String() {
+				tests.Info("Recieved: %d %s", unknown.Code, unknown.Body.String())
+				tests.Failed("Should have responded the same for unknown email and wrong password.")
+			}
+			tests.Passed("Should have responded the same for unknown email and wrong password.")
+
+			if strings.Contains(res.Body.String(), "users") || strings.Contains(res.Body.String(), "match") {
+				tests.Info("Recieved: %s", res.Body.String())
+				tests.Failed("Should not have delivered the error of the handlers.")
+			}
+			tests.Passed("Should not have delivered the error of the handlers.")
+		}
+
+		t.Log("\tWhen logging in from two devices")
+		{
+			var tokens []string
+
+			for _, label := range []string{"laptop", "phone"} {
+				req := httptest.NewRequest("POST", "/api/sessions/login", strings.NewReader(`{"email":"bob@guma.com","password":"Glow-Worm-Lantern-42","label":"`+label+`"}`))
+				req.Header.Set("User-Agent", label+"-agent")
+
+				res := httptest.NewRecorder()
+				server.ServeHTTP(res, req)
+
+				if res.Code != http.StatusCreated {
+					tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+					tests.Failed("Should have successfully logged in from %q.", label)
+				}
+
+				var fields map[string]string
+				if err := json.NewDecoder(res.Body).Decode(&fields); err != nil {
+					tests.Failed("Should have successfully decoded session: %+q.", err)
+				}
+
+				tokens = append(tokens, fields["token"])
+			}
+			tests.Passed("Should have successfully logged in from two devices.")
+
+			list := httptest.NewRequest("GET", "/api/sessions", nil)
+			list.Header.Set("Authorization", "Bearer "+tokens[1])
+
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, list)
+
+			if res.Code != http.StatusOK {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully listed sessions through guarded route.")
+			}
+			tests.Passed("Should have successfully listed sessions through guarded route.")
+
+			var listed struct {
+				Records []map[string]interface{} `json:"records"`
+			}
+			if err := json.NewDecoder(res.Body).Decode(&listed); err != nil {
+				tests.Failed("Should have successfully decoded sessions: %+q.", err)
+			}
+			tests.Passed("Should have successfully decoded sessions.")
+
+			if len(listed.Records) != 2 || listed.Records[0]["label"] != "laptop" || listed.Records[0]["current"] != false || listed.Records[1]["current"] != true {
+				tests.Info("Sessions: %+v", listed.Records)
+				tests.Failed("Should have listed both sessions with the current one flagged.")
+			}
+			tests.Passed("Should have listed both sessions with the current one flagged.")
+
+			if _, ok := listed.Records[0]["token"]; ok {
+				tests.Failed("Should have not listed session tokens.")
+			}
+			tests.Passed("Should have not listed session tokens.")
+
+			others := httptest.NewRequest("DELETE", "/api/sessions", nil)
+			others.Header.Set("Authorization", "Bearer "+tokens[1])
+
+			res = httptest.NewRecorder()
+			server.ServeHTTP(res, others)
+
+			if res.Code != http.StatusOK {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully revoked other sessions.")
+			}
+			tests.Passed("Should have successfully revoked other sessions.")
+
+			revoked := httptest.NewRequest("GET", "/api/sessions", nil)
+			revoked.Header.Set("Authorization", "Bearer "+tokens[0])
+
+			res = httptest.NewRecorder()
+			server.ServeHTTP(res, revoked)
+
+			if res.Code != http.StatusUnauthorized {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have rejected revoked session.")
+			}
+			tests.Passed("Should have rejected revoked session.")
+
+			logout := httptest.NewRequest("DELETE", "/api/sessions/logout", nil)
+			logout.Header.Set("Authorization", "Bearer "+tokens[1])
+
+			res = httptest.NewRecorder()
+			server.ServeHTTP(res, logout)
+
+			if res.Code != http.StatusNoContent {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully logged out.")
+			}
+			tests.Passed("Should have successfully logged out.")
+		}
+
+		t.Log("\tWhen acting on records with the permissions of a user")
+		{
+			alice, err := users.Create(user.NewUser{Email: "alice@guma.com", Password: "Glow-Worm-Lantern-42"})
+			if err != nil {
+				tests.Failed("Should have successfully created another user: %+q.", err)
+			}
+			tests.Passed("Should have successfully created another user.")
+
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("POST", "/api/sessions/login", strings.NewReader(`{"email":"bob@guma.com","password":"Glow-Worm-Lantern-42"}`)))
+
+			var fields map[string]string
+			if err := json.NewDecoder(res.Body).Decode(&fields); err != nil {
+				tests.Failed("Should have successfully decoded session: %+q.", err)
+			}
+			tests.Passed("Should have successfully logged in.")
+
+			request := func(method string, target string, body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, target, strings.NewReader(body))
+				req.Header.Set("Authorization", "Bearer "+fields["token"])
+
+				res := httptest.NewRecorder()
+				server.ServeHTTP(res, req)
+
+				return res
+			}
+
+			if res := request("GET", "/api/admin/users/"+nu.PublicID, ""); res.Code != http.StatusForbidden {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have forbidden admin route to user without admin role.")
+			}
+			tests.Passed("Should have forbidden admin route to user without admin role.")
+
+			if res := request("PUT", "/api/users/"+alice.PublicID, `{"email":"mallory@guma.com"}`); res.Code != http.StatusForbidden {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have forbidden user to update another user.")
+			}
+			tests.Passed("Should have forbidden user to update another user.")
+
+			if res := request("GET", "/api/profiles/users/"+alice.PublicID, ""); res.Code != http.StatusForbidden {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have forbidden user to read the profile of another user.")
+			}
+			tests.Passed("Should have forbidden user to read the profile of another user.")
+
+			if res := request("GET", "/api/profiles/users/"+nu.PublicID, ""); res.Code != http.StatusOK {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have permitted user to read it's own profile.")
+			}
+			tests.Passed("Should have permitted user to read it's own profile.")
+
+			if res := request("PUT", "/api/users/password/"+nu.PublicID, `{"public_id":"`+nu.PublicID+`","password":"Grow-Fern-Garden-58"}`); res.Code != http.StatusUnprocessableEntity {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have required current password to change password.")
+			}
+			tests.Passed("Should have required current password to change password.")
+
+			if res := request("PUT", "/api/users/password/"+nu.PublicID, `{"public_id":"`+nu.PublicID+`","current_password":"Glow-Worm-Lantern-42","password":"Grow-Fern-Garden-58"}`); res.Code != http.StatusNoContent {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully changed password with current password.")
+			}
+			tests.Passed("Should have successfully changed password with current password.")
+
+			if err := users.SetRoles(user.UpdateUserRoles{PublicID: nu.PublicID, Roles: []string{handlers.RoleAdmin}}); err != nil {
+				tests.Failed("Should have successfully set admin role: %+q.", err)
+			}
+			tests.Passed("Should have successfully set admin role.")
+
+			res = request("GET", "/api/admin/users/"+alice.PublicID, "")
+			if res.Code != http.StatusOK {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have permitted admin route to user with admin role.")
+			}
+			tests.Passed("Should have permitted admin route to user with admin role.")
+
+			var admin map[string]interface{}
+			if err := json.NewDecoder(res.Body).Decode(&admin); err != nil {
+				tests.Failed("Should have successfully decoded user: %+q.", err)
+			}
+			tests.Passed("Should have successfully decoded user.")
+
+			if _, ok := admin["hash"]; ok || admin["private_id"] != nil {
+				tests.Failed("Should have left security fields out of admin view of user.")
+			}
+			tests.Passed("Should have left security fields out of admin view of user.")
+		}
+
+		t.Log("\tWhen creating a user without a password")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("POST", "/api/users", strings.NewReader(`{"email":"alice@guma.com"}`)))
+
+			if res.Code != http.StatusUnprocessableEntity {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have responded with 422 Unprocessable Entity.")
+			}
+			tests.Passed("Should have responded with 422 Unprocessable Entity.")
+
+			var problem utils.Problem
+			if err := json.NewDecoder(res.Body).Decode(&problem); err != nil {
+				tests.Failed("Should have successfully decoded problem: %+q.", err)
+			}
+			tests.Passed("Should have successfully decoded problem.")
+
+			if problem.Fields["password"] == "" {
+				tests.Failed("Should have delivered field errors in problem.")
+			}
+			tests.Passed("Should have delivered field errors in problem.")
+		}
+
+		t.Log("\tWhen requesting an unknown route")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("GET", "/users/"+nu.PublicID, nil))
+
+			if res.Code != http.StatusNotFound {
+				tests.Failed("Should have responded with 404 Not Found.")
+			}
+			tests.Passed("Should have responded with 404 Not Found.")
+		}
+	}
+}
+
+// mailbox implements mail.Mailer by holding sent messages.
+type mailbox struct {
+	messages []mail.Message
+}
+
+func (m *mailbox) Send(ctx context.Context, msg mail.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// TestServerVerifications validates the email verification routes mounted by resources.NewServer.
+func TestServerVerifications(t *testing.T) {
+	store := memory.New()
+	box := new(mailbox)
+
+	usersTable := db.TableName{Name: "users"}
+	profilesTable := db.TableName{Name: "profiles"}
+	sessionsTable := db.TableName{Name: "sessions"}
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	sessions := handlers.SessionsFactory(log, store, sessionSecret, time.Hour, sessionsTable)
+	verifications := handlers.VerificationsFactory(log, store, users, db.TableName{Name: "verifications"}, []byte("verification-secret"), time.Hour, box)
+	verifications.Link = "/api/users/verify?token=%s"
+
+	server, err := resources.NewServer(resources.Routes{
+		Prefix:        "/api",
+		Auth:          &resources.Auth{BearerAuth: handlers.BearerAuthFactory(log, store, sessionSecret, usersTable, profilesTable, sessionsTable, time.Hour)},
+		Users:         &resources.Users{Users: users, Verifications: &verifications},
+		Sessions:      &resources.Sessions{Sessions: sessions, Users: users, RequireVerified: true},
+		Verifications: &resources.Verifications{Verifications: verifications},
+	})
+	if err != nil {
+		tests.Failed("Should have successfully created server: %+q.", err)
+	}
+	tests.Passed("Should have successfully created server.")
+
+	login := func() int {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, httptest.NewRequest("POST", "/api/sessions/login", strings.NewReader(`{"email":"bob@guma.com","password":"Glow-Worm-Lantern-42"}`)))
+		return res.Code
+	}
+
+	t.Logf("Given the need to verify the emails of users through mounted routes")
+	{
+		t.Log("\tWhen creating a user")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("POST", "/api/users", strings.NewReader(`{"email":"bob@guma.com","password":"Glow-Worm-Lantern-42"}`)))
+
+			if res.Code != http.StatusCreated {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully created user.")
+			}
+			tests.Passed("Should have successfully created user.")
+
+			if len(box.messages) != 1 || box.messages[0].To != "bob@guma.com" {
+				tests.Info("Messages: %+v", box.messages)
+				tests.Failed("Should have mailed verification to new user.")
+			}
+			tests.Passed("Should have mailed verification to new user.")
+		}
+
+		t.Log("\tWhen logging in with an unverified email")
+		{
+			if code := login(); code != http.StatusForbidden {
+				tests.Info("Recieved: %d", code)
+				tests.Failed("Should have responded with 403 Forbidden.")
+			}
+			tests.Passed("Should have responded with 403 Forbidden.")
+		}
+
+		t.Log("\tWhen resending the verification")
+		{
+			for _, email := range []string{"bob@guma.com", "alice@guma.com"} {
+				res := httptest.NewRecorder()
+				server.ServeHTTP(res, httptest.NewRequest("POST", "/api/users/verify/resend", strings.NewReader(`{"email":"`+email+`"}`)))
+
+				if res.Code != http.StatusAccepted {
+					tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+					tests.Failed("Should have responded with 202 Accepted for %q.", email)
+				}
+			}
+			tests.Passed("Should have responded with 202 Accepted for known and unknown emails.")
+
+			if len(box.messages) != 2 {
+				tests.Info("Messages: %+v", box.messages)
+				tests.Failed("Should have mailed only known email.")
+			}
+			tests.Passed("Should have mailed only known email.")
+		}
+
+		t.Log("\tWhen verifying with the mailed link")
+		{
+			link := strings.TrimSpace(box.messages[1].Body)
+			link = link[strings.LastIndex(link, "\n")+1:]
+
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("GET", link, nil))
+
+			if res.Code != http.StatusOK {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully verified email.")
+			}
+			tests.Passed("Should have successfully verified email.")
+
+			if code := login(); code != http.StatusCreated {
+				tests.Info("Recieved: %d", code)
+				tests.Failed("Should have successfully logged in with verified email.")
+			}
+			tests.Passed("Should have successfully logged in with verified email.")
+
+			res = httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("GET", link, nil))
+
+			if res.Code != http.StatusUnprocessableEntity {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have refused to reuse link.")
+			}
+			tests.Passed("Should have refused to reuse link.")
+		}
+	}
+}
+
+// TestServerPasswordResets validates the password reset routes mounted by resources.NewServer.
+func TestServerPasswordResets(t *testing.T) {
+	store := memory.New()
+	box := new(mailbox)
+
+	usersTable := db.TableName{Name: "users"}
+	profilesTable := db.TableName{Name: "profiles"}
+	sessionsTable := db.TableName{Name: "sessions"}
+
+	sessions := handlers.SessionsFactory(log, store, sessionSecret, time.Hour, sessionsTable)
+
+	resets := handlers.PasswordResetsFactory(log, store, db.TableName{Name: "password_resets"}, []byte("reset-secret"), time.Hour, box, &sessions)
+
+	users := handlers.UsersFactory(log, store, usersTable, profilesTable)
+	users.Resets = &resets
+
+	server, err := resources.NewServer(resources.Routes{
+		Prefix:   "/api",
+		Auth:     &resources.Auth{BearerAuth: handlers.BearerAuthFactory(log, store, sessionSecret, usersTable, profilesTable, sessionsTable, time.Hour)},
+		Users:    &resources.Users{Users: users},
+		Sessions: &resources.Sessions{Sessions: sessions, Users: users},
+	})
+	if err != nil {
+		tests.Failed("Should have successfully created server: %+q.", err)
+	}
+	tests.Passed("Should have successfully created server.")
+
+	if _, err := users.Create(user.NewUser{Email: "bob@guma.com", Password: "Glow-Worm-Lantern-42"}); err != nil {
+		tests.Failed("Should have successfully created new user: %+q.", err)
+	}
+	tests.Passed("Should have successfully created new user.")
+
+	t.Logf("Given the need to reset forgotten passwords through mounted routes")
+	{
+		t.Log("\tWhen requesting a password reset")
+		{
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("POST", "/api/users/password/reset", strings.NewReader(`{"email":"bob@guma.com"}`)))
+
+			if res.Code != http.StatusAccepted {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have responded with 202 Accepted.")
+			}
+			tests.Passed("Should have responded with 202 Accepted.")
+
+			if len(box.messages) != 1 {
+				tests.Info("Messages: %+v", box.messages)
+				tests.Failed("Should have mailed password reset.")
+			}
+			tests.Passed("Should have mailed password reset.")
+		}
+
+		t.Log("\tWhen resetting the password with the mailed token")
+		{
+			body := strings.TrimSpace(box.messages[0].Body)
+			token := body[strings.LastIndex(body, "\n")+1:]
+
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("POST", "/api/users/password/reset/confirm", strings.NewReader(`{"token":"`+token+`","password":"Shine-Bright-Moon-17"}`)))
+
+			if res.Code != http.StatusNoContent {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully reset password.")
+			}
+			tests.Passed("Should have successfully reset password.")
+
+			res = httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("POST", "/api/sessions/login", strings.NewReader(`{"email":"bob@guma.com","password":"Shine-Bright-Moon-17"}`)))
+
+			if res.Code != http.StatusCreated {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have successfully logged in with new password.")
+			}
+			tests.Passed("Should have successfully logged in with new password.")
+
+			res = httptest.NewRecorder()
+			server.ServeHTTP(res, httptest.NewRequest("POST", "/api/users/password/reset/confirm", strings.NewReader(`{"token":"`+token+`","password":"Glare-Sun-Visor-93"}`)))
+
+			if res.Code != http.StatusUnprocessableEntity {
+				tests.Info("Recieved: %d %s", res.Code, res.Body.String())
+				tests.Failed("Should have refused to reuse token.")
+			}
+			tests.Passed("Should have refused to reuse token.")
+		}
+	}
+}
@@ -3,8 +3,11 @@ package resources
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/influx6/backoffice/handlers"
 	"github.com/influx6/backoffice/models/session"
@@ -17,11 +20,21 @@ import (
 type Sessions struct {
 	handlers.Sessions
 	Users handlers.Users
+
+	// RequireVerified refuses to log in users who have not verified their email.
+	RequireVerified bool
 }
 
-// Get handles receiving requests to get a sessions from the db.
+// Get handles receiving requests to get the sessions of a user from the db.
 /* Service API
 	HTTP Method: GET
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
 	Request:
 		Path: /admin/sessions/:user_id
 		Body: None
@@ -29,31 +42,38 @@ type Sessions struct {
    Response: (Success, 200)
 	Body:
 		{
-			"user_id":"",
-			"public_id":"",
-			"expires":"",
-			"token":"",
+			"records": [{
+				"user_id":"",
+				"public_id":"",
+				"expires":"",
+				"user_agent":"",
+				"ip":"",
+				"label":"",
+				"last_seen":"",
+				"created_at":"",
+			}],
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (s Sessions) Get(w http.ResponseWriter, r *http.Request, params map[string]string) {
-	defer s.Log.Emit(sinks.Info("Get Existing Session").WithFields(sink.Fields{
+	defer s.Log.Emit(sinks.Info("Get Existing Sessions").WithFields(sink.Fields{
 		"remote":  r.RemoteAddr,
 		"params":  params,
 		"path":    r.URL.Path,
 		"user_id": params["user_id"],
-	}).Trace("Sessions.Create").End())
+	}).Trace("Sessions.Get").End())
 
 	userID, ok := params["user_id"]
 	if !ok {
-		err := errors.New("Expected Session `public_id` as param")
+		err := errors.New("Expected Session `user_id` as param")
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":    r.URL.Path,
 			"remote":  r.RemoteAddr,
@@ -61,11 +81,11 @@ func (s Sessions) Get(w http.ResponseWriter, r *http.Request, params map[string]
 			"user_id": params["user_id"],
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
-	nu, err := s.Sessions.Get(userID)
+	nus, err := s.Sessions.ListByUserCtx(r.Context(), userID)
 	if err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":    r.URL.Path,
@@ -73,20 +93,257 @@ func (s Sessions) Get(w http.ResponseWriter, r *http.Request, params map[string]
 			"params":  params,
 			"user_id": params["user_id"],
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve user session", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve user sessions", err)
 		return
 	}
 
+	records := make([]map[string]interface{}, 0, len(nus))
+	for _, nu := range nus {
+		records = append(records, nu.SafeFields())
+	}
+
 	w.WriteHeader(http.StatusOK)
 
-	if err := json.NewEncoder(w).Encode(nu.Fields()); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"records": records}); err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":    r.URL.Path,
 			"remote":  r.RemoteAddr,
 			"params":  params,
 			"user_id": params["user_id"],
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to return new user data", err)
+		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to return user sessions", err)
+		return
+	}
+}
+
+// List handles receiving requests to get the sessions of the user of the request's
+// session, each flagged as current if it is the session of the request.
+/* Service API
+	HTTP Method: GET
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
+	Request:
+		Path: /sessions
+		Body: None
+
+   Response: (Success, 200)
+	Body:
+		{
+			"records": [{
+				"user_id":"",
+				"public_id":"",
+				"expires":"",
+				"user_agent":"",
+				"ip":"",
+				"label":"",
+				"last_seen":"",
+				"created_at":"",
+				"current":true,
+			}],
+		}
+
+   Response: (Failure, 4xx/5xx, application/problem+json)
+	Body:
+		{
+			"status":"",
+			"title":"",
+			"detail":"",
+			"fields":{},
+		}
+*/
+func (s Sessions) List(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	defer s.Log.Emit(sinks.Info("List User Sessions").WithFields(sink.Fields{
+		"remote": r.RemoteAddr,
+		"params": params,
+		"path":   r.URL.Path,
+	}).Trace("Sessions.List").End())
+
+	current, err := s.Sessions.AuthorizeCtx(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to validate user's session", err)
+		return
+	}
+
+	nus, err := s.Sessions.ListByUserCtx(r.Context(), current.UserID)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve user sessions", err)
+		return
+	}
+
+	records := make([]map[string]interface{}, 0, len(nus))
+	for _, nu := range nus {
+		fields := nu.SafeFields()
+		fields["current"] = nu.PublicID == current.PublicID
+		records = append(records, fields)
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"records": records}); err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to return user sessions", err)
+		return
+	}
+}
+
+// Revoke handles receiving requests to end a session of the user of the request's session.
+/* Service API
+	HTTP Method: DELETE
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
+	Request:
+		Path: /sessions/:session_id
+		Body: None
+
+   Response: (Success, 204)
+		Body: None
+
+   Response: (Failure, 4xx/5xx, application/problem+json)
+	Body:
+		{
+			"status":"",
+			"title":"",
+			"detail":"",
+			"fields":{},
+		}
+*/
+func (s Sessions) Revoke(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	defer s.Log.Emit(sinks.Info("Revoke User Session").WithFields(sink.Fields{
+		"remote":     r.RemoteAddr,
+		"params":     params,
+		"path":       r.URL.Path,
+		"session_id": params["session_id"],
+	}).Trace("Sessions.Revoke").End())
+
+	sessionID, ok := params["session_id"]
+	if !ok {
+		err := errors.New("Expected Session `session_id` as param")
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
+		return
+	}
+
+	current, err := s.Sessions.AuthorizeCtx(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to validate user's session", err)
+		return
+	}
+
+	if err := s.Sessions.RevokeCtx(r.Context(), current.UserID, sessionID); err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to revoke user session", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOthers handles receiving requests to end all sessions of the user of the
+// request's session, except the session of the request.
+/* Service API
+	HTTP Method: DELETE
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
+	Request:
+		Path: /sessions
+		Body: None
+
+   Response: (Success, 200)
+	Body:
+		{
+			"revoked": 2,
+		}
+
+   Response: (Failure, 4xx/5xx, application/problem+json)
+	Body:
+		{
+			"status":"",
+			"title":"",
+			"detail":"",
+			"fields":{},
+		}
+*/
+func (s Sessions) RevokeOthers(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	defer s.Log.Emit(sinks.Info("Revoke Other User Sessions").WithFields(sink.Fields{
+		"remote": r.RemoteAddr,
+		"params": params,
+		"path":   r.URL.Path,
+	}).Trace("Sessions.RevokeOthers").End())
+
+	current, err := s.Sessions.AuthorizeCtx(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to validate user's session", err)
+		return
+	}
+
+	revoked, err := s.Sessions.RevokeOthersCtx(r.Context(), current.UserID, current.PublicID)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to revoke user sessions", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"revoked": revoked}); err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to return revoked sessions", err)
 		return
 	}
 }
@@ -94,11 +351,26 @@ func (s Sessions) Get(w http.ResponseWriter, r *http.Request, params map[string]
 // GetAll handles receiving requests to get all sessions from the db.
 /* Service API
 	HTTP Method: GET
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
 	Request:
-		Path: /admin/sessions/
+		Path: /admin/sessions
+		Path: /admin/sessions/:total/:page
+		Query: (Optional)
+			cursor=<CURSOR>&limit=<N>	pages by cursor, where limit overrides the :total param
+
+			WHERE: an empty cursor requests the first page of records ordered by their creation,
+			where the next and prev cursors of each page are returned in the body and Link header.
 		Body: None
 
    Response: (Success, 200)
+	Header:
+		Link: <NEXT_URL>; rel="next", <PREV_URL>; rel="prev"
 	Body:
 		{
 			page: 1,
@@ -108,16 +380,23 @@ func (s Sessions) Get(w http.ResponseWriter, r *http.Request, params map[string]
 				"user_id":"",
 				"public_id":"",
 				"expires":"",
-				"token":"",
-			}]
+				"user_agent":"",
+				"ip":"",
+				"label":"",
+				"last_seen":"",
+				"created_at":"",
+			}],
+			next: "<CURSOR>",
+			prev: "<CURSOR>",
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (s Sessions) GetAll(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -130,17 +409,32 @@ func (s Sessions) GetAll(w http.ResponseWriter, r *http.Request, params map[stri
 	responsePerPage, _ := strconv.Atoi(params[ResponsePerPageName])
 	page, _ := strconv.Atoi(params[PerPageName])
 
-	nus, err := s.Sessions.GetAll(page, responsePerPage)
+	var nus handlers.SessionRecords
+	var err error
+
+	if wantsCursor(r) {
+		var cursor string
+		var limit int
+
+		if cursor, limit, err = cursorPage(r, params); err == nil {
+			nus, err = s.Sessions.GetPageCtx(r.Context(), cursor, limit)
+		}
+	} else {
+		nus, err = s.Sessions.GetAllCtx(r.Context(), page, responsePerPage)
+	}
+
 	if err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve sessions", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve sessions", err)
 		return
 	}
 
+	setPageLinks(w, r, nus.ResponsePerPage, nus.Next, nus.Prev)
+
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(nus); err != nil {
@@ -155,6 +449,10 @@ func (s Sessions) GetAll(w http.ResponseWriter, r *http.Request, params map[stri
 }
 
 // Login handles receiving requests to create a new session for a user from the server.
+// Each login creates a session of it's own, recording the user agent and remote address
+// of the request along with the optional label naming the device. The token of the
+// session is issued by the TokenStrategy of the Sessions, and a refresh token is returned
+// along with the session if the Sessions issues refresh tokens.
 /* Service API
 	HTTP Method: POST
 	Request:
@@ -162,23 +460,28 @@ func (s Sessions) GetAll(w http.ResponseWriter, r *http.Request, params map[stri
 		Body:
 			{
 				"email": "",
-				"password": ""
+				"password": "",
+				"label": ""
 			}
 
    Response: (Success, 201)
 		Body:
 			{
 				"type":"Bearer",
+				"session_id":"",
 				"expires":"",
 				"token":"",
+				"refresh_token":"",
+				"refresh_expires":"",
 			}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		`{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (s Sessions) Login(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -199,11 +502,11 @@ func (s Sessions) Login(w http.ResponseWriter, r *http.Request, params map[strin
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
 		return
 	}
 
-	existingUser, err := s.Users.GetByEmail(nw.Email)
+	existingUser, err := s.Users.AuthenticateCtx(r.Context(), nw.Email, nw.Password)
 	if err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":       r.URL.Path,
@@ -212,11 +515,12 @@ func (s Sessions) Login(w http.ResponseWriter, r *http.Request, params map[strin
 			"user_email": nw.Email,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to find user with email", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to authenticate user", err)
 		return
 	}
 
-	if err := existingUser.Authenticate(nw.Password); err != nil {
+	if s.RequireVerified && !existingUser.Verified {
+		err := handlers.ErrUnverified
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":       r.URL.Path,
 			"remote":     r.RemoteAddr,
@@ -224,24 +528,54 @@ func (s Sessions) Login(w http.ResponseWriter, r *http.Request, params map[strin
 			"user_email": nw.Email,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusUnauthorized, "Invalid Credentials: Failed to authenticate user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to authenticate user", err)
+		return
+	}
+
+	newSession, err := s.Sessions.CreateCtx(r.Context(), existingUser, session.Device{
+		UserAgent: r.UserAgent(),
+		IP:        remoteIP(r),
+		Label:     nw.Label,
+	})
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to save new user", err)
 		return
 	}
 
-	newSession, err := s.Sessions.Create(existingUser)
+	fields, err := s.Sessions.TokenFields(newSession)
 	if err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to save new user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to issue session token", err)
 		return
 	}
 
+	if s.Sessions.Refreshable() {
+		refresh, err := s.Sessions.IssueRefreshCtx(r.Context(), newSession)
+		if err != nil {
+			s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+				"path":   r.URL.Path,
+				"remote": r.RemoteAddr,
+				"params": params,
+			}))
+			utils.WriteErrorMessage(w, statusOf(err), "Failed to issue refresh token", err)
+			return
+		}
+
+		withRefresh(fields, refresh)
+	}
+
 	w.WriteHeader(http.StatusCreated)
 
-	if err := json.NewEncoder(w).Encode(newSession.SessionFields()); err != nil {
+	if err := json.NewEncoder(w).Encode(fields); err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
@@ -252,26 +586,159 @@ func (s Sessions) Login(w http.ResponseWriter, r *http.Request, params map[strin
 	}
 }
 
-// LogoutWithJSON handles receiving requests to end a user session from the server.
+// Refresh handles receiving requests to exchange a refresh token for a new token of
+// it's session, along with a new refresh token replacing the exchanged one. Using an
+// exchanged refresh token again revokes the session and all it's refresh tokens. Stateless
+// tokens are issued with the current roles of the user.
 /* Service API
-	HTTP Method: DELETE
+	HTTP Method: POST
+	Request:
+		Path: /sessions/refresh
+		Body:
+			{
+				"refresh_token": ""
+			}
+
+   Response: (Success, 200)
+		Body:
+			{
+				"type":"Bearer",
+				"session_id":"",
+				"expires":"",
+				"token":"",
+				"refresh_token":"",
+				"refresh_expires":"",
+			}
+
+   Response: (Failure, 4xx/5xx, application/problem+json)
+	Body:
+		`{
+			"status":"",
+			"title":"",
+			"detail":"",
+			"fields":{},
+		}
+*/
+func (s Sessions) Refresh(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	defer s.Log.Emit(sinks.Info("Refresh Existing Session").WithFields(sink.Fields{
+		"remote": r.RemoteAddr,
+		"params": params,
+		"path":   r.URL.Path,
+	}).Trace("Sessions.Refresh").End())
+
+	var nw session.Refresh
+
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&nw); err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
+		return
+	}
+
+	if nw.RefreshToken == "" {
+		err := handlers.Invalid("refresh_token", "is required")
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to read body", err)
+		return
+	}
+
+	nus, refresh, err := s.Sessions.RefreshCtx(r.Context(), nw.RefreshToken)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to refresh user's session", err)
+		return
+	}
+
+	// Stateless tokens carry the roles of the user, which a refreshed session holds none of.
+	if s.Sessions.Stateless() {
+		users := s.Users
+		users.Profiles = nil
+
+		existingUser, err := users.GetCtx(r.Context(), nus.UserID)
+		if err != nil {
+			s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+				"path":   r.URL.Path,
+				"remote": r.RemoteAddr,
+				"params": params,
+			}))
+
+			utils.WriteErrorMessage(w, statusOf(err), "Failed to refresh user's session", err)
+			return
+		}
+
+		nus.Roles = existingUser.Roles
+	}
+
+	fields, err := s.Sessions.TokenFields(nus)
+	if err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to issue session token", err)
+		return
+	}
+
+	withRefresh(fields, refresh)
+
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(fields); err != nil {
+		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"params": params,
+		}))
+		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to return refreshed session", err)
+		return
+	}
+}
+
+// withRefresh adds the token and expiry of the refresh token to the fields of a session.
+func withRefresh(fields map[string]interface{}, refresh *session.RefreshToken) {
+	fields["refresh_token"] = refresh.EncodedToken()
+	fields["refresh_expires"] = refresh.Expires.Format(time.RFC3339)
+}
+
+// LogoutWithJSON handles receiving requests to end a user session from the server, where
+// token is the token returned on login.
+/* Service API
+	HTTP Method: POST
 	Request:
-		Path: /sessions/logout/
+		Path: /sessions/logout
 		Body:
 			{
 				"user_id": "",
 				"token": ""
 			}
 
-   Response: (Success, 201)
+   Response: (Success, 204)
 		Body: None
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		`{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (s Sessions) LogoutWithJSON(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -297,20 +764,12 @@ func (s Sessions) LogoutWithJSON(w http.ResponseWriter, r *http.Request, params
 		return
 	}
 
-	nus, err := s.Sessions.Get(nw.UserID)
-	if err != nil {
-		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"path":    r.URL.Path,
-			"remote":  r.RemoteAddr,
-			"params":  params,
-			"user_id": nw.UserID,
-		}))
-		utils.WriteErrorMessage(w, http.StatusUnauthorized, "Failed to retrieve user's session", err)
-		return
+	nus, err := s.Sessions.AuthorizeCtx(r.Context(), "Bearer "+nw.Token)
+	if err == nil && nus.UserID != nw.UserID {
+		err = fmt.Errorf("Session is not of user %q: %w", nw.UserID, handlers.ErrInvalidCredentials)
 	}
 
-	if !nus.ValidateToken(nw.Token) {
-		err := errors.New("Invalid User session tokens")
+	if err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":    r.URL.Path,
 			"remote":  r.RemoteAddr,
@@ -318,11 +777,11 @@ func (s Sessions) LogoutWithJSON(w http.ResponseWriter, r *http.Request, params
 			"user_id": nw.UserID,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusUnauthorized, "Failed to validate user's session", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to validate user's session", err)
 		return
 	}
 
-	if err := s.Sessions.Delete(nw.UserID); err != nil {
+	if err := s.Sessions.RevokeCtx(r.Context(), nus.UserID, nus.PublicID); err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":    r.URL.Path,
 			"remote":  r.RemoteAddr,
@@ -330,33 +789,37 @@ func (s Sessions) LogoutWithJSON(w http.ResponseWriter, r *http.Request, params
 			"user_id": nw.UserID,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to end user's session", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Logout handles receiving requests to end a user session from the server.
+// Logout handles receiving requests to end the user session of the request, leaving all
+// other sessions of the user intact.
 /* Service API
 	HTTP Method: DELETE
 	Header:
 			{
 				"Authorization":"Bearer <TOKEN>",
 			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
 	Request:
-		Path: /sessions/logout/
+		Path: /sessions/logout
 		Body: None
 
-   Response: (Success, 201)
+   Response: (Success, 204)
 		Body: None
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		`{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (s Sessions) Logout(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -375,86 +838,43 @@ func (s Sessions) Logout(w http.ResponseWriter, r *http.Request, params map[stri
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to authorization header", err)
-		return
-	}
-
-	// Retrieve authorization header.
-	authType, token, err := utils.ParseAuthorization(authorization)
-	if err != nil {
-		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
-			"path":          r.URL.Path,
-			"remote":        r.RemoteAddr,
-			"params":        params,
-		}))
-
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed decode authorization", err)
-		return
-	}
-
-	if authType != "Bearer" {
-		err := errors.New("Only `Bearer` Authorization supported")
-		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
-			"path":          r.URL.Path,
-			"remote":        r.RemoteAddr,
-			"params":        params,
-		}))
-
-		utils.WriteErrorMessage(w, http.StatusUnauthorized, "Failed decode authorization", err)
+		utils.WriteErrorMessage(w, http.StatusUnauthorized, "Failed to read authorization header", err)
 		return
 	}
 
-	// Retrieve Authorization UserID and Token.
-	sessionUserID, sessionToken, err := session.ParseToken(token)
+	nus, err := s.Sessions.AuthorizeCtx(r.Context(), authorization)
 	if err != nil {
-		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
-			"path":          r.URL.Path,
-			"remote":        r.RemoteAddr,
-			"params":        params,
-		}))
-
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed decode authorization", err)
-		return
-	}
-
-	nus, err := s.Sessions.Get(sessionUserID)
-	if err != nil {
-		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
-			"path":          r.URL.Path,
-			"remote":        r.RemoteAddr,
-			"params":        params,
-		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve user's session", err)
-		return
-	}
-
-	if !nus.ValidateToken(sessionToken) {
-		err := errors.New("Invalid User session tokens")
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-
-		utils.WriteErrorMessage(w, http.StatusUnauthorized, "Failed to validate user's session", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to validate user's session", err)
 		return
 	}
 
-	if err := s.Sessions.Delete(nus.PublicID); err != nil {
+	if err := s.Sessions.RevokeCtx(r.Context(), nus.UserID, nus.PublicID); err != nil {
 		s.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
-			"authorization": authorization,
-			"path":          r.URL.Path,
-			"remote":        r.RemoteAddr,
-			"params":        params,
+			"session_id": nus.PublicID,
+			"path":       r.URL.Path,
+			"remote":     r.RemoteAddr,
+			"params":     params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to end user's session", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// remoteIP returns the address of the client of the request without it's port. Proxy
+// headers such as X-Forwarded-For are not trusted, being set by any client.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
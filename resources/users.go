@@ -13,9 +13,11 @@ import (
 	"github.com/influx6/faux/sink/sinks"
 )
 
-// Users exposes a central handle for which requests are served to all requests.
+// Users exposes a central handle for which requests are served to all requests. If
+// Verifications is set, new users and users whoes email changes are mailed a verification.
 type Users struct {
 	handlers.Users
+	Verifications *handlers.Verifications
 }
 
 // GetLimited handles receiving requests to get a user from the db but returns a limited view of the user data.
@@ -34,12 +36,13 @@ type Users struct {
 			"email":"",
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Users) GetLimited(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -49,31 +52,35 @@ func (u Users) GetLimited(w http.ResponseWriter, r *http.Request, params map[str
 		"path":   r.URL.Path,
 	}).Trace("Users.Get").End())
 
-	publicID, ok := params["public_id"]
+	publicID, ok := params["user_id"]
 	if !ok {
-		err := errors.New("Expected User `public_id` as param")
+		err := errors.New("Expected User `user_id` as param")
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
-	nu, err := u.Users.Get(publicID)
+	nu, err := u.Users.GetCtx(r.Context(), publicID)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve user", err)
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(nu.SafeFields()); err != nil {
+	// The roles of users are only returned to those permitted to read them.
+	fields := nu.SafeFields()
+	delete(fields, "roles")
+
+	if err := json.NewEncoder(w).Encode(fields); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
@@ -84,9 +91,18 @@ func (u Users) GetLimited(w http.ResponseWriter, r *http.Request, params map[str
 	}
 }
 
-// Get handles receiving requests to get a users from the db.
+// Get handles receiving requests to get a users from the db, which requires the
+// users:read:any permission. Security based fields (hash, private_id) are excluded from
+// the response.
 /* Service API
 	HTTP Method: GET
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
 	Request:
 		Path: /admin/users/:user_id
 		Body: None
@@ -95,17 +111,17 @@ func (u Users) GetLimited(w http.ResponseWriter, r *http.Request, params map[str
 	Body:
 		{
 			"public_id":"",
-			"private_id":"",
-			"hash":"",
 			"email":"",
+			"roles":[],
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Users) Get(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -115,33 +131,33 @@ func (u Users) Get(w http.ResponseWriter, r *http.Request, params map[string]str
 		"path":   r.URL.Path,
 	}).Trace("Users.Get").End())
 
-	publicID, ok := params["public_id"]
+	publicID, ok := params["user_id"]
 	if !ok {
-		err := errors.New("Expected User `public_id` as param")
+		err := errors.New("Expected User `user_id` as param")
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
-	nu, err := u.Users.Get(publicID)
+	nu, err := u.Users.GetCtx(r.Context(), publicID)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve user", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 
-	if err := json.NewEncoder(w).Encode(nu.Fields()); err != nil {
+	if err := json.NewEncoder(w).Encode(nu.SafeFields()); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
@@ -152,15 +168,38 @@ func (u Users) Get(w http.ResponseWriter, r *http.Request, params map[string]str
 	}
 }
 
-// GetAll handles receiving requests to get all users from the db.
+// GetAll handles receiving requests to get all users from the db, which requires the
+// users:read:any permission.
 /* Service API
 	HTTP Method: GET
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
 	Request:
-		Path: /users
-		Path: /users/:responser_per_page/:page
+		Path: /admin/users
+		Path: /admin/users/:total/:page
+		Query: (Optional)
+			email=<VALUE>				equality, for any of email, public_id, created_at or updated_at
+			email[<OP>]=<VALUE>			where <OP> is one of ne, lt, lte, gt, gte, like, in or null
+			sort=-created_at,email		fields to order by, where "-" orders in descending order
+			limit=<N>&offset=<N>		overrides the :total and :page params
+			cursor=<CURSOR>&limit=<N>	pages by cursor, see below
+
+			WHERE: like takes a pattern such as %bob%, in takes a comma separated list, null takes
+			true or false and created_at and updated_at take RFC3339 times.
+
+			WHERE: an empty cursor requests the first page of records ordered by their creation,
+			where the next and prev cursors of each page are returned in the body and Link header.
+			Pages by cursor may be filtered but not sorted or offset.
 		Body: None
 
    Response: (Success, 200)
+	Header:
+		Link: <NEXT_URL>; rel="next", <PREV_URL>; rel="prev"
 	Body:
 		{
 			page: 1,
@@ -168,18 +207,20 @@ func (u Users) Get(w http.ResponseWriter, r *http.Request, params map[string]str
 			responsePerPage: 24,
 			records: [{
 				"public_id":"",
-				"private_id":"",
-				"hash":"",
 				"email":"",
-			}]
+				"roles":[],
+			}],
+			next: "<CURSOR>",
+			prev: "<CURSOR>",
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Users) GetAll(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -192,17 +233,36 @@ func (u Users) GetAll(w http.ResponseWriter, r *http.Request, params map[string]
 	responsePerPage, _ := strconv.Atoi(params[ResponsePerPageName])
 	page, _ := strconv.Atoi(params[PerPageName])
 
-	nus, err := u.Users.GetAll(page, responsePerPage)
+	var nus handlers.UserRecords
+	var err error
+
+	switch {
+	case wantsCursor(r):
+		nus, err = u.page(r, params)
+	case len(r.URL.Query()) == 0:
+		nus, err = u.Users.GetAllCtx(r.Context(), page, responsePerPage)
+	default:
+		nus, err = u.find(r, page, responsePerPage)
+	}
+
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to retrieve users", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to retrieve users", err)
 		return
 	}
 
+	setPageLinks(w, r, nus.ResponsePerPage, nus.Next, nus.Prev)
+
+	// Security based fields are left out of the records, as with Get.
+	for index := range nus.Records {
+		nus.Records[index].Hash = ""
+		nus.Records[index].PrivateID = ""
+	}
+
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(nus); err != nil {
@@ -216,11 +276,55 @@ func (u Users) GetAll(w http.ResponseWriter, r *http.Request, params map[string]
 	}
 }
 
-// Create handles receiving requests to create a user from the server.
+// find returns the users matching the query-string filters of the request, where the
+// page params are used unless the limit and offset are provided.
+func (u Users) find(r *http.Request, page int, responsePerPage int) (handlers.UserRecords, error) {
+	query, err := parseQuery(r.URL.Query(), userFilters)
+	if err != nil {
+		return handlers.UserRecords{}, err
+	}
+
+	if query.Limit == 0 && responsePerPage > 0 {
+		query.Limit = responsePerPage
+
+		if query.Offset == 0 && page > 1 {
+			query.Offset = (page - 1) * responsePerPage
+		}
+	}
+
+	return u.Users.FindCtx(r.Context(), query)
+}
+
+// page returns the page of users after the cursor of the request, which may be combined
+// with the query-string filters of find but not with sort or offset.
+func (u Users) page(r *http.Request, params map[string]string) (handlers.UserRecords, error) {
+	cursor, limit, err := cursorPage(r, params)
+	if err != nil {
+		return handlers.UserRecords{}, err
+	}
+
+	values := r.URL.Query()
+	values.Del(CursorName)
+	values.Del(LimitName)
+
+	query, err := parseQuery(values, userFilters)
+	if err != nil {
+		return handlers.UserRecords{}, err
+	}
+
+	if len(query.Order) != 0 || query.Offset != 0 {
+		return handlers.UserRecords{}, handlers.Invalid(CursorName, "can not be used with sort or offset")
+	}
+
+	return u.Users.GetPageCtx(r.Context(), cursor, limit, query.Where...)
+}
+
+// Create handles receiving requests to create a user from the server, which is mailed a
+// verification of it's email if Verifications is set.
 /* Service API
 	HTTP Method: POST
 	Request:
-		Path: /users/
+		Path: /users
 		Body:
 		{
 			"password":"",
@@ -232,15 +336,17 @@ func (u Users) GetAll(w http.ResponseWriter, r *http.Request, params map[string]
 		{
 			"public_id":"",
 			"email":"",
+			"verified":false,
 			"profile":"optional",
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Users) Create(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -261,21 +367,32 @@ func (u Users) Create(w http.ResponseWriter, r *http.Request, params map[string]
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
 		return
 	}
 
-	newUser, err := u.Users.Create(nw)
+	newUser, err := u.Users.CreateCtx(r.Context(), nw)
 	if err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to save new user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to save new user", err)
 		return
 	}
 
+	// The user is created regardless of the mail, which can be resent.
+	if u.Verifications != nil {
+		if err := u.Verifications.SendCtx(r.Context(), newUser); err != nil {
+			u.Log.Emit(sinks.Error("Failed to send verification: %+q", err).WithFields(sink.Fields{
+				"path":    r.URL.Path,
+				"remote":  r.RemoteAddr,
+				"user_id": newUser.PublicID,
+			}))
+		}
+	}
+
 	w.WriteHeader(http.StatusCreated)
 
 	if err := json.NewEncoder(w).Encode(newUser.SafeFields()); err != nil {
@@ -290,13 +407,23 @@ func (u Users) Create(w http.ResponseWriter, r *http.Request, params map[string]
 }
 
 // UpdatePassword handles receiving requests to update a user identified by it's public_id.
+// A user changing it's own password must provide it's current password, which is not
+// needed by users granted users:update:any changing the password of another user.
 /* Service API
 	HTTP Method: PUT
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
 	Request:
 		Path: /users/password/:user_id
 		Body: None
 			{
 				"public_id":"",
+				"current_password":"",
 				"password":"",
 				"password_confirmation":"",
 			}
@@ -304,12 +431,13 @@ func (u Users) Create(w http.ResponseWriter, r *http.Request, params map[string]
    Response: (Success, 201)
 		Body: None
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Users) UpdatePassword(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -319,16 +447,16 @@ func (u Users) UpdatePassword(w http.ResponseWriter, r *http.Request, params map
 		"path":   r.URL.Path,
 	}).Trace("Users.UpdatePassword").End())
 
-	publicID, ok := params["public_id"]
+	publicID, ok := params["user_id"]
 	if !ok {
-		err := errors.New("Expected User `public_id` as param")
+		err := errors.New("Expected User `user_id` as param")
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
@@ -343,7 +471,7 @@ func (u Users) UpdatePassword(w http.ResponseWriter, r *http.Request, params map
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
 		return
 	}
 
@@ -354,27 +482,165 @@ func (u Users) UpdatePassword(w http.ResponseWriter, r *http.Request, params map
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to connect to database", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if err := u.Users.UpdatePassword(nw); err != nil {
+	if err := u.Users.UpdatePasswordCtx(r.Context(), nw); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to update user password: %+q", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to update user password", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Update handles receiving requests to update a user identified by it's public_id.
+// RequestPasswordReset handles receiving requests to mail a password reset to the user
+// with the email. It responds the same whether or not a user has the email.
+/* Service API
+	HTTP Method: POST
+	Request:
+		Path: /users/password/reset
+		Body:
+		{
+			"email":"",
+		}
+
+   Response: (Success, 202)
+		Body: None
+
+   Response: (Failure, 4xx/5xx, application/problem+json)
+	Body:
+		{
+			"status":"",
+			"title":"",
+			"detail":"",
+			"fields":{},
+		}
+*/
+func (u Users) RequestPasswordReset(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	defer u.Log.Emit(sinks.Info("Request User Password Reset").WithFields(sink.Fields{
+		"remote": r.RemoteAddr,
+		"path":   r.URL.Path,
+	}).Trace("Users.RequestPasswordReset").End())
+
+	var nw struct {
+		Email string `json:"email"`
+	}
+
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&nw); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
+		return
+	}
+
+	if nw.Email == "" {
+		err := handlers.Invalid("email", "is required")
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to request password reset", err)
+		return
+	}
+
+	if err := u.Users.RequestPasswordResetCtx(r.Context(), nw.Email); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to request password reset", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword handles receiving requests to set a new password with the token mailed
+// by RequestPasswordReset, which revokes all sessions of the user.
+/* Service API
+	HTTP Method: POST
+	Request:
+		Path: /users/password/reset/confirm
+		Body:
+		{
+			"token":"",
+			"password":"",
+		}
+
+   Response: (Success, 204)
+		Body: None
+
+   Response: (Failure, 4xx/5xx, application/problem+json)
+	Body:
+		{
+			"status":"",
+			"title":"",
+			"detail":"",
+			"fields":{},
+		}
+*/
+func (u Users) ResetPassword(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	defer u.Log.Emit(sinks.Info("Reset User Password").WithFields(sink.Fields{
+		"remote": r.RemoteAddr,
+		"path":   r.URL.Path,
+	}).Trace("Users.ResetPassword").End())
+
+	var nw struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&nw); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
+		return
+	}
+
+	if err := u.Users.ResetPasswordCtx(r.Context(), nw.Token, nw.Password); err != nil {
+		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to reset user password", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Update handles receiving requests to update a user identified by it's public_id. A
+// user whoes email changes is mailed a verification of it's new email if Verifications
+// is set.
 /* Service API
 	HTTP Method: PUT
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
 	Request:
 		Path: /users/:user_id
 		Body: None
@@ -386,12 +652,13 @@ func (u Users) UpdatePassword(w http.ResponseWriter, r *http.Request, params map
 			"email":"",
 		}
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Users) Update(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -401,16 +668,16 @@ func (u Users) Update(w http.ResponseWriter, r *http.Request, params map[string]
 		"path":   r.URL.Path,
 	}).Trace("Users.Update").End())
 
-	publicID, ok := params["public_id"]
+	publicID, ok := params["user_id"]
 	if !ok {
-		err := errors.New("Expected User `public_id` as param")
+		err := errors.New("Expected User `user_id` as param")
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
@@ -425,7 +692,7 @@ func (u Users) Update(w http.ResponseWriter, r *http.Request, params map[string]
 			"params": params,
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read body", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
 		return
 	}
 
@@ -436,27 +703,37 @@ func (u Users) Update(w http.ResponseWriter, r *http.Request, params map[string]
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to connect to database", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if err := u.Users.Update(nw); err != nil {
-		err := errors.New("Failed to update user details")
+	if err := u.Users.UpdateCtx(r.Context(), nw); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":   r.URL.Path,
 			"remote": r.RemoteAddr,
 			"params": params,
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to connect to database", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to update user", err)
 		return
 	}
 
+	if u.Verifications != nil {
+		u.reverify(r, nw.PublicID)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // Delete handles receiving requests to removes a user from the server.
 /* Service API
 	HTTP Method: DELETE
+	Header:
+			{
+				"Authorization":"Bearer <TOKEN>",
+			}
+
+			WHERE: <TOKEN> = <USERID>:<SESSIONID>:<SESSIONTOKEN>
+
 	Request:
 		Path: /users/:user_id
 		Body: None
@@ -464,12 +741,13 @@ func (u Users) Update(w http.ResponseWriter, r *http.Request, params map[string]
    Response: (Success, 201)
 		Body: None
 
-   Response: (Failure, 500)
+   Response: (Failure, 4xx/5xx, application/problem+json)
 	Body:
 		{
 			"status":"",
 			"title":"",
-			"message":"",
+			"detail":"",
+			"fields":{},
 		}
 */
 func (u Users) Delete(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -490,20 +768,40 @@ func (u Users) Delete(w http.ResponseWriter, r *http.Request, params map[string]
 			"user_id": params["user_id"],
 		}))
 
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to read param", err)
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read param", err)
 		return
 	}
 
-	if err := u.Users.Delete(userID); err != nil {
+	if err := u.Users.DeleteCtx(r.Context(), userID); err != nil {
 		u.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
 			"path":    r.URL.Path,
 			"remote":  r.RemoteAddr,
 			"params":  params,
 			"user_id": params["user_id"],
 		}))
-		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to delete user", err)
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to delete user", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// reverify mails a verification to the user with the giving public id if it is no longer
+// verified, where failures are only logged as the update succeeded.
+func (u Users) reverify(r *http.Request, publicID string) {
+	users := u.Users
+	users.Profiles = nil
+
+	nu, err := users.GetCtx(r.Context(), publicID)
+	if err == nil {
+		err = u.Verifications.SendCtx(r.Context(), nu)
+	}
+
+	if err != nil {
+		u.Log.Emit(sinks.Error("Failed to send verification: %+q", err).WithFields(sink.Fields{
+			"path":    r.URL.Path,
+			"remote":  r.RemoteAddr,
+			"user_id": publicID,
+		}))
+	}
+}
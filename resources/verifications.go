@@ -0,0 +1,169 @@
+package resources
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/influx6/backoffice/handlers"
+	"github.com/influx6/backoffice/utils"
+	"github.com/influx6/faux/sink"
+	"github.com/influx6/faux/sink/sinks"
+)
+
+// Verifications exposes a central handle for which requests verifying the emails of
+// users are served.
+type Verifications struct {
+	handlers.Verifications
+}
+
+// Verify handles receiving requests to verify the email of a user with the token mailed
+// to it, which is given as the token query param or within the body.
+/* Service API
+	HTTP Method: GET, POST
+	Request:
+		Path: /users/verify
+		Query: token=<TOKEN>
+		Body: (POST)
+		{
+			"token":"",
+		}
+
+   Response: (Success, 200)
+	Body:
+		{
+			"public_id":"",
+			"email":"",
+			"verified":true,
+		}
+
+   Response: (Failure, 4xx/5xx, application/problem+json)
+	Body:
+		{
+			"status":"",
+			"title":"",
+			"detail":"",
+			"fields":{},
+		}
+*/
+func (v Verifications) Verify(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	defer v.Log.Emit(sinks.Info("Verify User Email").WithFields(sink.Fields{
+		"remote": r.RemoteAddr,
+		"path":   r.URL.Path,
+	}).Trace("Verifications.Verify").End())
+
+	token := r.URL.Query().Get("token")
+
+	if r.Method == http.MethodPost {
+		var nw struct {
+			Token string `json:"token"`
+		}
+
+		defer r.Body.Close()
+
+		if err := json.NewDecoder(r.Body).Decode(&nw); err != nil {
+			v.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+				"path":   r.URL.Path,
+				"remote": r.RemoteAddr,
+			}))
+
+			utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
+			return
+		}
+
+		token = nw.Token
+	}
+
+	nu, err := v.Verifications.VerifyCtx(r.Context(), token)
+	if err != nil {
+		v.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to verify user email", err)
+		return
+	}
+
+	fields := nu.SafeFields()
+	delete(fields, "roles")
+
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(fields); err != nil {
+		v.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+		utils.WriteErrorMessage(w, http.StatusInternalServerError, "Failed to return verified user data", err)
+		return
+	}
+}
+
+// Resend handles receiving requests to mail a new verification to the user with the
+// email. It responds the same whether or not a user has the email.
+/* Service API
+	HTTP Method: POST
+	Request:
+		Path: /users/verify/resend
+		Body:
+		{
+			"email":"",
+		}
+
+   Response: (Success, 202)
+		Body: None
+
+   Response: (Failure, 4xx/5xx, application/problem+json)
+	Body:
+		{
+			"status":"",
+			"title":"",
+			"detail":"",
+			"fields":{},
+		}
+*/
+func (v Verifications) Resend(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	defer v.Log.Emit(sinks.Info("Resend User Verification").WithFields(sink.Fields{
+		"remote": r.RemoteAddr,
+		"path":   r.URL.Path,
+	}).Trace("Verifications.Resend").End())
+
+	var nw struct {
+		Email string `json:"email"`
+	}
+
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&nw); err != nil {
+		v.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, http.StatusBadRequest, "Failed to read body", err)
+		return
+	}
+
+	if nw.Email == "" {
+		err := handlers.Invalid("email", "is required")
+		v.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to resend verification", err)
+		return
+	}
+
+	if err := v.Verifications.ResendCtx(r.Context(), nw.Email); err != nil {
+		v.Log.Emit(sinks.Error(err).WithFields(sink.Fields{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		}))
+
+		utils.WriteErrorMessage(w, statusOf(err), "Failed to resend verification", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
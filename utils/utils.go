@@ -1,25 +1,79 @@
 package utils
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 )
 
+// Problem defines the application/problem+json body delivered for failed requests.
+type Problem struct {
+	Type   string            `json:"type"`
+	Title  string            `json:"title"`
+	Status int               `json:"status"`
+	Detail string            `json:"detail,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// FieldErrors defines an error which contains a message for each invalid field of
+// a request, which are delivered as the fields of a Problem.
+type FieldErrors interface {
+	error
+	FieldErrors() map[string]string
+}
+
+// problemDetails contains the fixed detail delivered for each client error status, which
+// the resources derive from the sentinel errors of the handlers.
+var problemDetails = map[int]string{
+	http.StatusBadRequest:          "The request could not be read.",
+	http.StatusUnauthorized:        "The credentials provided are invalid.",
+	http.StatusForbidden:           "The request is not permitted.",
+	http.StatusNotFound:            "The requested record was not found.",
+	http.StatusMethodNotAllowed:    "The method is not allowed for the requested route.",
+	http.StatusConflict:            "The record conflicts with an existing record.",
+	http.StatusUnprocessableEntity: "The request holds invalid fields.",
+	http.StatusInternalServerError: "The request could not be completed.",
+}
+
+// NewProblem returns a Problem for the giving status and error. The text of the error is
+// never delivered, as it may hold the names of tables, driver messages or reveal which
+// records exist, where the detail is fixed for each status and only the fields of an
+// error implementing FieldErrors are delivered.
+func NewProblem(status int, header string, err error) Problem {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  header,
+		Status: status,
+		Detail: problemDetails[status],
+	}
+
+	if problem.Detail == "" {
+		problem.Detail = http.StatusText(status)
+	}
+
+	var fields FieldErrors
+	if err != nil && status < http.StatusInternalServerError && errors.As(err, &fields) {
+		problem.Fields = fields.FieldErrors()
+	}
+
+	return problem
+}
+
 // ErrorMessage returns a string which contains a json value of a
 // given error message to be delivered.
 func ErrorMessage(status int, header string, err error) string {
-	return fmt.Sprintf(`{
-		"status": %d,
-		"title": %+q,
-		"message": %+q,
-	}`, status, header, err)
+	data, _ := json.Marshal(NewProblem(status, header, err))
+	return string(data)
 }
 
 // WriteErrorMessage writes the giving error message to the provided writer.
 func WriteErrorMessage(w http.ResponseWriter, status int, header string, err error) {
-	http.Error(w, ErrorMessage(status, header, err), status)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	fmt.Fprintln(w, ErrorMessage(status, header, err))
 }
 
 // ParseAuthorization returns the scheme and token of the Authorization string